@@ -0,0 +1,167 @@
+package traft
+
+// applyBatch is the committed logs that are ready to apply.
+type applyBatch struct {
+	// gen of the state machine these logs are applied to.
+	gen int64
+
+	// restore the state machine with this snapshot before applying recs.
+	restore *Snapshot
+
+	recs []*Record
+}
+
+// appliedReq tells Loop() what logs are applied.
+type appliedReq struct {
+	gen     int64
+	recs    []*Record
+	results [][]byte
+}
+
+// ApplyLoop applies committed logs to the state machine and makes a snapshot
+// when there are too many applied logs.
+// It is the only goroutine that calls StateMachine.
+func (tr *TRaft) ApplyLoop() {
+	for {
+		for {
+			n, ok := tr.applyOnce()
+			if !ok {
+				return
+			}
+			if n == 0 {
+				break
+			}
+		}
+
+		tr.maybeSnapshot()
+
+		select {
+		case <-tr.shutdown:
+			return
+		case <-tr.applyCh:
+		}
+	}
+}
+
+// applyOnce applies all logs that are ready.
+// It returns the number of applied logs and false if TRaft is stopped.
+func (tr *TRaft) applyOnce() (int, bool) {
+	rst := tr.queryOrStop("applicable", nil)
+	if rst == nil {
+		return 0, false
+	}
+
+	a := tr.applyLogs(rst.v.(*applyBatch))
+	if a == nil {
+		return 0, true
+	}
+
+	rst = tr.queryOrStop("applied", a)
+	if rst == nil {
+		return 0, false
+	}
+
+	return len(a.recs), true
+}
+
+// applyLogs restores the state machine if b has a snapshot, and applies the
+// logs in b.
+// It returns what to tell Loop() with hdlApplied(), or nil if there is no log
+// in b.
+// It must be called from ApplyLoop().
+func (tr *TRaft) applyLogs(b *applyBatch) *appliedReq {
+	if b.restore != nil {
+		err := tr.sm.Restore(b.restore.Data)
+		if err != nil {
+			lg.Panicw("fail to restore state machine", "err", err)
+		}
+	}
+
+	if len(b.recs) == 0 {
+		return nil
+	}
+
+	return &appliedReq{
+		gen:     b.gen,
+		recs:    b.recs,
+		results: applyDAG(tr.sm, b.recs, applyWorkers),
+	}
+}
+
+// notifyApply wakes up ApplyLoop() when there may be logs to apply.
+func (tr *TRaft) notifyApply() {
+	select {
+	case tr.applyCh <- struct{}{}:
+	default:
+	}
+}
+
+// applicableLogs returns committed logs whose dependencies are all applied or
+// will be applied before them.
+// It must be called from Loop().
+func (tr *TRaft) applicableLogs() *applyBatch {
+
+	me := tr.Status[tr.Id]
+
+	b := &applyBatch{
+		gen:     tr.applyGen,
+		restore: tr.snapshotToRestore,
+		recs:    []*Record{},
+	}
+	tr.snapshotToRestore = nil
+
+	will := me.Applied.Clone()
+	end := me.Committed.Len()
+
+	for lsn := will.Offset; lsn < end; lsn++ {
+		if will.Get(lsn) != 0 || me.Committed.Get(lsn) == 0 {
+			continue
+		}
+
+		r := tr.logs.Get(lsn)
+		if r.Empty() {
+			// It is committed because a later log overrides it.
+			// It will never be applied but be overridden.
+			continue
+		}
+
+		if !will.Includes(r.Depends) {
+			continue
+		}
+
+		b.recs = append(b.recs, r)
+
+		// overridden logs have no effect any more.
+		will.Union(r.Overrides)
+		will.Set(lsn)
+	}
+
+	return b
+}
+
+// hdlApplied updates Applied and sends results to proposers.
+// It must be called from Loop().
+func (tr *TRaft) hdlApplied(a *appliedReq) {
+
+	if a.gen != tr.applyGen {
+		// the state machine has been replaced by a snapshot.
+		lg.Infow("hdl-applied: stale generation", "gen", a.gen, "tr.applyGen", tr.applyGen)
+		return
+	}
+
+	me := tr.Status[tr.Id]
+
+	for i, r := range a.recs {
+		me.Applied.Union(r.Overrides)
+		me.Applied.Set(r.Seq)
+
+		tr.replyApplied(r.Seq, a.results[i])
+	}
+
+	// A proposed log overridden by another log is never applied.
+	for lsn := range tr.proposing {
+		if me.Applied.Get(lsn) != 0 {
+			tr.replyApplied(lsn, nil)
+		}
+	}
+}
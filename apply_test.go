@@ -0,0 +1,220 @@
+package traft
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// waitFor waits until cond, which is evaluated in Loop(), becomes true.
+func waitFor(ta *require.Assertions, tr *TRaft, cond func() bool) {
+	for i := 0; i < 1000; i++ {
+		ok := false
+		query(tr.actionCh, "func", func() error {
+			ok = cond()
+			return nil
+		})
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+	ta.Fail("timeout waiting for condition")
+}
+
+// recordSM is a StateMachine records what is applied.
+type recordSM struct {
+	mu      sync.Mutex
+	applied []int64
+}
+
+func (s *recordSM) Apply(r *Record) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.applied = append(s.applied, r.Seq)
+	return []byte(fmt.Sprintf("%d", r.Seq))
+}
+
+func (s *recordSM) Snapshot() ([]byte, error) { return nil, nil }
+func (s *recordSM) Restore(data []byte) error { return nil }
+
+func TestTRaft_applicableLogs(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	rec := func(lsn int64, depends, overrides *TailBitmap) *Record {
+		r := NewRecord(lid(1, 1), lsn, NewCmdI64("set", fmt.Sprintf("k%d", lsn), lsn))
+		r.Depends = depends
+		r.Overrides = overrides
+		return r
+	}
+
+	cases := []struct {
+		logs      []*Record
+		committed *TailBitmap
+		applied   *TailBitmap
+		want      []int64
+	}{
+		{
+			logs:      []*Record{},
+			committed: bm(0),
+			applied:   bm(0),
+			want:      []int64{},
+		},
+		{
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				rec(1, bm(0), bm(0, 1)),
+			},
+			committed: bm(2),
+			applied:   bm(0),
+			want:      []int64{0, 1},
+		},
+		{
+			// not committed
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				rec(1, bm(0), bm(0, 1)),
+				rec(2, bm(0), bm(0, 2)),
+			},
+			committed: bm(0, 0, 2),
+			applied:   bm(0),
+			want:      []int64{0, 2},
+		},
+		{
+			// depends on a not committed log
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				rec(1, bm(0), bm(0, 1)),
+				rec(2, bm(2), bm(0, 2)),
+			},
+			committed: bm(0, 0, 2),
+			applied:   bm(0),
+			want:      []int64{0},
+		},
+		{
+			// depends on a log applied in this batch
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				rec(1, bm(1), bm(0, 1)),
+			},
+			committed: bm(2),
+			applied:   bm(0),
+			want:      []int64{0, 1},
+		},
+		{
+			// applied
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				rec(1, bm(0), bm(0, 1)),
+				rec(2, bm(0), bm(0, 2)),
+			},
+			committed: bm(3),
+			applied:   bm(0, 1),
+			want:      []int64{0, 2},
+		},
+		{
+			// 1 is overridden by 2 and is never applied.
+			logs: []*Record{
+				rec(0, bm(0), bm(0, 0)),
+				{Seq: 1},
+				rec(2, bm(0), bm(0, 1, 2)),
+				rec(3, bm(2), bm(0, 3)),
+			},
+			committed: bm(4),
+			applied:   bm(1),
+			want:      []int64{2, 3},
+		},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501"})
+		me := tr.Status[1]
+
+		tr.appendLogs(c.logs...)
+		me.Committed = c.committed
+		me.Applied = c.applied
+
+		b := tr.applicableLogs()
+		got := []int64{}
+		for _, r := range b.recs {
+			got = append(got, r.Seq)
+		}
+		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_ApplyLoop(t *testing.T) {
+
+	ta := require.New(t)
+
+	id := int64(1)
+	sm := &recordSM{}
+	tr := NewTRaft(id, map[int64]string{1: ":5501"}, WithStateMachine(sm))
+	defer tr.Stop()
+
+	me := tr.Status[id]
+
+	for i := 0; i < 5; i++ {
+		tr.addlogs(fmt.Sprintf("k%d=%d", i%2, i))
+	}
+
+	finCh := make(chan *ProposeReply, 1)
+	tr.proposing[3] = &proposal{
+		committer:   me.VotedFor.Clone(),
+		waitApplied: true,
+		finCh:       finCh,
+	}
+
+	tr.StartMainLoop()
+
+	// not committed, nothing to apply
+	query(tr.actionCh, "func", func() error {
+		ta.Equal(NewTailBitmap(0), me.Applied)
+		return nil
+	})
+
+	query(tr.actionCh, "func", func() error {
+		return tr.leaderUpdateCommitted(me.VotedFor, []int64{0, 4})
+	})
+
+	reply := <-finCh
+	ta.Equal(&ProposeReply{
+		OK:        true,
+		Result:    []byte("3"),
+		Seq:       3,
+		Committer: NewLeaderId(0, 1),
+	}, reply)
+
+	waitFor(ta, tr, func() bool {
+		return me.Applied.Len() == 4
+	})
+
+	query(tr.actionCh, "func", func() error {
+		ta.Equal(NewTailBitmap(4), me.Applied)
+		ta.ElementsMatch([]int64{0, 1, 2, 3}, sm.applied)
+		ta.Empty(tr.proposing)
+		return nil
+	})
+
+	// an outdated apply is discarded.
+	query(tr.actionCh, "func", func() error {
+		tr.applyGen++
+		tr.hdlApplied(&appliedReq{
+			gen:     tr.applyGen - 1,
+			recs:    []*Record{tr.logs.Get(4)},
+			results: [][]byte{nil},
+		})
+		ta.Equal(NewTailBitmap(4), me.Applied)
+		return nil
+	})
+}
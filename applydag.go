@@ -0,0 +1,133 @@
+package traft
+
+import (
+	"math/bits"
+	"runtime"
+)
+
+// applyWorkers is the max number of logs being applied concurrently.
+var applyWorkers = runtime.NumCPU()
+
+// applyDAG applies recs with at most `workers` goroutines.
+//
+// recs must be sorted by Seq. A record is applied after all records in recs it
+// depends on or overrides. Records that do not interfere with each other are
+// applied concurrently.
+//
+// It returns the results in the same order as recs.
+func applyDAG(sm StateMachine, recs []*Record, workers int) [][]byte {
+
+	n := len(recs)
+	results := make([][]byte, n)
+
+	if workers <= 1 || n <= 1 {
+		for i, r := range recs {
+			results[i] = sm.Apply(r)
+		}
+		return results
+	}
+
+	idx := make(map[int64]int, n)
+	for i, r := range recs {
+		idx[r.Seq] = i
+	}
+
+	// dependents[j] are the records that must be applied after recs[j].
+	dependents := make([][]int, n)
+	// pending[i] is the number of records recs[i] is waiting for.
+	pending := make([]int, n)
+
+	// counted[j] == i+1 if recs[j] is already counted as a dependency of
+	// recs[i]: a record may be in both Depends and Overrides.
+	counted := make([]int, n)
+
+	for i, r := range recs {
+		dep := func(j int) {
+			if counted[j] == i+1 {
+				return
+			}
+			counted[j] = i + 1
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+		forEachInBatch(recs, idx, i, r.Depends, dep)
+		forEachInBatch(recs, idx, i, r.Overrides, dep)
+	}
+
+	todo := make(chan int, n)
+	done := make(chan int, n)
+
+	for i := range recs {
+		if pending[i] == 0 {
+			todo <- i
+		}
+	}
+
+	if workers > n {
+		workers = n
+	}
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range todo {
+				results[i] = sm.Apply(recs[i])
+				done <- i
+			}
+		}()
+	}
+
+	for k := 0; k < n; k++ {
+		j := <-done
+		for _, i := range dependents[j] {
+			pending[i]--
+			if pending[i] == 0 {
+				todo <- i
+			}
+		}
+	}
+	close(todo)
+
+	return results
+}
+
+// forEachInBatch calls fn with the index of every record before recs[i] that
+// is set in tb. idx maps the Seq of a record to its index in recs.
+//
+// Only the set bits of tb are walked, word by word. The cost does not grow
+// with the number of records in the batch a record does not depend on.
+func forEachInBatch(recs []*Record, idx map[int64]int, i int, tb *TailBitmap, fn func(j int)) {
+
+	if tb == nil {
+		return
+	}
+
+	// every bit before Offset is set.
+	for j := 0; j < i && recs[j].Seq < tb.Offset; j++ {
+		fn(j)
+	}
+
+	first, seq := recs[0].Seq, recs[i].Seq
+
+	wi := int64(0)
+	if first > tb.Offset {
+		wi = (first - tb.Offset) >> 6
+	}
+
+	for ; wi < int64(len(tb.Words)); wi++ {
+		base := tb.Offset + wi<<6
+		if base >= seq {
+			return
+		}
+
+		for w := tb.Words[wi]; w != 0; w &= w - 1 {
+			lsn := base + int64(bits.TrailingZeros64(w))
+			if lsn >= seq {
+				return
+			}
+
+			if j, ok := idx[lsn]; ok {
+				fn(j)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package traft
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// bitSet returns true if bit idx in tb is set. A nil tb has no bit set.
+func bitSet(tb *TailBitmap, idx int64) bool {
+	return tb != nil && tb.Get(idx) != 0
+}
+
+// orderSM checks that a record is applied after all those it depends on or
+// overrides.
+type orderSM struct {
+	mu      sync.Mutex
+	applied map[int64]bool
+	errs    []string
+}
+
+func (s *orderSM) Apply(r *Record) []byte {
+	s.mu.Lock()
+	for lsn := int64(0); lsn < r.Seq; lsn++ {
+		if (bitSet(r.Depends, lsn) || bitSet(r.Overrides, lsn)) && !s.applied[lsn] {
+			s.errs = append(s.errs, fmt.Sprintf("%d applied before %d", r.Seq, lsn))
+		}
+	}
+	s.mu.Unlock()
+
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+
+	s.mu.Lock()
+	s.applied[r.Seq] = true
+	s.mu.Unlock()
+
+	return []byte(fmt.Sprintf("%d", r.Seq))
+}
+
+func (s *orderSM) Snapshot() ([]byte, error) { return nil, nil }
+func (s *orderSM) Restore(data []byte) error { return nil }
+
+// randomLogs builds n records on a leader with random `set` on nkeys keys.
+func randomLogs(n, nkeys int) []*Record {
+	tr := NewTRaft(1, map[int64]string{1: ":5501"})
+	defer tr.Stop()
+
+	for i := 0; i < n; i++ {
+		tr.addlogs(fmt.Sprintf("k%d=%d", rand.Intn(nkeys), i))
+	}
+	return tr.allLogs()
+}
+
+func TestApplyDAG_order(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		n, nkeys, workers int
+		// recs before `from` are applied in a former batch.
+		from int
+	}{
+		{0, 1, 4, 0},
+		{1, 1, 4, 0},
+		{100, 1, 4, 0},
+		{100, 3, 4, 0},
+		{200, 50, 8, 0},
+		{200, 50, 1, 0},
+		// Depends of a record in the batch has Offset beyond the first one.
+		{200, 1, 4, 70},
+		{300, 3, 8, 130},
+	}
+
+	for i, c := range cases {
+		recs := randomLogs(c.n, c.nkeys)
+		sm := &orderSM{applied: map[int64]bool{}}
+		for _, r := range recs[:c.from] {
+			sm.applied[r.Seq] = true
+		}
+		recs = recs[c.from:]
+
+		results := applyDAG(sm, recs, c.workers)
+
+		ta.Empty(sm.errs, "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.n, len(sm.applied), "%d-th: case: %+v", i+1, c)
+		for j, r := range recs {
+			ta.Equal([]byte(fmt.Sprintf("%d", r.Seq)), results[j], "%d-th: case: %+v", i+1, c)
+		}
+	}
+}
+
+func TestApplyDAG_sameAsSerial(t *testing.T) {
+
+	ta := require.New(t)
+
+	for i := 0; i < 20; i++ {
+		recs := randomLogs(500, 1+rand.Intn(20))
+
+		serial := newKVState()
+		want := applyDAG(serial, recs, 1)
+
+		parallel := newKVState()
+		got := applyDAG(parallel, recs, 8)
+
+		ta.Equal(want, got, "%d-th: results", i+1)
+		ta.Equal(serial.kvs, parallel.kvs, "%d-th: state", i+1)
+	}
+}
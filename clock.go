@@ -0,0 +1,96 @@
+package traft
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is where TRaft gets time from.
+// Leases and timeouts are all measured with it, so that a test is able to
+// control time with a FakeClock.
+type Clock interface {
+	// Now returns the current time in nanoseconds, in the same unit as
+	// VoteExpireAt.
+	Now() int64
+
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() int64                             { return uSecondI64() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FakeClock is a Clock that moves only when Advance or Set is called.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    int64
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at int64
+	ch chan time.Time
+}
+
+// NewFakeClock creates a FakeClock starting at `now` nanoseconds.
+func NewFakeClock(now int64) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		at: c.now + int64(d),
+		ch: make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		t.ch <- time.Unix(0, c.now)
+		return t.ch
+	}
+
+	c.timers = append(c.timers, t)
+	return t.ch
+}
+
+// Advance moves the clock forward by d and fires timers that expire.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now() + int64(d))
+}
+
+// Set moves the clock to `now` and fires timers that expire.
+// The clock never goes backward.
+func (c *FakeClock) Set(now int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now < c.now {
+		return
+	}
+	c.now = now
+
+	// fire in time order
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at < c.timers[j].at
+	})
+
+	i := 0
+	for ; i < len(c.timers) && c.timers[i].at <= now; i++ {
+		c.timers[i].ch <- time.Unix(0, c.timers[i].at)
+	}
+	c.timers = c.timers[i:]
+}
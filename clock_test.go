@@ -0,0 +1,72 @@
+package traft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := NewFakeClock(100)
+	ta.Equal(int64(100), c.Now())
+
+	fired := func(ch <-chan time.Time) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	ta.True(fired(c.After(0)))
+
+	a := c.After(10)
+	b := c.After(20)
+
+	c.Advance(5)
+	ta.Equal(int64(105), c.Now())
+	ta.False(fired(a))
+	ta.False(fired(b))
+
+	c.Advance(5)
+	ta.True(fired(a))
+	ta.False(fired(b))
+
+	// never goes backward
+	c.Set(50)
+	ta.Equal(int64(110), c.Now())
+
+	c.Set(200)
+	ta.True(fired(b))
+}
+
+func TestTRaft_sleep_fakeClock(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := NewFakeClock(0)
+	tr := NewTRaft(1, map[int64]string{1: ":5501"}, WithClock(c))
+	defer tr.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		tr.sleep(time.Hour)
+		close(done)
+	}()
+
+	for i := 0; i < 100; i++ {
+		c.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		ta.Fail("sleep does not return after the fake clock passed an hour")
+	}
+}
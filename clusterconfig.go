@@ -1,6 +1,13 @@
 package traft
 
-import proto "github.com/gogo/protobuf/proto"
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
 
 func (cc *ClusterConfig) MaxPosition() int64 {
 	maxPos := int64(0)
@@ -32,13 +39,460 @@ func (cc *ClusterConfig) SortedReplicaInfos() []*ReplicaInfo {
 // check if a set of member is a quorum.
 // The set of member is a bitmap in which a `1` indicates a present member.
 // In this system, the position of `1` is ReplicaInfo.Position.
+// Only members at position 0 to 63 can be in v. See IsQuorumSet.
 func (cc *ClusterConfig) IsQuorum(v uint64) bool {
+	return cc.IsQuorumSet(MemberSet{v})
+}
+
+// IsVoteQuorum checks if a set of members is a quorum to elect a leader.
+// See IsQuorum.
+func (cc *ClusterConfig) IsVoteQuorum(v uint64) bool {
+	return cc.IsVoteQuorumSet(MemberSet{v})
+}
+
+// IsQuorumSet checks if a set of members is a quorum to commit a log.
+func (cc *ClusterConfig) IsQuorumSet(s MemberSet) bool {
+	if cc.QuorumRule != nil {
+		return cc.QuorumRule.isQuorum(s)
+	}
+	return isListedQuorum(cc.Quorums, s)
+}
+
+// IsVoteQuorumSet checks if a set of members is a quorum to elect a leader.
+func (cc *ClusterConfig) IsVoteQuorumSet(s MemberSet) bool {
+	if cc.VoteQuorumRule != nil {
+		return cc.VoteQuorumRule.isQuorum(s)
+	}
+	if len(cc.VoteQuorums) > 0 {
+		return isListedQuorum(cc.VoteQuorums, s)
+	}
+	return cc.IsQuorumSet(s)
+}
+
+// voteQuorums returns VoteQuorums, or Quorums if there is no VoteQuorums.
+func (cc *ClusterConfig) voteQuorums() []uint64 {
+	if len(cc.VoteQuorums) == 0 {
+		return cc.Quorums
+	}
+	return cc.VoteQuorums
+}
+
+// quorumRule returns QuorumRule, or a rule of the listed Quorums.
+func (cc *ClusterConfig) quorumRule() *QuorumRule {
+	if cc.QuorumRule != nil {
+		return cc.QuorumRule
+	}
+	return listedRule(cc.Quorums)
+}
+
+// voteQuorumRule returns the rule of quorums to elect a leader, the same way
+// IsVoteQuorumSet chooses.
+func (cc *ClusterConfig) voteQuorumRule() *QuorumRule {
+	if cc.VoteQuorumRule != nil {
+		return cc.VoteQuorumRule
+	}
+	if len(cc.VoteQuorums) > 0 {
+		return listedRule(cc.VoteQuorums)
+	}
+	return cc.quorumRule()
+}
+
+// hasRule returns true if any quorums of cc are described by a QuorumRule.
+func (cc *ClusterConfig) hasRule() bool {
+	return cc.QuorumRule != nil || cc.VoteQuorumRule != nil
+}
+
+// hasVoteQuorums returns true if cc has quorums to elect a leader other than
+// those to commit.
+func (cc *ClusterConfig) hasVoteQuorums() bool {
+	return len(cc.VoteQuorums) > 0 || cc.VoteQuorumRule != nil
+}
+
+// membersSet returns the set of all members.
+func (cc *ClusterConfig) membersSet() MemberSet {
+	s := MemberSet{}
+	for _, m := range cc.Members {
+		s.Add(m.Position)
+	}
+	return s
+}
+
+// setMajorityQuorums sets quorums to majorities of members. They are listed
+// in Quorums for a small cluster, or described by QuorumRule.
+func (cc *ClusterConfig) setMajorityQuorums() {
+	members := cc.membersSet()
+
+	if len(cc.Members) <= maxListedMembers && len(members) <= 1 {
+		cc.Quorums = buildMajorityQuorums(members.low())
+		cc.QuorumRule = nil
+	} else {
+		cc.Quorums = nil
+		cc.QuorumRule = majorityRule(members)
+	}
+}
+
+// checkNext checks if the cluster can change from cc to next through a joint
+// config: a member has the same position in both, and two members never share
+// a position.
+func (cc *ClusterConfig) checkNext(next *ClusterConfig) error {
+
+	if len(next.Members) == 0 {
+		return errors.Wrapf(ErrInvalidConfig, "no member")
+	}
+
+	byPos := map[int64]int64{}
+	for _, m := range cc.Members {
+		byPos[m.Position] = m.Id
+	}
+
+	nextPos := map[int64]int64{}
+	for id, m := range next.Members {
+		if m.Id != id {
+			return errors.Wrapf(ErrInvalidConfig, "member %d has id %d", id, m.Id)
+		}
+
+		if m.Position < 0 {
+			return errors.Wrapf(ErrInvalidConfig, "member %d position: %d", id, m.Position)
+		}
+
+		if other, ok := nextPos[m.Position]; ok {
+			return errors.Wrapf(ErrInvalidConfig, "member %d and %d at position %d",
+				other, id, m.Position)
+		}
+		nextPos[m.Position] = id
+
+		if other, ok := byPos[m.Position]; ok && other != id {
+			return errors.Wrapf(ErrInvalidConfig, "position %d is used by %d",
+				m.Position, other)
+		}
+
+		if prev, ok := cc.Members[id]; ok && prev.Position != m.Position {
+			return errors.Wrapf(ErrInvalidConfig, "member %d moves from position %d to %d",
+				id, prev.Position, m.Position)
+		}
+	}
+
+	return next.checkQuorums()
+}
+
+// checkQuorums checks if every quorum is a set of members, and every vote
+// quorum intersects every quorum. Otherwise a leader may be elected without
+// seeing a committed log.
+// Without VoteQuorums, it is every two quorums that must intersect.
+func (cc *ClusterConfig) checkQuorums() error {
+
+	if len(cc.Quorums) == 0 && cc.QuorumRule == nil {
+		return errors.Wrapf(ErrInvalidConfig, "no quorum")
+	}
+
+	if len(cc.Quorums) > 0 && cc.QuorumRule != nil ||
+		len(cc.VoteQuorums) > 0 && cc.VoteQuorumRule != nil {
+		return errors.Wrapf(ErrInvalidConfig, "both listed quorums and QuorumRule")
+	}
+
+	members := cc.membersSet()
+	for _, qs := range [][]uint64{cc.Quorums, cc.VoteQuorums} {
+		for _, q := range qs {
+			if q == 0 || q&members.low() != q {
+				return errors.Wrapf(ErrInvalidConfig, "quorum %b is not a subset of members %b",
+					q, members.low())
+			}
+		}
+	}
+
+	for _, r := range []*QuorumRule{cc.QuorumRule, cc.VoteQuorumRule} {
+		if r != nil {
+			err := r.check(members)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// A listed quorum intersects every quorum of the other kind, if members
+	// not in it are not a quorum of the other kind.
+	voteListed := cc.VoteQuorums
+	if cc.VoteQuorumRule == nil && len(voteListed) == 0 {
+		voteListed = cc.Quorums
+	}
+
+	for _, v := range voteListed {
+		if cc.IsQuorumSet(members.without(v)) {
+			return errors.Wrapf(ErrInvalidConfig, "vote quorum %b does not intersect every quorum", v)
+		}
+	}
 
 	for _, q := range cc.Quorums {
-		if v&q == q {
-			return true
+		if cc.IsVoteQuorumSet(members.without(q)) {
+			return errors.Wrapf(ErrInvalidConfig, "quorum %b does not intersect every vote quorum", q)
+		}
+	}
+
+	if cc.QuorumRule != nil && len(cc.VoteQuorums) == 0 {
+		return cc.voteQuorumRule().checkIntersect(cc.QuorumRule)
+	}
+
+	return nil
+}
+
+// SizedQuorums builds quorums each of which is any n members.
+// E.g., in a cluster of 5, Quorums of size 2 and VoteQuorums of size 4 commit a
+// log faster but elect a leader slower.
+func (cc *ClusterConfig) SizedQuorums(n int) ([]uint64, error) {
+	if n <= 0 || n > len(cc.Members) {
+		return nil, errors.Wrapf(ErrInvalidConfig, "quorum size %d of %d members",
+			n, len(cc.Members))
+	}
+
+	members := cc.membersSet()
+	if len(members) > 1 {
+		return nil, errors.Wrapf(ErrInvalidConfig,
+			"can not list quorums with members at position 64 or above")
+	}
+
+	return buildSizedQuorums(members.low(), n), nil
+}
+
+// WeightedQuorums builds quorums in which member `id` has weights[id] votes.
+// A quorum has more than half of all votes. A member not in weights has no
+// vote.
+func (cc *ClusterConfig) WeightedQuorums(weights map[int64]int64) ([]uint64, error) {
+	byPos := map[int64]int64{}
+	total := int64(0)
+	for id, w := range weights {
+		m, ok := cc.Members[id]
+		if !ok {
+			return nil, errors.Wrapf(ErrNotMember, "id: %d", id)
+		}
+		if w < 0 {
+			return nil, errors.Wrapf(ErrInvalidConfig, "member %d has negative weight: %d", id, w)
+		}
+		err := checkListable(m.Position)
+		if err != nil {
+			return nil, err
+		}
+		byPos[m.Position] = w
+		total += w
+	}
+
+	if total == 0 {
+		return nil, errors.Wrapf(ErrInvalidConfig, "no vote")
+	}
+
+	return buildWeightedQuorums(byPos), nil
+}
+
+// GridQuorums builds quorums on a grid of member ids. A quorum is a full row
+// and a full column. Every row must have the same number of members.
+func (cc *ClusterConfig) GridQuorums(rows [][]int64) ([]uint64, error) {
+	grid, err := cc.positionsOf(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range grid {
+		if len(row) != len(grid[0]) {
+			return nil, errors.Wrapf(ErrInvalidConfig, "grid is not rectangular: %v", rows)
+		}
+
+		err := checkListable(row...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildGridQuorums(grid), nil
+}
+
+// HierarchicalQuorums builds quorums from groups of member ids, e.g., one
+// group per datacenter. A quorum is a majority in each of a majority of
+// groups. Thus with 3 or more groups, the cluster survives the loss of a whole
+// group.
+func (cc *ClusterConfig) HierarchicalQuorums(groups [][]int64) ([]uint64, error) {
+	gs, err := cc.positionsOf(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range gs {
+		err := checkListable(g...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildHierarchicalQuorums(gs), nil
+}
+
+// checkListable returns an error if a position can not be in a listed quorum.
+func checkListable(positions ...int64) error {
+	for _, p := range positions {
+		if p >= 64 {
+			return errors.Wrapf(ErrInvalidConfig,
+				"can not list quorums with member at position %d, use a QuorumRule", p)
+		}
+	}
+	return nil
+}
+
+// MajorityRule builds a QuorumRule in which a quorum is a majority of members.
+func (cc *ClusterConfig) MajorityRule() *QuorumRule {
+	return majorityRule(cc.membersSet())
+}
+
+// CountingRule builds a QuorumRule in which member `id` has votes[id] votes,
+// and a quorum has at least threshold votes. A member not in votes has no
+// vote.
+// E.g., in a cluster of 5, a QuorumRule with threshold 2 and a VoteQuorumRule
+// with threshold 4 commit a log faster but elect a leader slower.
+func (cc *ClusterConfig) CountingRule(votes map[int64]int64, threshold int64) (*QuorumRule, error) {
+	r := &QuorumRule{
+		Votes:     map[int64]int64{},
+		Threshold: threshold,
+	}
+
+	for id, v := range votes {
+		m, ok := cc.Members[id]
+		if !ok {
+			return nil, errors.Wrapf(ErrNotMember, "id: %d", id)
+		}
+		if v != 0 {
+			r.Votes[m.Position] = v
+		}
+	}
+
+	err := r.check(cc.membersSet())
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// HierarchicalRule builds a QuorumRule with the same quorums as
+// HierarchicalQuorums, without listing them.
+func (cc *ClusterConfig) HierarchicalRule(groups [][]int64) (*QuorumRule, error) {
+	gs, err := cc.positionsOf(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &QuorumRule{Threshold: int64(len(gs)/2 + 1)}
+	for _, g := range gs {
+		r.Groups = append(r.Groups, majorityRule(NewMemberSet(g...)))
+	}
+	return r, nil
+}
+
+// positionsOf converts groups of member ids to groups of positions.
+// No group may be empty, and no member may appear twice.
+func (cc *ClusterConfig) positionsOf(groups [][]int64) ([][]int64, error) {
+	if len(groups) == 0 {
+		return nil, errors.Wrapf(ErrInvalidConfig, "no group")
+	}
+
+	seen := map[int64]bool{}
+	rst := make([][]int64, 0, len(groups))
+	for _, g := range groups {
+		if len(g) == 0 {
+			return nil, errors.Wrapf(ErrInvalidConfig, "empty group in: %v", groups)
+		}
+
+		ps := make([]int64, 0, len(g))
+		for _, id := range g {
+			m, ok := cc.Members[id]
+			if !ok {
+				return nil, errors.Wrapf(ErrNotMember, "id: %d", id)
+			}
+			if seen[id] {
+				return nil, errors.Wrapf(ErrInvalidConfig, "member %d appears twice", id)
+			}
+			seen[id] = true
+			ps = append(ps, m.Position)
+		}
+		rst = append(rst, ps)
+	}
+	return rst, nil
+}
+
+// jointConfig returns the config to change from cc to next.
+// It has members of both, and each of its quorums is a quorum of both cc and
+// next.
+func (cc *ClusterConfig) jointConfig(next *ClusterConfig) *ClusterConfig {
+
+	joint := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{},
+		Next:    next.Clone(),
+	}
+
+	for _, c := range []*ClusterConfig{cc.Clone(), next.Clone()} {
+		for id, m := range c.Members {
+			joint.Members[id] = m
 		}
 	}
 
-	return false
+	if cc.hasRule() || next.hasRule() {
+		// a quorum of both rules
+		joint.QuorumRule = &QuorumRule{
+			Groups:    []*QuorumRule{cc.quorumRule(), next.quorumRule()},
+			Threshold: 2,
+		}
+		if cc.hasVoteQuorums() || next.hasVoteQuorums() {
+			joint.VoteQuorumRule = &QuorumRule{
+				Groups:    []*QuorumRule{cc.voteQuorumRule(), next.voteQuorumRule()},
+				Threshold: 2,
+			}
+		}
+		// not to share rules with cc or next.
+		return joint.Clone()
+	}
+
+	joint.Quorums = jointQuorums(cc.Quorums, next.Quorums)
+	if cc.hasVoteQuorums() || next.hasVoteQuorums() {
+		joint.VoteQuorums = jointQuorums(cc.voteQuorums(), next.voteQuorums())
+	}
+
+	return joint
+}
+
+// jointQuorums returns the sets that are a quorum in both `a` and `b`.
+func jointQuorums(a, b []uint64) []uint64 {
+	rst := []uint64{}
+	seen := map[uint64]bool{}
+	for _, x := range a {
+		for _, y := range b {
+			q := x | y
+			if !seen[q] {
+				seen[q] = true
+				rst = append(rst, q)
+			}
+		}
+	}
+	return rst
+}
+
+// ShortStr returns ids of members, e.g., "1,2,3", or "1,2,3,4→1,2,4" for a
+// joint config.
+func (cc *ClusterConfig) ShortStr() string {
+	if cc == nil {
+		return "<>"
+	}
+
+	ids := []int64{}
+	for id := range cc.Members {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	ss := []string{}
+	for _, id := range ids {
+		ss = append(ss, fmt.Sprintf("%d", id))
+	}
+
+	s := strings.Join(ss, ",")
+	if cc.Next != nil {
+		s += "→" + cc.Next.ShortStr()
+	}
+	return s
 }
@@ -3,6 +3,7 @@ package traft
 import (
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,3 +73,461 @@ func TestClusterConfig_IsQuorum(t *testing.T) {
 		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
 	}
 }
+
+func TestClusterConfig_checkNext(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 0},
+			2: {2, "222", 1},
+			3: {3, "333", 2},
+		},
+	}
+
+	m := func(rs ...*ReplicaInfo) *ClusterConfig {
+		next := &ClusterConfig{Members: map[int64]*ReplicaInfo{}}
+		for _, r := range rs {
+			next.Members[r.Id] = r
+		}
+		next.Quorums = buildMajorityQuorums(next.membersSet().low())
+		return next
+	}
+
+	cases := []struct {
+		next    *ClusterConfig
+		wantErr error
+	}{
+		{m(&ReplicaInfo{1, "111", 0}, &ReplicaInfo{2, "222", 1}, &ReplicaInfo{4, "444", 3}), nil},
+		{m(&ReplicaInfo{1, "111", 0}), nil},
+		{m(&ReplicaInfo{1, "new", 0}, &ReplicaInfo{2, "222", 1}), nil},
+		{m(), ErrInvalidConfig},
+		// moved
+		{m(&ReplicaInfo{1, "111", 5}, &ReplicaInfo{2, "222", 1}), ErrInvalidConfig},
+		// position of a removed member
+		{m(&ReplicaInfo{1, "111", 0}, &ReplicaInfo{4, "444", 2}), ErrInvalidConfig},
+		// a member at position 64 is allowed, but can not be in a listed quorum.
+		{m(&ReplicaInfo{1, "111", 0}, &ReplicaInfo{4, "444", 64}), nil},
+		{&ClusterConfig{Members: map[int64]*ReplicaInfo{1: {1, "111", 0}, 4: {4, "444", -1}}, Quorums: []uint64{1}}, ErrInvalidConfig},
+		{&ClusterConfig{Members: map[int64]*ReplicaInfo{1: {1, "111", 0}}}, ErrInvalidConfig},
+		{&ClusterConfig{Members: map[int64]*ReplicaInfo{1: {1, "111", 0}}, Quorums: []uint64{3}}, ErrInvalidConfig},
+		{&ClusterConfig{Members: map[int64]*ReplicaInfo{1: {2, "222", 1}}, Quorums: []uint64{2}}, ErrInvalidConfig},
+		// quorums do not intersect
+		{&ClusterConfig{Members: map[int64]*ReplicaInfo{1: {1, "111", 0}, 2: {2, "222", 1}}, Quorums: []uint64{1, 2}}, ErrInvalidConfig},
+	}
+
+	for i, c := range cases {
+		err := cc.checkNext(c.next)
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestClusterConfig_jointConfig(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 0},
+			2: {2, "222", 1},
+			3: {3, "333", 2},
+		},
+		Quorums: buildMajorityQuorums(7),
+	}
+
+	next := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 0},
+			4: {4, "444", 3},
+			5: {5, "555", 4},
+		},
+		Quorums: buildMajorityQuorums(1 | 8 | 16),
+	}
+
+	joint := cc.jointConfig(next)
+	ta.Equal("1,2,3,4,5→1,4,5", joint.ShortStr())
+	ta.Equal(next, joint.Next)
+
+	cases := []struct {
+		input uint64
+		want  bool
+	}{
+		{1 | 2, false},
+		{8 | 16, false},
+		{1 | 2 | 8, true},
+		{2 | 4 | 8 | 16, true},
+		{1 | 2 | 4, false},
+		{1 | 2 | 4 | 8 | 16, true},
+	}
+
+	for i, c := range cases {
+		got := joint.IsQuorum(c.input)
+		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
+	}
+
+	ta.Nil(joint.VoteQuorums)
+
+	// to elect in the joint config, all of 1, 4, 5 are required.
+	next.VoteQuorums = []uint64{1 | 8 | 16}
+	joint = cc.jointConfig(next)
+
+	ta.True(joint.IsQuorum(1 | 2 | 8))
+	ta.False(joint.IsVoteQuorum(1 | 2 | 8))
+	ta.True(joint.IsVoteQuorum(1 | 2 | 8 | 16))
+	ta.Nil(joint.checkQuorums())
+}
+
+func TestClusterConfig_checkQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	members := map[int64]*ReplicaInfo{
+		1: {1, "111", 0},
+		2: {2, "222", 1},
+		3: {3, "333", 2},
+		4: {4, "444", 3},
+	}
+
+	cases := []struct {
+		quorums []uint64
+		wantErr error
+	}{
+		{buildMajorityQuorums(15), nil},
+		{[]uint64{1}, nil},
+		{[]uint64{3, 6, 5}, nil},
+		{[]uint64{3, 12}, ErrInvalidConfig},
+		{[]uint64{3, 6, 12}, ErrInvalidConfig},
+		{[]uint64{16}, ErrInvalidConfig},
+		{[]uint64{0}, ErrInvalidConfig},
+		{nil, ErrInvalidConfig},
+	}
+
+	for i, c := range cases {
+		cc := &ClusterConfig{Members: members, Quorums: c.quorums}
+		err := cc.checkQuorums()
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+
+	flexCases := []struct {
+		quorums     []uint64
+		voteQuorums []uint64
+		wantErr     error
+	}{
+		// quorums do not have to intersect each other.
+		{[]uint64{3, 12}, []uint64{15}, nil},
+		{buildSizedQuorums(15, 2), buildSizedQuorums(15, 3), nil},
+		{buildSizedQuorums(15, 2), buildSizedQuorums(15, 2), ErrInvalidConfig},
+		{[]uint64{3, 12}, []uint64{3}, ErrInvalidConfig},
+		{[]uint64{3}, []uint64{16}, ErrInvalidConfig},
+	}
+
+	for i, c := range flexCases {
+		cc := &ClusterConfig{Members: members, Quorums: c.quorums, VoteQuorums: c.voteQuorums}
+		err := cc.checkQuorums()
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestClusterConfig_IsVoteQuorum(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 0},
+			2: {2, "222", 1},
+			3: {3, "333", 2},
+		},
+		Quorums: buildSizedQuorums(7, 1),
+	}
+
+	// the same as Quorums if no VoteQuorums
+	ta.True(cc.IsVoteQuorum(1))
+
+	cc.VoteQuorums = buildSizedQuorums(7, 3)
+
+	cases := []struct {
+		input    uint64
+		want     bool
+		wantVote bool
+	}{
+		{1, true, false},
+		{1 | 2, true, false},
+		{1 | 2 | 4, true, true},
+		{0, false, false},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, cc.IsQuorum(c.input), "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantVote, cc.IsVoteQuorum(c.input), "%d-th: case: %+v", i+1, c)
+	}
+
+	_, err := cc.SizedQuorums(4)
+	ta.Equal(ErrInvalidConfig, errors.Cause(err))
+
+	_, err = cc.SizedQuorums(0)
+	ta.Equal(ErrInvalidConfig, errors.Cause(err))
+}
+
+func TestClusterConfig_buildQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	// positions differ from ids.
+	cc := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 3},
+			2: {2, "222", 2},
+			3: {3, "333", 1},
+			4: {4, "444", 0},
+		},
+	}
+
+	qs, err := cc.WeightedQuorums(map[int64]int64{1: 2, 2: 1, 3: 1})
+	ta.Nil(err)
+	ta.Equal([]string{"01010000", "00110000"}, fmtBitmap(qs))
+
+	qs, err = cc.GridQuorums([][]int64{{1, 2}, {3, 4}})
+	ta.Nil(err)
+	ta.Equal(4, len(qs))
+
+	qs, err = cc.HierarchicalQuorums([][]int64{{1}, {2}, {3, 4}})
+	ta.Nil(err)
+	// a majority of group {3, 4} is both of them.
+	ta.Equal([]string{"00110000", "11010000", "11100000"}, fmtBitmap(qs))
+
+	for _, build := range []func() ([]uint64, error){
+		func() ([]uint64, error) { return cc.WeightedQuorums(map[int64]int64{1: 1, 2: 1, 3: 1, 4: 1}) },
+		func() ([]uint64, error) { return cc.GridQuorums([][]int64{{1, 2}, {3, 4}}) },
+		func() ([]uint64, error) { return cc.HierarchicalQuorums([][]int64{{1, 2}, {3, 4}}) },
+	} {
+		qs, err := build()
+		ta.Nil(err)
+		ta.Nil((&ClusterConfig{Members: cc.Members, Quorums: qs}).checkQuorums())
+	}
+
+	cases := []struct {
+		build   func() ([]uint64, error)
+		wantErr error
+	}{
+		{func() ([]uint64, error) { return cc.WeightedQuorums(map[int64]int64{1: 1, 5: 1}) }, ErrNotMember},
+		{func() ([]uint64, error) { return cc.WeightedQuorums(map[int64]int64{1: 1, 2: -1}) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.WeightedQuorums(map[int64]int64{1: 0}) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.GridQuorums([][]int64{{1, 2}, {3}}) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.GridQuorums([][]int64{{1, 2}, {2, 3}}) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.HierarchicalQuorums([][]int64{{1, 2}, {}}) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.HierarchicalQuorums(nil) }, ErrInvalidConfig},
+		{func() ([]uint64, error) { return cc.HierarchicalQuorums([][]int64{{1, 5}}) }, ErrNotMember},
+	}
+
+	for i, c := range cases {
+		_, err := c.build()
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestClusterConfig_largeCluster(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{Members: map[int64]*ReplicaInfo{}}
+	for i := int64(0); i < 100; i++ {
+		cc.Members[i+1] = &ReplicaInfo{Id: i + 1, Position: i}
+	}
+
+	cc.setMajorityQuorums()
+	ta.Nil(cc.Quorums)
+	ta.Equal(int64(51), cc.QuorumRule.Threshold)
+	ta.Nil(cc.checkQuorums())
+
+	s := MemberSet{}
+	for i := int64(99); i > 49; i-- {
+		s.Add(i)
+	}
+	ta.False(cc.IsQuorumSet(s))
+	ta.False(cc.IsVoteQuorumSet(s))
+
+	s.Add(0)
+	ta.True(cc.IsQuorumSet(s))
+	ta.True(cc.IsVoteQuorumSet(s))
+
+	// 10 datacenters of 10
+	dcs := [][]int64{}
+	for i := int64(0); i < 10; i++ {
+		dc := []int64{}
+		for j := int64(1); j <= 10; j++ {
+			dc = append(dc, i*10+j)
+		}
+		dcs = append(dcs, dc)
+	}
+	r, err := cc.HierarchicalRule(dcs)
+	ta.Nil(err)
+
+	// 6 from each of 6 datacenters.
+	s = MemberSet{}
+	for i := int64(0); i < 6; i++ {
+		for j := int64(0); j < 6; j++ {
+			s.Add(i*10 + j)
+		}
+	}
+	ta.True(r.isQuorum(s))
+	ta.False(r.isQuorum(s.without(1)))
+
+	_, err = cc.HierarchicalQuorums(dcs)
+	ta.Equal(ErrInvalidConfig, errors.Cause(err), "can not be listed")
+
+	_, err = cc.SizedQuorums(51)
+	ta.Equal(ErrInvalidConfig, errors.Cause(err), "can not be listed")
+
+	// Flexible Paxos: commit with 10, elect with 91.
+	votes := map[int64]int64{}
+	for id := range cc.Members {
+		votes[id] = 1
+	}
+	cc.QuorumRule, err = cc.CountingRule(votes, 10)
+	ta.Nil(err)
+	cc.VoteQuorumRule, err = cc.CountingRule(votes, 91)
+	ta.Nil(err)
+	ta.Nil(cc.checkQuorums())
+
+	cc.VoteQuorumRule, err = cc.CountingRule(votes, 90)
+	ta.Nil(err)
+	ta.Equal(ErrInvalidConfig, errors.Cause(cc.checkQuorums()))
+
+	// 90 learners
+	learners := map[int64]int64{}
+	for id := int64(1); id <= 10; id++ {
+		learners[id] = 1
+	}
+	cc.QuorumRule, err = cc.CountingRule(learners, 6)
+	ta.Nil(err)
+	cc.VoteQuorumRule = nil
+	ta.Nil(cc.checkQuorums())
+	ta.True(cc.IsQuorumSet(NewMemberSet(0, 1, 2, 3, 4, 5)))
+	ta.False(cc.IsQuorumSet(NewMemberSet(0, 1, 2, 3, 4, 10, 11, 12, 13, 14, 15)))
+
+	_, err = cc.CountingRule(map[int64]int64{101: 1}, 1)
+	ta.Equal(ErrNotMember, errors.Cause(err))
+
+	_, err = cc.CountingRule(votes, 101)
+	ta.Equal(ErrInvalidConfig, errors.Cause(err))
+}
+
+func TestClusterConfig_rules(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{Members: map[int64]*ReplicaInfo{}}
+	for i := int64(0); i < 9; i++ {
+		cc.Members[i+1] = &ReplicaInfo{Id: i + 1, Position: i}
+	}
+
+	// a rule has the same quorums as the listed ones.
+	groups := [][]int64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	listed, err := cc.HierarchicalQuorums(groups)
+	ta.Nil(err)
+	r, err := cc.HierarchicalRule(groups)
+	ta.Nil(err)
+
+	majority := buildMajorityQuorums(1<<9 - 1)
+	mr := cc.MajorityRule()
+
+	for i := uint64(0); i < 1<<9; i++ {
+		ta.Equal(isListedQuorum(listed, MemberSet{i}), r.isQuorum(MemberSet{i}), "set: %b", i)
+		ta.Equal(isListedQuorum(majority, MemberSet{i}), mr.isQuorum(MemberSet{i}), "set: %b", i)
+	}
+
+	size := func(n int) []uint64 {
+		qs, err := cc.SizedQuorums(n)
+		ta.Nil(err)
+		return qs
+	}
+	counting := func(threshold int64) *QuorumRule {
+		return &QuorumRule{Votes: mr.Votes, Threshold: threshold}
+	}
+
+	cases := []struct {
+		quorums     []uint64
+		voteQuorums []uint64
+		rule        *QuorumRule
+		voteRule    *QuorumRule
+		wantErr     error
+	}{
+		{nil, nil, counting(5), nil, nil},
+		{nil, nil, counting(4), nil, ErrInvalidConfig},
+		{nil, nil, counting(0), nil, ErrInvalidConfig},
+		{nil, nil, counting(10), nil, ErrInvalidConfig},
+		{nil, nil, counting(3), counting(7), nil},
+		{nil, nil, counting(3), counting(6), ErrInvalidConfig},
+		{size(3), nil, nil, counting(7), nil},
+		{size(3), nil, nil, counting(6), ErrInvalidConfig},
+		{nil, size(7), counting(3), nil, nil},
+		{nil, size(6), counting(3), nil, ErrInvalidConfig},
+		{size(5), nil, counting(5), nil, ErrInvalidConfig},
+		{nil, size(5), nil, counting(5), ErrInvalidConfig},
+		{nil, nil, &QuorumRule{Votes: map[int64]int64{9: 1}, Threshold: 1}, nil, ErrInvalidConfig},
+		{nil, nil, r, nil, nil},
+		{nil, nil, r, mr, ErrInvalidConfig},
+	}
+
+	for i, c := range cases {
+		conf := &ClusterConfig{
+			Members:        cc.Members,
+			Quorums:        c.quorums,
+			VoteQuorums:    c.voteQuorums,
+			QuorumRule:     c.rule,
+			VoteQuorumRule: c.voteRule,
+		}
+		err := conf.checkQuorums()
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestClusterConfig_jointConfig_rules(t *testing.T) {
+
+	ta := require.New(t)
+
+	cc := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, "111", 0},
+			2: {2, "222", 1},
+			3: {3, "333", 2},
+		},
+		Quorums: buildMajorityQuorums(7),
+	}
+
+	next := &ClusterConfig{Members: map[int64]*ReplicaInfo{}}
+	for i := int64(0); i < 70; i++ {
+		next.Members[i+1] = &ReplicaInfo{Id: i + 1, Position: i}
+	}
+	next.setMajorityQuorums()
+	ta.Nil(cc.checkNext(next))
+
+	joint := cc.jointConfig(next)
+	ta.Nil(joint.Quorums)
+	ta.Nil(joint.VoteQuorumRule)
+
+	s := MemberSet{}
+	for i := int64(69); i > 33; i-- {
+		s.Add(i)
+	}
+	ta.True(next.IsQuorumSet(s))
+	ta.False(joint.IsQuorumSet(s), "not a quorum of cc")
+	ta.False(joint.IsVoteQuorumSet(s), "not a quorum of cc")
+
+	s.Add(0)
+	ta.False(joint.IsQuorumSet(s), "not a quorum of cc")
+
+	s.Add(1)
+	ta.True(joint.IsQuorumSet(s))
+	ta.True(joint.IsVoteQuorumSet(s))
+
+	// joint vote quorums
+	next.VoteQuorumRule = next.MajorityRule()
+	next.VoteQuorumRule.Threshold = 60
+	joint = cc.jointConfig(next)
+	ta.True(joint.IsQuorumSet(s))
+	ta.False(joint.IsVoteQuorumSet(s))
+	ta.Equal(int64(2), joint.VoteQuorumRule.Threshold)
+}
@@ -15,13 +15,22 @@ func NewCmdI64(op, key string, v int64) *Cmd {
 	return cmd
 }
 
+// NewCmdConfig returns a Cmd that changes the cluster members to conf.
+func NewCmdConfig(conf *ClusterConfig) *Cmd {
+	return &Cmd{
+		Op:    "config",
+		Value: &Cmd_VClusterConfig{conf},
+	}
+}
+
 func cmdValueShortStr(v isCmd_Value) string {
 	switch vv := v.(type) {
 	case *Cmd_VI64:
 		return fmt.Sprintf("%d", vv.VI64)
 	case *Cmd_VStr:
 		return vv.VStr
-		// TODO ClusterConfig
+	case *Cmd_VClusterConfig:
+		return vv.VClusterConfig.ShortStr()
 	default:
 		return fmt.Sprintf("%s", vv)
 	}
@@ -19,10 +19,11 @@ func (tr *TRaft) leaderUpdateCommitted(
 
 		// NOTE: using start, end lsn to describe logs requires every
 		// forwarding action operates on continous logs
-		for i := lsns[0]; i < lsns[1]; i++ {
-			r := tr.Logs[i-tr.LogOffset]
+		for _, r := range tr.logs.Range(lsns[0], lsns[1]) {
 			me.Committed.Union(r.Overrides)
 		}
+		tr.saveCommitted()
+		tr.notifyApply()
 
 		return nil
 	}
@@ -34,3 +35,81 @@ func (tr *TRaft) leaderUpdateCommitted(
 	lg.Infow("leaderUpdateCommitted", "err", err)
 	return err
 }
+
+// followerUpdateCommitted commits logs that are committed by the leader and
+// are accepted by this replica from the same leader.
+// It must be called from Loop().
+func (tr *TRaft) followerUpdateCommitted(committer *LeaderId, committed *TailBitmap) {
+
+	me := tr.Status[tr.Id]
+
+	if committed == nil || !committer.Equal(me.Committer) {
+		// logs accepted from another committer may be different.
+		return
+	}
+
+	c := committed.Clone()
+	c.Intersection(me.Accepted)
+
+	if me.Committed.Includes(c) {
+		return
+	}
+
+	me.Committed.Union(c)
+	tr.saveCommitted()
+	tr.notifyApply()
+}
+
+// leaderCommitAccepted commits logs accepted by a quorum from the leader
+// `committer`, and replies to their proposers.
+// It must be called from Loop().
+func (tr *TRaft) leaderCommitAccepted(committer *LeaderId) {
+
+	me := tr.Status[tr.Id]
+	config := tr.Config
+
+	updated := false
+
+	end := me.Accepted.Len()
+	for lsn := me.Committed.Offset; lsn < end; lsn++ {
+		if me.Committed.Get(lsn) != 0 || me.Accepted.Get(lsn) == 0 {
+			continue
+		}
+
+		r := tr.logs.Get(lsn)
+		if r.Empty() {
+			continue
+		}
+
+		acked := MemberSet{}
+		for _, m := range config.Members {
+			st := tr.Status[m.Id]
+			if committer.Equal(st.Committer) && st.Accepted.Get(lsn) != 0 {
+				acked.Add(m.Position)
+			}
+		}
+
+		if config.IsQuorumSet(acked) {
+			me.Committed.Union(r.Overrides)
+			me.Committed.Set(lsn)
+			updated = true
+		}
+	}
+
+	if !updated {
+		return
+	}
+
+	tr.saveCommitted()
+	tr.notifyApply()
+
+	// the proposer of a joint config waits for the final one.
+	tr.finishJointConfig()
+	tr.stepDownIfRemoved()
+
+	for lsn := range tr.proposing {
+		if me.Committed.Get(lsn) != 0 {
+			tr.replyCommitted(lsn)
+		}
+	}
+}
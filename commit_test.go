@@ -0,0 +1,142 @@
+package traft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_followerUpdateCommitted(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		committer *LeaderId
+		committed *TailBitmap
+		want      *TailBitmap
+	}{
+		{lid(2, 2), nil, bm(0, 0)},
+		{lid(2, 2), bm(0), bm(0, 0)},
+		{lid(2, 2), bm(2), bm(2)},
+		// 2 is not accepted
+		{lid(2, 2), bm(4), bm(2, 3)},
+		{lid(2, 2), bm(0, 1, 3, 5), bm(0, 0, 1, 3)},
+		// logs from another committer
+		{lid(3, 2), bm(4), bm(0, 0)},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502"})
+		tr.initTraft(lid(2, 2), lid(2, 2), []int64{0, 1, 3}, nil, []int64{0}, lid(3, 2))
+
+		me := tr.Status[1]
+		tr.followerUpdateCommitted(c.committer, c.committed)
+
+		ta.True(me.Committed.Includes(c.want) && c.want.Includes(me.Committed),
+			"%d-th: case: %+v, got: %s", i+1, c, me.Committed.DebugStr())
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_leaderCommitAccepted(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		committer2 *LeaderId
+		accepted2  *TailBitmap
+		accepted3  *TailBitmap
+		want       *TailBitmap
+		wantReply  bool
+	}{
+		{lid(2, 1), bm(0), bm(0), bm(0), false},
+		{lid(2, 1), bm(0, 1), bm(0), bm(0, 1), true},
+		// x=2 overrides x=0
+		{lid(2, 1), bm(0, 0, 2), bm(0), bm(0, 0, 2), false},
+		{lid(2, 1), bm(0, 1), bm(0, 0, 2), bm(3), true},
+		// accepted from another committer
+		{lid(1, 1), bm(3), bm(0), bm(0), false},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+		tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+		tr.addlogs("x=0", "y=1", "x=2")
+
+		tr.Status[2].Committer = c.committer2
+		tr.Status[2].Accepted = c.accepted2
+		tr.Status[3].Committer = lid(2, 1)
+		tr.Status[3].Accepted = c.accepted3
+
+		finCh := make(chan *ProposeReply, 1)
+		tr.proposing[1] = &proposal{
+			committer: lid(2, 1),
+			finCh:     finCh,
+		}
+
+		tr.leaderCommitAccepted(lid(2, 1))
+
+		me := tr.Status[1]
+		ta.True(me.Committed.Includes(c.want) && c.want.Includes(me.Committed),
+			"%d-th: case: %+v, got: %s", i+1, c, me.Committed.DebugStr())
+		ta.Equal(c.wantReply, len(finCh) == 1, "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_leaderCommitAccepted_largeCluster(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	addrs := map[int64]string{}
+	for id := int64(1); id <= 100; id++ {
+		addrs[id] = fmt.Sprintf(":%d", 5500+id)
+	}
+
+	tr := NewTRaft(1, addrs)
+	defer tr.Stop()
+
+	ta.Nil(tr.Config.Quorums)
+	ta.NotNil(tr.Config.QuorumRule)
+
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+	tr.addlogs("x=0")
+
+	// 49 followers, at position 51 to 99, accepted it.
+	for id := int64(52); id <= 100; id++ {
+		tr.Status[id].Committer = lid(2, 1)
+		tr.Status[id].Accepted = bm(1)
+	}
+
+	tr.leaderCommitAccepted(lid(2, 1))
+	ta.Equal(uint64(0), tr.Status[1].Committed.Get(0), "50 of 100")
+
+	tr.Status[2].Committer = lid(2, 1)
+	tr.Status[2].Accepted = bm(1)
+
+	tr.leaderCommitAccepted(lid(2, 1))
+	ta.NotEqual(uint64(0), tr.Status[1].Committed.Get(0), "51 of 100")
+
+	// voting
+	vt := newVoteTally(lid(2, 1), tr.Status[1], tr.Config)
+	for id := int64(2); id <= 50; id++ {
+		vt.add(tr.Config.Members[id], &VoteReply{VotedFor: lid(2, 1)})
+	}
+	ta.False(vt.granted())
+	ta.False(vt.done())
+
+	vt.add(tr.Config.Members[100], &VoteReply{VotedFor: lid(2, 1)})
+	ta.True(vt.granted())
+}
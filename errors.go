@@ -3,8 +3,16 @@ package traft
 import "github.com/pkg/errors"
 
 var (
-	ErrStaleLog    = errors.New("local log is stale")
-	ErrStaleTermId = errors.New("local Term-Id is stale")
-	ErrTimeout     = errors.New("timeout")
-	ErrLeaderLost  = errors.New("leadership lost")
+	ErrStaleLog       = errors.New("local log is stale")
+	ErrStaleTermId    = errors.New("local Term-Id is stale")
+	ErrLeaderAlive    = errors.New("leader is alive")
+	ErrTimeout        = errors.New("timeout")
+	ErrLeaderLost     = errors.New("leadership lost")
+	ErrNotLeader      = errors.New("I am not leader")
+	ErrNotMember      = errors.New("not a member")
+	ErrTransferring   = errors.New("leadership is being transferred")
+	ErrConfigChanging = errors.New("cluster config is being changed")
+	ErrInvalidConfig  = errors.New("invalid cluster config")
+	ErrStopped        = errors.New("TRaft stopped")
+	ErrUnreachable    = errors.New("replica unreachable")
 )
@@ -0,0 +1,772 @@
+package traft
+
+import (
+	context "context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// faultCluster runs replicas in goroutines connected by a MemNetwork.
+// A replica can be killed and restarted from what it has persisted in its
+// dir, while the network between replicas is partitioned or disturbed.
+type faultCluster struct {
+	ta    *require.Assertions
+	net   *MemNetwork
+	dir   string
+	ids   []int64
+	addrs map[int64]string
+	opts  []Option
+
+	// running replicas. A replica is killed or started while others are
+	// proposing to it, thus it is protected by mu.
+	mu    sync.Mutex
+	alive map[int64]*TRaft
+}
+
+// newFaultCluster starts a cluster with all VoteLoop-s running.
+// Timeouts are shortened so that a scenario finishes in seconds.
+// Every replica is created with opts.
+func newFaultCluster(t *testing.T, ids []int64, opts ...Option) *faultCluster {
+
+	l, h, f := leaderLease, heartbeatInterval, followerSleep
+	r := replicateRetryMin
+	t.Cleanup(func() {
+		leaderLease, heartbeatInterval, followerSleep = l, h, f
+		replicateRetryMin = r
+	})
+
+	leaderLease = int64(time.Millisecond * 300)
+	heartbeatInterval = time.Millisecond * 50
+	followerSleep = time.Millisecond * 50
+	replicateRetryMin = time.Millisecond
+
+	c := &faultCluster{
+		ta:    require.New(t),
+		net:   NewMemNetwork(WithNetworkSeed(seedForTest(t))),
+		dir:   t.TempDir(),
+		ids:   ids,
+		addrs: map[int64]string{},
+		opts:  opts,
+		alive: map[int64]*TRaft{},
+	}
+
+	for _, id := range ids {
+		c.addrs[id] = fmt.Sprintf("mem-%d", id)
+	}
+
+	for _, id := range ids {
+		c.start(id)
+	}
+
+	t.Cleanup(c.stop)
+	return c
+}
+
+// start runs replica `id` with what it persisted before being killed.
+func (c *faultCluster) start(id int64) {
+	dir := filepath.Join(c.dir, fmt.Sprintf("%d", id))
+	err := os.MkdirAll(dir, 0755)
+	c.ta.Nil(err)
+
+	opts := append([]Option{WithDir(dir), WithTransport(c.net.Transport(id))}, c.opts...)
+	tr := NewTRaft(id, c.addrs, opts...)
+	c.net.Register(c.addrs[id], tr)
+	tr.StartMainLoop()
+	tr.StartVoteLoop()
+
+	c.mu.Lock()
+	c.alive[id] = tr
+	c.mu.Unlock()
+}
+
+// kill stops replica `id` as if its process is killed: it can no longer be
+// reached and what it is doing is interrupted.
+func (c *faultCluster) kill(id int64) {
+	c.mu.Lock()
+	tr := c.alive[id]
+	delete(c.alive, id)
+	c.mu.Unlock()
+
+	c.net.Unregister(c.addrs[id])
+	tr.Stop()
+}
+
+// get returns the running replica `id`, or nil if it is killed.
+func (c *faultCluster) get(id int64) *TRaft {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.alive[id]
+}
+
+func (c *faultCluster) stop() {
+	for _, id := range c.ids {
+		if c.get(id) != nil {
+			c.kill(id)
+		}
+	}
+}
+
+// isLeader returns true if replica `id` has won an election and its lease is
+// not expired.
+func (c *faultCluster) isLeader(id int64) bool {
+	tr := c.get(id)
+	if tr == nil {
+		return false
+	}
+
+	ok := false
+	tr.queryOrStop("func", func() error {
+		me := tr.Status[id]
+		ok = me.VotedFor.Id == id &&
+			me.VotedFor.Equal(me.Committer) &&
+			tr.clock.Now() < me.VoteExpireAt
+		return nil
+	})
+	return ok
+}
+
+// waitLeader waits until one of `ids` becomes the leader and returns it.
+func (c *faultCluster) waitLeader(ids ...int64) int64 {
+	for i := 0; i < 1000; i++ {
+		for _, id := range ids {
+			if c.isLeader(id) {
+				return id
+			}
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	c.ta.Fail("no leader elected", "in: %v", ids)
+	return 0
+}
+
+// votedFor returns the leader replica `id` votes for.
+func (c *faultCluster) votedFor(id int64) *LeaderId {
+	tr := c.get(id)
+	var v *LeaderId
+	tr.queryOrStop("func", func() error {
+		v = tr.Status[id].VotedFor.Clone()
+		return nil
+	})
+	return v
+}
+
+// waitStableLeader waits until exactly one replica is the leader and every
+// running replica votes for it, thus no one refuses its heartbeats, e.g., a
+// candidate of the same term that did not win.
+// It returns the leader.
+func (c *faultCluster) waitStableLeader() int64 {
+	for i := 0; i < 1000; i++ {
+		leaders := []int64{}
+		stable := true
+		var leader *LeaderId
+
+		for _, id := range c.ids {
+			if c.get(id) == nil {
+				continue
+			}
+			if c.isLeader(id) {
+				leaders = append(leaders, id)
+			}
+
+			v := c.votedFor(id)
+			if leader == nil {
+				leader = v
+			}
+			stable = stable && v.Equal(leader)
+		}
+
+		if len(leaders) == 1 && stable && leader.Id == leaders[0] {
+			return leaders[0]
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	c.ta.Fail("no stable leader elected", "in: %v", c.ids)
+	return 0
+}
+
+// add starts a new replica `id` that is not yet a member.
+func (c *faultCluster) add(id int64) {
+	c.addrs[id] = fmt.Sprintf("mem-%d", id)
+	c.ids = append(c.ids, id)
+	c.start(id)
+}
+
+// changeMembers changes the cluster to have only members `ids`.
+// A member is at position id-1, the same as NewTRaft builds.
+func (c *faultCluster) changeMembers(ids ...int64) {
+	conf := &ClusterConfig{Members: map[int64]*ReplicaInfo{}}
+	for _, id := range ids {
+		conf.Members[id] = &ReplicaInfo{Id: id, Addr: c.addrs[id], Position: id - 1}
+	}
+
+	for i := 0; i < 100; i++ {
+		leader := c.waitLeader(c.ids...)
+		reply := c.propose(leader, NewCmdConfig(conf), time.Second)
+		if reply != nil && reply.OK {
+			return
+		}
+	}
+	c.ta.Fail("fail to change members", "to: %v", ids)
+}
+
+// config returns the cluster config replica `id` uses.
+func (c *faultCluster) config(id int64) *ClusterConfig {
+	rst := c.get(id).queryOrStop("config", nil)
+	c.ta.NotNil(rst)
+	return rst.v.(*ClusterConfig)
+}
+
+// propose sends a command to replica `id` and waits at most `timeout` for
+// the reply. It returns nil if there is no reply.
+func (c *faultCluster) propose(id int64, cmd interface{}, timeout time.Duration) *ProposeReply {
+	tr := c.get(id)
+
+	ch := make(chan *ProposeReply, 1)
+	go func() {
+		reply, err := tr.Propose(context.Background(), toCmd(cmd))
+		if err == nil {
+			ch <- reply
+		}
+	}()
+
+	select {
+	case reply := <-ch:
+		return reply
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// write proposes a command to the leader among `ids` until it is committed.
+func (c *faultCluster) write(cmd string, ids ...int64) {
+	for i := 0; i < 100; i++ {
+		leader := c.waitLeader(ids...)
+		reply := c.propose(leader, cmd, time.Second)
+		if reply != nil && reply.OK {
+			return
+		}
+	}
+	c.ta.Fail("fail to commit", "cmd: %s", cmd)
+}
+
+// waitKV waits until every replica in `ids` applies key=value.
+func (c *faultCluster) waitKV(key string, value int64, ids ...int64) {
+	want := NewCmdI64("set", key, value)
+	for _, id := range ids {
+		tr := c.get(id)
+		waitFor(c.ta, tr, func() bool {
+			kvs := tr.sm.(*kvState)
+			kvs.mu.Lock()
+			defer kvs.mu.Unlock()
+			return kvs.get(key).Equal(want)
+		})
+	}
+}
+
+// others returns ids in the cluster except `except`.
+func (c *faultCluster) others(except ...int64) []int64 {
+	rst := []int64{}
+	for _, id := range c.ids {
+		found := false
+		for _, e := range except {
+			found = found || e == id
+		}
+		if !found {
+			rst = append(rst, id)
+		}
+	}
+	return rst
+}
+
+func TestFault_leaderIsolated(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	old := c.waitLeader(c.ids...)
+	rest := c.others(old)
+
+	c.net.Partition([]int64{old}, rest)
+
+	// The isolated leader can not commit anything.
+	reply := c.propose(old, "y=1", time.Second)
+	ta.True(reply == nil || !reply.OK, "isolated leader committed: %+v", reply)
+
+	// The others elect a new one.
+	leader := c.waitLeader(rest...)
+	ta.NotEqual(old, leader)
+
+	c.write("x=2", rest...)
+	c.waitKV("x", 2, rest...)
+
+	c.net.Heal()
+
+	c.write("x=3", c.ids...)
+	c.waitKV("x", 3, c.ids...)
+}
+
+func TestFault_minorityPartitioned(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3, 4, 5})
+
+	c.write("x=1", c.ids...)
+	leader := c.waitLeader(c.ids...)
+
+	minority := c.others(leader)[:2]
+	majority := c.others(minority...)
+
+	c.net.Partition(minority, majority)
+
+	// The majority keeps committing, while the minority never elects a
+	// leader.
+	for i := int64(2); i < 10; i++ {
+		c.write(fmt.Sprintf("x=%d", i), majority...)
+		for _, id := range minority {
+			ta.False(c.isLeader(id), "minority %d became leader", id)
+		}
+	}
+	c.waitKV("x", 9, majority...)
+
+	time.Sleep(time.Duration(leaderLease) * 2)
+	for _, id := range minority {
+		ta.False(c.isLeader(id), "minority %d became leader", id)
+	}
+
+	c.net.Heal()
+
+	c.write("x=10", c.ids...)
+	c.waitKV("x", 10, c.ids...)
+}
+
+func TestFault_splitBrain(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3, 4, 5})
+
+	c.write("x=1", c.ids...)
+	old := c.waitLeader(c.ids...)
+
+	minority := []int64{old, c.others(old)[0]}
+	majority := c.others(minority...)
+
+	c.net.Partition(minority, majority)
+
+	// The old leader may still believe it is the leader until its lease
+	// expires, but it can not commit.
+	reply := c.propose(old, "x=100", time.Second)
+	ta.True(reply == nil || !reply.OK, "minority leader committed: %+v", reply)
+
+	leader := c.waitLeader(majority...)
+	ta.NotEqual(old, leader)
+
+	c.write("x=2", majority...)
+	c.waitKV("x", 2, majority...)
+
+	for _, id := range minority {
+		ta.False(c.isLeader(id), "minority %d is leader", id)
+	}
+
+	c.net.Heal()
+
+	// Only one leader is accepted by the cluster.
+	reply = c.propose(old, "x=101", time.Second)
+	ta.True(reply == nil || !reply.OK, "former leader committed: %+v", reply)
+
+	c.write("x=3", c.ids...)
+	c.waitKV("x", 3, c.ids...)
+}
+
+func TestFault_asymmetricLink(t *testing.T) {
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	leader := c.waitLeader(c.ids...)
+	f := c.others(leader)[0]
+
+	// The follower receives nothing from the leader but the leader still
+	// hears from it. Others see slow and lossy links.
+	c.net.Cut(leader, f)
+	c.net.SetFault(LinkFault{
+		DropRate: 0.05,
+		MinDelay: time.Millisecond,
+		MaxDelay: time.Millisecond * 10,
+	})
+
+	for i := int64(2); i < 10; i++ {
+		c.write(fmt.Sprintf("x=%d", i), c.ids...)
+	}
+
+	c.net.Heal()
+	c.net.SetFault(LinkFault{})
+
+	c.write("x=10", c.ids...)
+	c.waitKV("x", 10, c.ids...)
+}
+
+func TestFault_killAndRestart(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	// slow links so that logs are being forwarded when a replica is killed.
+	c.net.SetFault(LinkFault{
+		MinDelay: time.Millisecond * 5,
+		MaxDelay: time.Millisecond * 20,
+	})
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	// kill a follower while replicating to it
+	leader := c.waitStableLeader()
+	f := c.others(leader)[0]
+
+	replies := make(chan *ProposeReply, 10)
+	for i := int64(2); i < 12; i++ {
+		go func(i int64) {
+			replies <- c.propose(leader, fmt.Sprintf("y=%d", i), time.Second*5)
+		}(i)
+	}
+	time.Sleep(time.Millisecond * 10)
+	c.kill(f)
+
+	for i := 0; i < 10; i++ {
+		reply := <-replies
+		ta.NotNil(reply)
+		ta.True(reply.OK, "reply: %+v", reply)
+	}
+
+	c.start(f)
+
+	// kill the leader while replicating
+	leader = c.waitLeader(c.ids...)
+	go c.propose(leader, "z=1", time.Second)
+	time.Sleep(time.Millisecond * 10)
+	c.kill(leader)
+
+	rest := c.others(leader)
+	newLeader := c.waitLeader(rest...)
+	ta.NotEqual(leader, newLeader)
+
+	c.write("x=2", rest...)
+	c.waitKV("x", 2, newLeader)
+
+	// The killed leader comes back with what it persisted.
+	c.start(leader)
+
+	c.write("x=3", c.ids...)
+	c.waitKV("x", 3, c.ids...)
+}
+
+func TestFault_rejoinNotDisruptive(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	// wait for the elections at startup to settle down.
+	leader := c.waitStableLeader()
+	f := c.others(leader)[0]
+
+	before := c.votedFor(leader)
+
+	// A partitioned follower keeps calling for election but can not pass
+	// the pre-vote, thus it does not bump its term.
+	c.net.Partition([]int64{f}, c.others(f))
+	time.Sleep(time.Duration(leaderLease) * 5)
+	ta.True(c.votedFor(f).Cmp(before) <= 0, "%s", c.votedFor(f))
+
+	c.net.Heal()
+	c.write("x=2", c.ids...)
+	time.Sleep(time.Duration(leaderLease) * 2)
+
+	ta.True(c.isLeader(leader))
+	ta.Equal(before, c.votedFor(leader))
+	c.waitKV("x", 2, c.ids...)
+}
+
+func TestFault_staleLogRejoin(t *testing.T) {
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	leader := c.waitLeader(c.ids...)
+	f, stale := c.others(leader)[0], c.others(leader)[1]
+
+	c.net.Partition([]int64{leader, f}, []int64{stale})
+	c.write("x=2", leader, f)
+
+	// Replicas with the latest logs are gone one by one. The stale one can not
+	// be elected until it gets logs from the others.
+	c.kill(leader)
+	c.net.Heal()
+
+	c.write("x=3", f, stale)
+	c.waitKV("x", 3, f, stale)
+
+	c.kill(f)
+	c.start(leader)
+
+	c.write("x=4", leader, stale)
+	c.waitKV("x", 4, leader, stale)
+}
+
+func TestFault_changeMembers(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+
+	// replace 3 with 4
+	c.add(4)
+	c.changeMembers(1, 2, 4)
+	c.kill(3)
+
+	c.write("x=2", 1, 2, 4)
+	c.waitKV("x", 2, 1, 2, 4)
+
+	for _, id := range []int64{1, 2, 4} {
+		tr := c.get(id)
+		waitFor(ta, tr, func() bool {
+			return tr.Config.ShortStr() == "1,2,4"
+		})
+	}
+
+	// the config is loaded from logs after restart.
+	c.kill(4)
+	c.start(4)
+	ta.Equal("1,2,4", c.config(4).ShortStr())
+
+	// the leader removes itself and steps down.
+	leader := c.waitLeader(1, 2, 4)
+	rest := c.others(leader, 3)
+	c.changeMembers(rest...)
+
+	tr := c.get(leader)
+	waitFor(ta, tr, func() bool {
+		me := tr.Status[leader]
+		return me.VotedFor.Id != leader || tr.clock.Now() > me.VoteExpireAt
+	})
+
+	c.write("x=3", rest...)
+	c.waitKV("x", 3, rest...)
+}
+
+func TestFault_memberAPI(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	leader := c.waitLeader(c.ids...)
+
+	// a follower redirects to the leader.
+	f := c.others(leader)[0]
+	pr, err := c.get(f).RemoveMember(context.Background(), leader, nil)
+	ta.Equal(ErrNotLeader, err)
+	ta.NotNil(pr.OtherLeader)
+
+	stages := []string{}
+	progress := func(pr *ChangeMembersProgress) {
+		stages = append(stages, pr.Stage)
+	}
+
+	c.add(4)
+	pr, err = c.get(leader).AddMember(context.Background(), 4, c.addrs[4], progress)
+	ta.Nil(err)
+	ta.Equal([]string{StageProposed, StageJointCommitted, StageCommitted}, stages)
+	ta.Equal("1,2,3,4", pr.Config.ShortStr())
+	ta.Equal(int64(3), pr.Config.Members[4].Position)
+
+	// replace a follower
+	c.add(5)
+	old := c.others(leader, 4, 5)[0]
+	pr, err = c.get(leader).ReplaceMember(context.Background(), old, 5, c.addrs[5], nil)
+	ta.Nil(err)
+	ta.Equal(StageCommitted, pr.Stage)
+	// the position of `old` is in use by the joint config.
+	ta.Equal(int64(4), pr.Config.Members[5].Position)
+	c.kill(old)
+
+	members := c.others(old)
+	c.write("x=2", members...)
+	c.waitKV("x", 2, members...)
+
+	// the leader removes itself and steps down.
+	leader = c.waitLeader(members...)
+	stages = []string{}
+	pr, err = c.get(leader).RemoveMember(context.Background(), leader, progress)
+	ta.Nil(err)
+	ta.Equal([]string{StageProposed, StageJointCommitted, StageCommitted, StageSteppedDown}, stages)
+	ta.False(c.isLeader(leader))
+
+	rest := c.others(old, leader)
+	ta.NotEqual(leader, c.waitLeader(rest...))
+
+	c.write("x=3", rest...)
+	c.waitKV("x", 3, rest...)
+}
+
+func TestFault_replaceAllMembers(t *testing.T) {
+
+	defer func(n int) {
+		maxForwardLogs = n
+	}(maxForwardLogs)
+
+	// one log in a batch, thus a batch of an earlier log may be lost while a
+	// later one is delivered.
+	maxForwardLogs = 1
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	keys := []string{}
+	for i := 0; i < 20; i++ {
+		k := fmt.Sprintf("k%d", i)
+		keys = append(keys, k)
+		c.write(fmt.Sprintf("%s=%d", k, i), 1, 2, 3)
+	}
+
+	former := []int64{1, 2, 3}
+	members := []int64{4, 5, 6}
+	for _, id := range members {
+		c.add(id)
+	}
+
+	// Logs reach the new members out of order: the joint config may be
+	// accepted by them before the logs committed by the former members.
+	for _, from := range former {
+		for _, to := range members {
+			c.net.SetLinkFault(from, to, LinkFault{
+				DropRate: 0.5,
+				MaxDelay: time.Millisecond * 10,
+			})
+		}
+	}
+
+	c.changeMembers(members...)
+
+	for _, id := range former {
+		c.kill(id)
+	}
+
+	// A leader elected by the new members only has all committed logs.
+	c.waitLeader(members...)
+	for i, k := range keys {
+		c.waitKV(k, int64(i), members...)
+	}
+
+	c.write("x=1", members...)
+	c.waitKV("x", 1, members...)
+}
+
+func TestFault_hierarchicalQuorums(t *testing.T) {
+
+	// 3 datacenters with 3 replicas in each.
+	dcs := [][]int64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+
+	c := newFaultCluster(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		WithQuorums(func(conf *ClusterConfig) ([]uint64, error) {
+			return conf.HierarchicalQuorums(dcs)
+		}))
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	// A whole datacenter and one more replica in each of the others are
+	// lost. The 4 left are not a majority, but are a majority in each of 2
+	// datacenters.
+	for _, id := range []int64{7, 8, 9, 3, 6} {
+		c.kill(id)
+	}
+	rest := []int64{1, 2, 4, 5}
+
+	c.write("x=2", rest...)
+	c.waitKV("x", 2, rest...)
+}
+
+func TestFault_flexibleQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	// commit with any 2 of 5, elect with any 4 of 5.
+	c := newFaultCluster(t, []int64{1, 2, 3, 4, 5},
+		WithQuorums(func(conf *ClusterConfig) ([]uint64, error) {
+			return conf.SizedQuorums(2)
+		}),
+		WithVoteQuorums(func(conf *ClusterConfig) ([]uint64, error) {
+			return conf.SizedQuorums(4)
+		}))
+
+	c.write("x=1", c.ids...)
+	leader := c.waitLeader(c.ids...)
+
+	// The leader and one follower still commit.
+	lost := c.others(leader)[:3]
+	for _, id := range lost {
+		c.kill(id)
+	}
+	rest := c.others(lost...)
+
+	for i := int64(2); i < 5; i++ {
+		reply := c.propose(leader, fmt.Sprintf("x=%d", i), time.Second)
+		ta.NotNil(reply)
+		ta.True(reply.OK, "reply: %+v", reply)
+	}
+	c.waitKV("x", 4, rest...)
+
+	// but they can not elect another leader.
+	c.kill(leader)
+	f := c.others(leader, lost[0], lost[1], lost[2])[0]
+	time.Sleep(time.Duration(leaderLease) * 2)
+	ta.False(c.isLeader(f))
+
+	// A new leader elected by 4 sees all committed logs.
+	for _, id := range lost {
+		c.start(id)
+	}
+	c.write("y=1", c.others(leader)...)
+	c.waitKV("x", 4, c.others(leader)...)
+}
+
+func TestFault_largeCluster(t *testing.T) {
+
+	ids := []int64{}
+	for id := int64(1); id <= 70; id++ {
+		ids = append(ids, id)
+	}
+
+	c := newFaultCluster(t, ids)
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	// The 36 left are just a majority of 70.
+	for _, id := range ids[:34] {
+		c.kill(id)
+	}
+	rest := ids[34:]
+
+	c.write("x=2", rest...)
+	c.waitKV("x", 2, rest...)
+}
@@ -0,0 +1,152 @@
+package traft
+
+// fetchLogs gets logs from replica `from`, which refused this replica for a
+// greater log status.
+// After that this replica has the same log status as `from`, thus it can be
+// elected again, e.g., when `from` is down too.
+func (tr *TRaft) fetchLogs(from *ReplicaInfo) error {
+
+	var req *FetchLogsReq
+	rst := tr.queryOrStop("func", func() error {
+		req = tr.fetchLogsReq()
+		return nil
+	})
+	if rst == nil {
+		return ErrStopped
+	}
+
+	reply, err := tr.transport.SendFetchLogs(from, req)
+	if err != nil {
+		return err
+	}
+
+	rst = tr.queryOrStop("func", func() error {
+		tr.hdlFetchLogsReply(from.Id, reply)
+		return nil
+	})
+	if rst == nil {
+		return ErrStopped
+	}
+	return nil
+}
+
+// fetchLogsReq returns the request to fetch logs this replica does not have.
+// It must be called from Loop().
+func (tr *TRaft) fetchLogsReq() *FetchLogsReq {
+	me := tr.Status[tr.Id]
+	return &FetchLogsReq{
+		Committer: me.Committer.Clone(),
+		Accepted:  me.Accepted.Clone(),
+		Committed: me.Committed.Clone(),
+	}
+}
+
+// hdlFetchLogsReq replies with the log status of this replica, and the logs
+// the sender does not have if this replica has a greater log status.
+// It must be called from Loop().
+func (tr *TRaft) hdlFetchLogsReq(req *FetchLogsReq) *FetchLogsReply {
+	me := tr.Status[tr.Id]
+
+	repl := &FetchLogsReply{
+		Committer: me.Committer.Clone(),
+		Accepted:  me.Accepted.Clone(),
+		Committed: me.Committed.Clone(),
+	}
+
+	if CmpLogStatus(me, req) <= 0 {
+		return repl
+	}
+
+	// logs from another committer will be discarded except committed ones.
+	have := req.Accepted
+	if !me.Committer.Equal(req.Committer) {
+		have = req.Committed
+	}
+
+	for _, lsn := range missingLsns(me.Accepted, have) {
+		r := tr.logs.Get(lsn)
+		if r == nil {
+			// compacted. The sender has to wait for a snapshot from leader.
+			repl.Logs = nil
+			return repl
+		}
+		repl.Logs = append(repl.Logs, r)
+	}
+
+	return repl
+}
+
+// hdlFetchLogsReply makes this replica have the same log status as replica
+// `from`, if `from` has a greater one and sent all logs this replica lacks.
+//
+// Logs accepted from a different committer are discarded first except
+// committed ones, the same as receiving logs from a new leader.
+// It returns true if logs are updated.
+// It must be called from Loop().
+func (tr *TRaft) hdlFetchLogsReply(from int64, reply *FetchLogsReply) bool {
+	me := tr.Status[tr.Id]
+
+	if CmpLogStatus(reply, me) <= 0 {
+		// e.g., a leader has forwarded logs in the meantime.
+		return false
+	}
+
+	have := me.Accepted
+	if !reply.Committer.Equal(me.Committer) {
+		have = me.Committed
+	}
+
+	logs := map[int64]*Record{}
+	for _, r := range reply.Logs {
+		logs[r.Seq] = r
+	}
+
+	missing := missingLsns(reply.Accepted, have)
+	for _, lsn := range missing {
+		if logs[lsn] == nil {
+			lg.Infow("fetch-logs:incomplete",
+				"from", from,
+				"lsn", lsn,
+				"reply.Committer", reply.Committer.ShortStr())
+			return false
+		}
+	}
+
+	// A committer has been granted by a quorum.
+	tr.followGreaterCommitter(reply.Committer)
+
+	if reply.Committer.Cmp(me.Committer) > 0 {
+		tr.discardUncommitted()
+	}
+
+	for _, lsn := range missing {
+		tr.appendLogs(logs[lsn])
+	}
+	me.Accepted.Union(reply.Accepted)
+	me.Committer = reply.Committer.Clone()
+
+	tr.syncLogs()
+	tr.persistHardState()
+
+	tr.followerUpdateCommitted(reply.Committer, reply.Committed)
+
+	tr.sendMsg("fetch-logs:done",
+		"from", from,
+		"committer", me.Committer,
+		"accepted", me.Accepted)
+
+	return true
+}
+
+// missingLsns returns lsns in accepted but not in have.
+func missingLsns(accepted, have *TailBitmap) []int64 {
+	rst := []int64{}
+
+	end := accepted.Len()
+	for lsn := have.Offset; lsn < end; lsn++ {
+		if accepted.Get(lsn) != 0 && have.Get(lsn) == 0 {
+			rst = append(rst, lsn)
+		}
+	}
+	return rst
+}
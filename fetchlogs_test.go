@@ -0,0 +1,175 @@
+package traft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingLsns(t *testing.T) {
+
+	ta := require.New(t)
+
+	bm := NewTailBitmap
+
+	cases := []struct {
+		accepted *TailBitmap
+		have     *TailBitmap
+		want     []int64
+	}{
+		{bm(0), bm(0), []int64{}},
+		{bm(3), bm(0), []int64{0, 1, 2}},
+		{bm(3), bm(2), []int64{2}},
+		{bm(0, 1, 3), bm(0, 1), []int64{3}},
+		{bm(2, 5), bm(0, 1), []int64{0, 5}},
+		{bm(2), bm(5), []int64{}},
+	}
+
+	for i, c := range cases {
+		got := missingLsns(c.accepted, c.have)
+		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestTRaft_hdlFetchLogsReq(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		req      *FetchLogsReq
+		wantLsns []int64
+	}{
+		{
+			&FetchLogsReq{Committer: lid(2, 2), Accepted: bm(2), Committed: bm(1)},
+			[]int64{2, 3},
+		},
+		{
+			// logs of another committer will be discarded by the sender.
+			&FetchLogsReq{Committer: lid(1, 2), Accepted: bm(4), Committed: bm(1)},
+			[]int64{1, 2, 3},
+		},
+		{
+			&FetchLogsReq{Committer: lid(2, 2), Accepted: bm(4), Committed: bm(1)},
+			nil,
+		},
+		{
+			&FetchLogsReq{Committer: lid(3, 3), Accepted: bm(0), Committed: bm(0)},
+			nil,
+		},
+	}
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(2, 2), lid(2, 2), []int64{0, 1, 2, 3}, nil, []int64{0}, lid(2, 2))
+
+	for i, c := range cases {
+		repl := tr.hdlFetchLogsReq(c.req)
+		ta.Equal(lid(2, 2), repl.Committer, "%d-th: case: %+v", i+1, c)
+		ta.Equal(bm(4), repl.Accepted, "%d-th: case: %+v", i+1, c)
+
+		var lsns []int64
+		for _, r := range repl.Logs {
+			lsns = append(lsns, r.Seq)
+		}
+		ta.Equal(c.wantLsns, lsns, "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestTRaft_hdlFetchLogsReply(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	type logState struct {
+		committer *LeaderId
+		author    *LeaderId
+		lsns      []int64
+		committed []int64
+		votedFor  *LeaderId
+	}
+
+	// the replica logs are fetched from
+	from := logState{lid(2, 2), lid(2, 2), []int64{0, 1, 2, 3}, []int64{0}, lid(2, 2)}
+
+	cases := []struct {
+		me logState
+		// logs before this are compacted on `from`
+		compacted int64
+
+		wantOK        bool
+		wantVotedFor  *LeaderId
+		wantCommitted *TailBitmap
+	}{
+		{
+			logState{nil, lid(0, 0), []int64{}, nil, lid(0, 1)},
+			0,
+			true, lid(2, 2), bm(1),
+		},
+		{
+			// logs from an older committer are replaced.
+			logState{lid(1, 3), lid(1, 3), []int64{0, 1, 2}, nil, lid(1, 3)},
+			0,
+			true, lid(2, 2), bm(1),
+		},
+		{
+			// voted for a greater one
+			logState{lid(2, 2), lid(2, 2), []int64{0, 1}, []int64{0}, lid(5, 1)},
+			0,
+			true, lid(5, 1), bm(1),
+		},
+		{
+			logState{lid(3, 3), lid(3, 3), []int64{0}, nil, lid(3, 3)},
+			0,
+			false, lid(3, 3), bm(0),
+		},
+		{
+			logState{nil, lid(0, 0), []int64{}, nil, lid(0, 1)},
+			2,
+			false, lid(0, 1), bm(0),
+		},
+	}
+
+	ids := map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"}
+
+	for i, c := range cases {
+		src := NewTRaft(2, ids)
+		src.initTraft(from.committer, from.author, from.lsns, nil, from.committed, from.votedFor)
+		if c.compacted > 0 {
+			logs := src.logs.Range(c.compacted, 4)
+			src.logs = NewMemStorage(c.compacted)
+			src.appendLogs(logs...)
+		}
+
+		tr := NewTRaft(1, ids)
+		m := c.me
+		tr.initTraft(m.committer, m.author, m.lsns, nil, m.committed, m.votedFor)
+		me := tr.Status[1]
+
+		req := &FetchLogsReq{
+			Committer: me.Committer.Clone(),
+			Accepted:  me.Accepted.Clone(),
+			Committed: me.Committed.Clone(),
+		}
+		ok := tr.hdlFetchLogsReply(2, src.hdlFetchLogsReq(req))
+
+		ta.Equal(c.wantOK, ok, "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantVotedFor, me.VotedFor, "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantCommitted, me.Committed, "%d-th: case: %+v", i+1, c)
+
+		if c.wantOK {
+			ta.Equal(lid(2, 2), me.Committer, "%d-th: case: %+v", i+1, c)
+			ta.Equal(bm(4), me.Accepted, "%d-th: case: %+v", i+1, c)
+			ta.Equal(RecordsShortStr(src.allLogs(), ""), RecordsShortStr(tr.allLogs(), ""),
+				"%d-th: case: %+v", i+1, c)
+		}
+
+		src.Stop()
+		tr.Stop()
+	}
+}
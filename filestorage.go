@@ -0,0 +1,195 @@
+package traft
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// FileStorage is a LogStorage that writes through a WAL on disk and keeps all
+// records in memory.
+// Every record operation is written to WAL and is replayed when it is opened.
+type FileStorage struct {
+	mem       *MemStorage
+	committed *TailBitmap
+	wal       *WAL
+
+	// the max lsn a WAL segment has ever touched.
+	// A segment can be removed once all of them are reclaimed.
+	segMaxLsn map[int64]int64
+}
+
+// OpenFileStorage opens the WAL in dir and replays it.
+func OpenFileStorage(dir string) (*FileStorage, error) {
+	w, err := OpenWAL(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStorage{
+		mem:       NewMemStorage(0),
+		committed: NewTailBitmap(0),
+		wal:       w,
+		segMaxLsn: map[int64]int64{},
+	}
+
+	err = w.ReadAll(s.replay)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileStorage) replay(seg int64, typ byte, payload []byte) error {
+	switch typ {
+	case walRecord:
+		r := &Record{}
+		err := r.Unmarshal(payload)
+		if err != nil {
+			return errors.Wrapf(err, "unmarshal record")
+		}
+		s.touch(seg, r.Seq)
+		return s.mem.Append(r)
+
+	case walCommitted:
+		c := &TailBitmap{}
+		err := c.Unmarshal(payload)
+		if err != nil {
+			return errors.Wrapf(err, "unmarshal committed")
+		}
+		s.committed.Union(c)
+		return nil
+
+	case walTruncate, walReclaim:
+		if len(payload) != 8 {
+			return errors.Errorf("invalid lsn entry size: %d", len(payload))
+		}
+		lsn := int64(binary.LittleEndian.Uint64(payload))
+		s.touch(seg, lsn)
+		if typ == walTruncate {
+			return s.mem.Truncate(lsn)
+		}
+		return s.mem.Reclaim(lsn)
+
+	default:
+		return errors.Errorf("unknown WAL entry type: %d", typ)
+	}
+}
+
+func (s *FileStorage) touch(seg, lsn int64) {
+	max, ok := s.segMaxLsn[seg]
+	if !ok || max < lsn {
+		s.segMaxLsn[seg] = lsn
+	}
+}
+
+func (s *FileStorage) Append(recs ...*Record) error {
+	err := s.wal.AppendRecords(recs...)
+	if err != nil {
+		return err
+	}
+
+	seg := s.wal.LastSegment()
+	for _, r := range recs {
+		s.touch(seg, r.Seq)
+	}
+
+	return s.mem.Append(recs...)
+}
+
+func (s *FileStorage) Get(lsn int64) *Record {
+	return s.mem.Get(lsn)
+}
+
+func (s *FileStorage) Range(start, end int64) []*Record {
+	return s.mem.Range(start, end)
+}
+
+func (s *FileStorage) Truncate(lsn int64) error {
+	err := s.wal.AppendLsn(walTruncate, lsn)
+	if err != nil {
+		return err
+	}
+	s.touch(s.wal.LastSegment(), lsn)
+
+	return s.mem.Truncate(lsn)
+}
+
+// Reclaim removes records before lsn and removes WAL segments in which every
+// entry is reclaimed.
+func (s *FileStorage) Reclaim(lsn int64) error {
+	err := s.wal.AppendLsn(walReclaim, lsn)
+	if err != nil {
+		return err
+	}
+
+	// the removed segments may have the only copy of committed.
+	err = s.wal.AppendCommitted(s.committed)
+	if err != nil {
+		return err
+	}
+
+	err = s.wal.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = s.mem.Reclaim(lsn)
+	if err != nil {
+		return err
+	}
+
+	// Only remove a prefix of segments: a later entry such as truncate may
+	// affect records in an earlier segment.
+	for {
+		segs := s.wal.Segments()
+		if len(segs) <= 1 {
+			break
+		}
+
+		first := segs[0]
+		max, ok := s.segMaxLsn[first]
+		if ok && max >= lsn {
+			break
+		}
+
+		err = s.wal.RemoveFirstSegment()
+		if err != nil {
+			return err
+		}
+		delete(s.segMaxLsn, first)
+	}
+
+	return nil
+}
+
+func (s *FileStorage) FirstIndex() int64 {
+	return s.mem.FirstIndex()
+}
+
+func (s *FileStorage) LastIndex() int64 {
+	return s.mem.LastIndex()
+}
+
+func (s *FileStorage) SaveCommitted(committed *TailBitmap) error {
+	err := s.wal.AppendCommitted(committed)
+	if err != nil {
+		return err
+	}
+	s.committed = committed.Clone()
+	return nil
+}
+
+func (s *FileStorage) Committed() *TailBitmap {
+	return s.committed.Clone()
+}
+
+func (s *FileStorage) Sync() error {
+	return s.wal.Sync()
+}
+
+func (s *FileStorage) Close() error {
+	return s.wal.Close()
+}
@@ -0,0 +1,144 @@
+package traft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorage(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := OpenFileStorage(tmpDir(t))
+	ta.Nil(err)
+	defer s.Close()
+
+	testLogStorage(t, s)
+}
+
+func TestFileStorage_reopen(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+	rec := func(lsn int64) *Record {
+		return NewRecord(lid(1, 1), lsn, NewCmdI64("set", "x", lsn))
+	}
+
+	s, err := OpenFileStorage(dir)
+	ta.Nil(err)
+
+	ta.Nil(s.Append(rec(0), rec(1), rec(2), rec(3)))
+	ta.Nil(s.Append(&Record{Seq: 1}))
+	ta.Nil(s.Truncate(3))
+	ta.Nil(s.SaveCommitted(NewTailBitmap(0, 0)))
+	ta.Nil(s.Sync())
+	ta.Nil(s.Close())
+
+	s, err = OpenFileStorage(dir)
+	ta.Nil(err)
+
+	ta.Equal(int64(0), s.FirstIndex())
+	ta.Equal(int64(2), s.LastIndex())
+	ta.Equal(
+		"[<001#001:000{set(x, 0)}-0→0>, <>, <001#001:002{set(x, 2)}-0→0>]",
+		RecordsShortStr(s.Range(0, 3)))
+	ta.Equal(NewTailBitmap(0, 0), s.Committed())
+
+	ta.Nil(s.Reclaim(2))
+	ta.Nil(s.Close())
+
+	s, err = OpenFileStorage(dir)
+	ta.Nil(err)
+	defer s.Close()
+
+	ta.Equal(int64(2), s.FirstIndex())
+	ta.Equal(int64(2), s.LastIndex())
+	ta.Equal(NewTailBitmap(0, 0), s.Committed())
+}
+
+func TestFileStorage_Reclaim_removeSegments(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(sz int64) { walSegmentSize = sz }(walSegmentSize)
+	walSegmentSize = 64
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+
+	s, err := OpenFileStorage(dir)
+	ta.Nil(err)
+
+	for i := int64(0); i < 20; i++ {
+		ta.Nil(s.Append(NewRecord(lid(1, 1), i, NewCmdI64("set", "x", i))))
+	}
+	nseg := len(s.wal.Segments())
+	ta.True(nseg > 2)
+
+	ta.Nil(s.Reclaim(10))
+	ta.True(len(s.wal.Segments()) < nseg)
+	ta.Nil(s.Close())
+
+	s, err = OpenFileStorage(dir)
+	ta.Nil(err)
+	defer s.Close()
+
+	ta.Equal(int64(10), s.FirstIndex())
+	ta.Equal(int64(19), s.LastIndex())
+	ta.Equal("<001#001:010{set(x, 10)}-0→0>", s.Get(10).ShortStr())
+}
+
+func TestTRaft_restartWithDir(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	id := int64(1)
+	cluster := map[int64]string{1: ":5501", 2: ":5502"}
+
+	tr := NewTRaft(id, cluster, WithDir(dir))
+	me := tr.Status[id]
+	me.VotedFor = lid(2, 2)
+	me.VoteExpireAt = uSecondI64() + int64(time.Second)
+
+	leader := NewTRaft(2, cluster)
+	leader.Status[2].VotedFor = lid(2, 2)
+	leader.addlogs("x=0", "y=1", "x=2")
+
+	// accept log 1, 2 from leader
+	repl := tr.hdlLogForward(&LogForwardReq{
+		Committer: lid(2, 2),
+		Logs:      leader.allLogs()[1:],
+	})
+	ta.True(repl.OK)
+
+	me.Committed.Union(leader.logs.Get(1).Overrides)
+	tr.saveCommitted()
+
+	// a newer committer erases non-committed log 2
+	me.VotedFor = lid(3, 2)
+	repl = tr.hdlLogForward(&LogForwardReq{
+		Committer: lid(3, 2),
+		Logs:      []*Record{},
+	})
+	ta.True(repl.OK)
+
+	tr.Stop()
+
+	tr = NewTRaft(id, cluster, WithDir(dir))
+	defer tr.Stop()
+
+	me = tr.Status[id]
+	ta.Equal(
+		"[<><002#002:001{set(y, 1)}-0:2→0>]",
+		RecordsShortStr(tr.allLogs(), ""))
+	ta.True(bm(0, 1).Equal(me.Accepted))
+	ta.True(bm(0, 1).Equal(me.Committed))
+}
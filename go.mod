@@ -7,6 +7,7 @@ require (
 	github.com/golang/protobuf v1.4.3
 	github.com/kr/pretty v0.2.1
 	github.com/openacid/low v0.1.22-0.20210209151724-95ca9483dbbb
+	github.com/openacid/slim v0.5.11
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/multierr v1.6.0 // indirect
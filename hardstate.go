@@ -0,0 +1,95 @@
+package traft
+
+import (
+	"github.com/pkg/errors"
+)
+
+const hardStateFn = "hardstate"
+
+// saveHardState writes hs to dir atomically.
+func saveHardState(dir string, hs *HardState) error {
+	b, err := hs.Marshal()
+	if err != nil {
+		return errors.Wrapf(err, "marshal hard state")
+	}
+
+	return writeFileAtomic(dir, hardStateFn, b)
+}
+
+// loadHardState reads hard state from dir.
+// It returns nil HardState if there is none.
+func loadHardState(dir string) (*HardState, error) {
+	b, err := readFileChecked(dir, hardStateFn)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+
+	hs := &HardState{}
+	err = hs.Unmarshal(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal hard state")
+	}
+	return hs, nil
+}
+
+func exportHardState(st *ReplicaStatus) *HardState {
+	return &HardState{
+		VotedFor:     st.VotedFor.Clone(),
+		VoteExpireAt: st.VoteExpireAt,
+		Committer:    st.Committer.Clone(),
+	}
+}
+
+// loadHardStateToMe restores VotedFor, VoteExpireAt and Committer of this
+// replica.
+func (tr *TRaft) loadHardStateToMe() error {
+	hs, err := loadHardState(tr.dir)
+	if err != nil {
+		return err
+	}
+	if hs == nil {
+		return nil
+	}
+
+	me := tr.Status[tr.Id]
+	me.VotedFor = hs.VotedFor.Clone()
+	me.VoteExpireAt = hs.VoteExpireAt
+	me.Committer = hs.Committer.Clone()
+
+	tr.hardState = hs
+
+	lg.Infow("load-hard-state",
+		"Id", tr.Id,
+		"VotedFor", me.VotedFor.ShortStr(),
+		"Committer", me.Committer.ShortStr())
+
+	return nil
+}
+
+// persistHardState saves VotedFor, VoteExpireAt and Committer of this replica
+// if VotedFor or Committer changed since last save.
+// It must be called before a vote is granted or a new Committer is
+// acknowledged.
+func (tr *TRaft) persistHardState() {
+	if tr.dir == "" {
+		return
+	}
+
+	me := tr.Status[tr.Id]
+	prev := tr.hardState
+	if prev != nil &&
+		prev.VotedFor.Equal(me.VotedFor) &&
+		prev.Committer.Equal(me.Committer) {
+		return
+	}
+
+	hs := exportHardState(me)
+	err := saveHardState(tr.dir, hs)
+	if err != nil {
+		lg.Panicw("fail to save hard state", "err", err)
+	}
+	tr.hardState = hs
+}
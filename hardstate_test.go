@@ -0,0 +1,90 @@
+package traft
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHardState_SaveLoad(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+
+	got, err := loadHardState(dir)
+	ta.Nil(err)
+	ta.Nil(got)
+
+	hs := &HardState{
+		VotedFor:     lid(3, 2),
+		VoteExpireAt: 100,
+		Committer:    lid(2, 1),
+	}
+	ta.Nil(saveHardState(dir, hs))
+
+	got, err = loadHardState(dir)
+	ta.Nil(err)
+	ta.Equal(hs, got)
+
+	// overwrite
+	hs.VotedFor = lid(4, 1)
+	ta.Nil(saveHardState(dir, hs))
+
+	got, err = loadHardState(dir)
+	ta.Nil(err)
+	ta.Equal(hs, got)
+
+	// corrupted
+	path := filepath.Join(dir, hardStateFn)
+	buf, err := ioutil.ReadFile(path)
+	ta.Nil(err)
+	buf[len(buf)-1] ^= 1
+	ta.Nil(ioutil.WriteFile(path, buf, 0644))
+
+	_, err = loadHardState(dir)
+	ta.NotNil(err)
+}
+
+func TestTRaft_persistHardState(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	id := int64(1)
+	cluster := map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"}
+
+	tr := NewTRaft(id, cluster, WithDir(dir))
+
+	repl := tr.hdlVoteReq(&VoteReq{
+		Candidate: lid(2, 2),
+		Committer: lid(0, 0),
+		Accepted:  bm(0),
+	})
+	ta.Equal(lid(2, 2), repl.VotedFor)
+	expireAt := tr.Status[id].VoteExpireAt
+
+	tr.Stop()
+
+	// a restarted voter remembers whom it voted for
+	tr = NewTRaft(id, cluster, WithDir(dir))
+	defer tr.Stop()
+
+	me := tr.Status[id]
+	ta.Equal(lid(2, 2), me.VotedFor)
+	ta.Equal(expireAt, me.VoteExpireAt)
+
+	// and does not vote for another candidate with a smaller LeaderId.
+	repl = tr.hdlVoteReq(&VoteReq{
+		Candidate: lid(2, 1),
+		Committer: lid(0, 0),
+		Accepted:  bm(0),
+	})
+	ta.Equal(lid(2, 2), repl.VotedFor)
+}
@@ -0,0 +1,230 @@
+package traft
+
+import "time"
+
+// heartbeatInterval is how often a leader sends heartbeat to followers.
+// It must be much less than leaderLease.
+var heartbeatInterval = time.Millisecond * 200
+
+// the result of sending a heartbeat to a follower.
+type heartbeatRst struct {
+	from *ReplicaInfo
+	ok   bool
+}
+
+// heartbeat sends a LogForwardReq with Heartbeat set and what the leader has
+// committed to every follower.
+// If a quorum acknowledges it, the leader extends its VoteExpireAt.
+// A follower extends its VoteExpireAt when receiving a heartbeat.
+//
+// It returns true if the lease is extended.
+func (tr *TRaft) heartbeat(committer *LeaderId) bool {
+
+	var req *LogForwardReq
+	var config *ClusterConfig
+
+	rst := tr.queryOrStop("func", func() error {
+		var err error
+		req, config, err = tr.heartbeatReq(committer)
+		return err
+	})
+	if rst == nil || rst.err != nil {
+		return false
+	}
+
+	// the lease starts when heartbeat is sent: followers receive it later.
+	sentAt := tr.clock.Now()
+
+	ch := make(chan *heartbeatRst, len(config.Members))
+
+	for _, m := range config.Members {
+		if m.Id == tr.Id {
+			continue
+		}
+
+		go func(ri ReplicaInfo) {
+			ok := false
+			reply, err := tr.transport.SendLogForward(&ri, req)
+			if err == nil {
+				// handled even if it is not collected once a quorum replied.
+				tr.queryOrStop("func", func() error {
+					ok = tr.hdlHeartbeatReply(committer, ri.Id, reply)
+					return nil
+				})
+				if ok {
+					go tr.maybeSendSnapshot(committer, &ri, reply.Accepted)
+				}
+			}
+			ch <- &heartbeatRst{&ri, ok}
+		}(*m)
+	}
+
+	ht := newHeartbeatTally(tr.Id, config)
+	timeout := tr.clock.After(heartbeatInterval)
+
+	for !ht.done() {
+		select {
+		case <-timeout:
+			lg.Infow("heartbeat:timeout", "cmtr", committer.ShortStr())
+			return false
+		case res := <-ch:
+			ht.add(res.from, res.ok)
+		}
+	}
+
+	if !ht.granted() {
+		return false
+	}
+
+	rst = tr.queryOrStop("func", func() error {
+		return tr.extendLease(committer, sentAt)
+	})
+
+	return rst != nil && rst.err == nil
+}
+
+// heartbeatReq returns the heartbeat of leader `committer` and the config whose
+// members it is sent to.
+// It returns ErrLeaderLost if this replica is no longer the leader.
+// It must be called from Loop().
+func (tr *TRaft) heartbeatReq(committer *LeaderId) (*LogForwardReq, *ClusterConfig, error) {
+	me := tr.Status[tr.Id]
+	if !me.VotedFor.Equal(committer) || !me.Committer.Equal(committer) {
+		// A candidate that did not win is not a leader, although it voted
+		// for itself.
+		return nil, nil, ErrLeaderLost
+	}
+
+	req := &LogForwardReq{
+		Committer: committer.Clone(),
+		Committed: me.Committed.Clone(),
+		Heartbeat: true,
+	}
+	return req, tr.Config.Clone(), nil
+}
+
+// hdlHeartbeatReply handles the reply of follower `from` to a heartbeat of
+// leader `committer`.
+// It returns true if the follower acknowledges the leader.
+// It must be called from Loop().
+func (tr *TRaft) hdlHeartbeatReply(committer *LeaderId, from int64, reply *LogForwardReply) bool {
+	if !reply.OK {
+		lg.Infow("heartbeat:refused",
+			"from", from,
+			"VotedFor", reply.VotedFor.ShortStr())
+
+		tr.hdlHeartbeatRefused(committer, reply.VotedFor)
+		return false
+	}
+
+	err := tr.hdlForwardReply(committer, from, reply)
+	return err == nil
+}
+
+// heartbeatTally collects acknowledgements to a heartbeat.
+type heartbeatTally struct {
+	config *ClusterConfig
+
+	// bitmap of positions of members acknowledged the leader.
+	acked MemberSet
+
+	// number of followers not yet replied.
+	waiting int
+}
+
+// newHeartbeatTally creates a heartbeatTally for leader `id` sending heartbeat
+// to members in config.
+func newHeartbeatTally(id int64, config *ClusterConfig) *heartbeatTally {
+	ht := &heartbeatTally{
+		config:  config,
+		acked:   MemberSet{},
+		waiting: len(config.Members),
+	}
+
+	if m := config.Members[id]; m != nil {
+		// a leader removed from the cluster is not counted.
+		ht.acked.Add(m.Position)
+		ht.waiting--
+	}
+	return ht
+}
+
+// add counts a reply from a follower. ok is false if the follower refused or
+// is unreachable.
+func (ht *heartbeatTally) add(from *ReplicaInfo, ok bool) {
+	ht.waiting--
+	if ok {
+		ht.acked.Add(from.Position)
+	}
+}
+
+func (ht *heartbeatTally) granted() bool {
+	return ht.config.IsQuorumSet(ht.acked)
+}
+
+// done returns true if a quorum acknowledged or every follower replied.
+func (ht *heartbeatTally) done() bool {
+	return ht.granted() || ht.waiting <= 0
+}
+
+// extendLease extends the lease of leader `committer` after a quorum
+// acknowledged a heartbeat sent at `sentAt`.
+// It must be called from Loop().
+func (tr *TRaft) extendLease(committer *LeaderId, sentAt int64) error {
+	me := tr.Status[tr.Id]
+	if !me.VotedFor.Equal(committer) {
+		return ErrLeaderLost
+	}
+	me.VoteExpireAt = sentAt + leaderLease
+	return nil
+}
+
+// hdlHeartbeatRefused handles a follower that refuses leader `committer`
+// because it voted for a greater one, e.g., a candidate that did not win.
+// Such a follower never accepts this leader. The leader gives up its lease
+// to elect itself again with a greater term.
+// It must be called from Loop().
+func (tr *TRaft) hdlHeartbeatRefused(committer, votedFor *LeaderId) {
+	me := tr.Status[tr.Id]
+	if !me.VotedFor.Equal(committer) || votedFor.Cmp(committer) <= 0 {
+		return
+	}
+
+	lg.Infow("heartbeat:refused-by-greater",
+		"committer", committer.ShortStr(),
+		"votedFor", votedFor.ShortStr())
+
+	me.VoteExpireAt = 0
+}
+
+// hdlHeartbeat extends the lease of the leader if it is the one this replica
+// voted for.
+// It must be called from Loop().
+func (tr *TRaft) hdlHeartbeat(req *LogForwardReq) *LogForwardReply {
+	me := tr.Status[tr.Id]
+
+	tr.followGreaterCommitter(req.Committer)
+	if req.Committer.Cmp(me.VotedFor) != 0 {
+		lg.Infow("hdl-heartbeat: illegal committer",
+			"req.Commiter", req.Committer,
+			"me.VotedFor", me.VotedFor)
+
+		return &LogForwardReply{
+			OK:        false,
+			VotedFor:  me.VotedFor.Clone(),
+			Committer: me.Committer.Clone(),
+		}
+	}
+
+	me.VoteExpireAt = tr.clock.Now() + leaderLease
+
+	tr.followerUpdateCommitted(req.Committer, req.Committed)
+
+	return &LogForwardReply{
+		OK:        true,
+		VotedFor:  me.VotedFor.Clone(),
+		Accepted:  me.Accepted.Clone(),
+		Committed: me.Committed.Clone(),
+		Committer: me.Committer.Clone(),
+	}
+}
@@ -0,0 +1,221 @@
+package traft
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_hdlHeartbeat(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	id := int64(1)
+	tr := NewTRaft(id, map[int64]string{1: ":5501", 2: ":5502"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(1, 2), lid(1, 2), []int64{0, 1}, nil, []int64{0}, lid(3, 2))
+	me := tr.Status[id]
+	me.VoteExpireAt = uSecondI64() - 1
+
+	// not the voted leader
+	repl := tr.hdlLogForward(&LogForwardReq{Committer: lid(2, 2), Heartbeat: true})
+	ta.False(repl.OK)
+	ta.Equal(lid(3, 2), repl.VotedFor)
+	ta.True(me.VoteExpireAt < uSecondI64())
+
+	repl = tr.hdlLogForward(&LogForwardReq{Committer: lid(3, 2), Heartbeat: true})
+	ta.True(repl.OK)
+	ta.Equal(NewTailBitmap(0, 0, 1), repl.Accepted)
+	ta.Equal(lid(1, 2), repl.Committer)
+	ta.InDelta(uSecondI64()+leaderLease, me.VoteExpireAt, float64(time.Second/10))
+
+	// heartbeat does not change logs or Committer
+	ta.Equal(lid(1, 2), me.Committer)
+	ta.Equal(int64(2), tr.logs.LastIndex()+1)
+
+	// commit what the leader committed
+	repl = tr.hdlLogForward(&LogForwardReq{
+		Committer: lid(1, 2),
+		Committed: NewTailBitmap(5),
+		Heartbeat: true,
+	})
+	ta.False(repl.OK)
+
+	me.VotedFor = lid(1, 2)
+	repl = tr.hdlLogForward(&LogForwardReq{
+		Committer: lid(1, 2),
+		Committed: NewTailBitmap(5),
+		Heartbeat: true,
+	})
+	ta.True(repl.OK)
+	ta.Equal(NewTailBitmap(2), repl.Committed)
+
+	// a greater committer is elected without this replica
+	repl = tr.hdlLogForward(&LogForwardReq{Committer: lid(5, 2), Heartbeat: true})
+	ta.True(repl.OK)
+	ta.Equal(lid(5, 2), repl.VotedFor)
+	ta.Equal(lid(5, 2), me.VotedFor)
+
+	// voted for myself but a smaller candidate of the same term won:
+	// VotedFor never goes down. The leader sees a greater VotedFor in the
+	// reply and gives up its lease.
+	me.VotedFor = lid(6, 1)
+	repl = tr.hdlLogForward(&LogForwardReq{Committer: lid(6, 0), Heartbeat: true})
+	ta.False(repl.OK)
+	ta.Equal(lid(6, 1), repl.VotedFor)
+	ta.Equal(lid(6, 1), me.VotedFor)
+
+	// but a leader does not step down for a smaller one
+	me.VotedFor = lid(7, 1)
+	me.Committer = lid(7, 1)
+	repl = tr.hdlLogForward(&LogForwardReq{Committer: lid(7, 0), Heartbeat: true})
+	ta.False(repl.OK)
+	ta.Equal(lid(7, 1), me.VotedFor)
+}
+
+func TestTRaft_hdlHeartbeatRefused(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	cases := []struct {
+		votedFor     *LeaderId
+		committer    *LeaderId
+		refusedBy    *LeaderId
+		wantLeaseOff bool
+	}{
+		// a follower granted a greater candidate that did not win
+		{lid(1, 1), lid(1, 1), lid(1, 2), true},
+		{lid(1, 1), lid(1, 1), lid(3, 0), true},
+		// refused by a smaller one, the follower will follow me.
+		{lid(1, 1), lid(1, 1), lid(1, 0), false},
+		{lid(1, 1), lid(1, 1), lid(1, 1), false},
+		// no longer the leader that sent the heartbeat
+		{lid(2, 1), lid(1, 1), lid(1, 2), false},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502"})
+		me := tr.Status[tr.Id]
+		me.VotedFor = c.votedFor
+		me.Committer = c.votedFor
+		me.VoteExpireAt = uSecondI64() + leaderLease
+
+		tr.hdlHeartbeatRefused(c.committer, c.refusedBy)
+		ta.Equal(c.wantLeaseOff, me.VoteExpireAt == 0, "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_heartbeat(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	ids := []int64{1, 2, 3}
+	ts := serveCluster(ids)
+	defer stopAll(ts)
+
+	leader := lid(2, 1)
+
+	vote := func(tr *TRaft, v *LeaderId) {
+		query(tr.actionCh, "func", func() error {
+			me := tr.Status[tr.Id]
+			me.VotedFor = v.Clone()
+			me.VoteExpireAt = uSecondI64() + int64(time.Millisecond*10)
+			return nil
+		})
+	}
+
+	expireAt := func(tr *TRaft) int64 {
+		return query(tr.actionCh, "leaderStat", nil).v.(*LeaderStatus).VoteExpireAt
+	}
+
+	for _, tr := range ts {
+		vote(tr, leader)
+	}
+
+	// a candidate that has not yet won does not send heartbeat.
+	ok := ts[0].heartbeat(leader)
+	ta.False(ok)
+
+	query(ts[0].actionCh, "func", func() error {
+		ts[0].Status[ts[0].Id].Committer = leader.Clone()
+		return nil
+	})
+
+	ok = ts[0].heartbeat(leader)
+	ta.True(ok)
+	for _, tr := range ts {
+		// heartbeat returns once a quorum replied.
+		tr := tr
+		waitFor(ta, tr, func() bool {
+			return tr.Status[tr.Id].VoteExpireAt > uSecondI64()+leaderLease/2
+		})
+		ta.InDelta(uSecondI64()+leaderLease, expireAt(tr), float64(time.Second/10))
+	}
+
+	// a quorum is enough
+	vote(ts[1], lid(3, 3))
+	ok = ts[0].heartbeat(leader)
+	ta.True(ok)
+
+	// no quorum
+	vote(ts[2], lid(3, 2))
+	vote(ts[0], leader)
+	ok = ts[0].heartbeat(leader)
+	ta.False(ok)
+	ta.True(expireAt(ts[0]) < uSecondI64()+int64(time.Millisecond*10))
+}
+
+func TestTRaft_VoteLoop_keepLeadership(t *testing.T) {
+
+	defer func(l int64, h time.Duration) {
+		leaderLease = l
+		heartbeatInterval = h
+	}(leaderLease, heartbeatInterval)
+
+	leaderLease = int64(time.Millisecond * 300)
+	heartbeatInterval = time.Millisecond * 50
+
+	lid := NewLeaderId
+
+	withCluster(t, "stableLeader",
+		[]int64{0, 1, 2},
+		func(t *testing.T, ts []*TRaft) {
+			ta := require.New(t)
+
+			ts[0].StartVoteLoop()
+
+			waitForMsg(ts, map[string]int{
+				"vote-win VotedFor:<Term:1 >": 1,
+			})
+
+			ts[1].StartVoteLoop()
+			ts[2].StartVoteLoop()
+
+			time.Sleep(time.Duration(leaderLease) * 4)
+
+			// nobody calls for a new leader
+			for _, tr := range ts {
+				for len(tr.MsgCh) > 0 {
+					msg := <-tr.MsgCh
+					ta.False(strings.Contains(msg, "vote-start"), msg)
+				}
+			}
+
+			for _, tr := range ts {
+				leadst := query(tr.actionCh, "leaderStat", nil).v.(*LeaderStatus)
+				ta.Equal(lid(1, 0), leadst.VotedFor)
+				ta.True(leadst.VoteExpireAt > uSecondI64())
+			}
+		})
+}
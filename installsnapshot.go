@@ -0,0 +1,274 @@
+package traft
+
+import "github.com/pkg/errors"
+
+// snapshotChunkSize is the max size of Data in a SnapshotChunk.
+var snapshotChunkSize = 1024 * 1024
+
+// snapshotSendRetry is the max number of streams opened to send one snapshot.
+// Every retry resumes from where the follower has received.
+var snapshotSendRetry = 3
+
+// snapshotRecv is a partially received snapshot on a follower.
+type snapshotRecv struct {
+	committer *LeaderId
+	offset    int64
+	size      int64
+	data      []byte
+}
+
+func (r *snapshotRecv) match(c *SnapshotChunk) bool {
+	return r.committer.Equal(c.Committer) &&
+		r.offset == c.SnapshotOffset &&
+		r.size == c.SnapshotSize
+}
+
+// needSnapshot returns true if a replica with accepted lacks some log before
+// offset, which can only be provided by a snapshot.
+func needSnapshot(accepted *TailBitmap, offset int64) bool {
+	for i := accepted.Offset; i < offset; i++ {
+		if accepted.Get(i) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeSendSnapshot sends the latest snapshot to a follower if the follower
+// lacks logs the leader has reclaimed.
+// At most one snapshot is being sent to a follower at a time.
+func (tr *TRaft) maybeSendSnapshot(committer *LeaderId, ri *ReplicaInfo, accepted *TailBitmap) {
+
+	var snap *Snapshot
+
+	tr.queryOrStop("func", func() error {
+		if tr.snapshot == nil || tr.sendingSnapshot[ri.Id] {
+			return nil
+		}
+		if !needSnapshot(accepted, tr.snapshot.Offset) {
+			return nil
+		}
+		tr.sendingSnapshot[ri.Id] = true
+		snap = tr.snapshot
+		return nil
+	})
+
+	if snap == nil {
+		return
+	}
+
+	defer tr.queryOrStop("func", func() error {
+		delete(tr.sendingSnapshot, ri.Id)
+		return nil
+	})
+
+	_, err := tr.sendSnapshot(committer, ri, snap)
+	if err != nil {
+		lg.Infow("fail to send snapshot", "to", ri.Id, "err", err)
+	}
+}
+
+// sendSnapshot sends snap to replica ri in chunks.
+// If a stream is broken, it opens another one and resumes from where the
+// receiver has received.
+func (tr *TRaft) sendSnapshot(committer *LeaderId, ri *ReplicaInfo, snap *Snapshot) (*InstallSnapshotReply, error) {
+
+	data, err := snap.Marshal()
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal snapshot")
+	}
+
+	var reply *InstallSnapshotReply
+
+	from := int64(0)
+
+	for i := 0; i < snapshotSendRetry; i++ {
+
+		chunks := buildSnapshotChunks(committer, snap.Offset, data, from)
+		reply, err = tr.transport.SendSnapshotChunks(ri, chunks)
+
+		if err == nil && reply.OK {
+			lg.Infow("send-snapshot:done",
+				"to", ri.Id,
+				"Offset", snap.Offset,
+				"Accepted", reply.Accepted.ShortStr())
+			return reply, nil
+		}
+
+		if reply != nil {
+			if !reply.VotedFor.Equal(committer) {
+				return reply, errors.Wrapf(ErrLeaderLost, "send snapshot")
+			}
+			from = reply.NextChunkOffset
+		}
+
+		lg.Infow("send-snapshot:retry", "to", ri.Id, "from", from, "err", err)
+	}
+
+	if err == nil {
+		err = errors.Errorf("snapshot is not installed")
+	}
+	return reply, errors.Wrapf(err, "send snapshot to %d", ri.Id)
+}
+
+// buildSnapshotChunks splits data[from:] of a marshaled snapshot into chunks
+// to send through one stream.
+func buildSnapshotChunks(
+	committer *LeaderId,
+	offset int64,
+	data []byte,
+	from int64,
+) []*SnapshotChunk {
+
+	chunks := make([]*SnapshotChunk, 0)
+
+	size := int64(len(data))
+
+	for pos := from; ; {
+		end := pos + int64(snapshotChunkSize)
+		if end > size {
+			end = size
+		}
+
+		chunks = append(chunks, &SnapshotChunk{
+			Committer:      committer,
+			SnapshotOffset: offset,
+			SnapshotSize:   size,
+			ChunkOffset:    pos,
+			Data:           data[pos:end],
+			Done:           end == size,
+		})
+
+		pos = end
+		if pos == size {
+			break
+		}
+	}
+
+	return chunks
+}
+
+// hdlSnapshotChunk receives a chunk and installs the snapshot when all chunks
+// are received.
+// It returns false if the chunk is not accepted and the stream should be
+// closed.
+func (tr *TRaft) hdlSnapshotChunk(c *SnapshotChunk) (*InstallSnapshotReply, bool) {
+
+	me := tr.Status[tr.Id]
+	now := tr.clock.Now()
+
+	reply := func(ok bool) *InstallSnapshotReply {
+		rpl := &InstallSnapshotReply{
+			OK:        ok,
+			VotedFor:  me.VotedFor.Clone(),
+			Accepted:  me.Accepted.Clone(),
+			Committed: me.Committed.Clone(),
+		}
+		if tr.snapshotRecv != nil && tr.snapshotRecv.match(c) {
+			rpl.NextChunkOffset = int64(len(tr.snapshotRecv.data))
+		}
+		return rpl
+	}
+
+	if c.Committer.Cmp(me.VotedFor) != 0 || now > me.VoteExpireAt {
+		lg.Infow("hdl-snapshot-chunk: illegal committer",
+			"c.Committer", c.Committer,
+			"me.VotedFor", me.VotedFor,
+			"me.VoteExpireAt-now", me.VoteExpireAt-now)
+		return reply(false), false
+	}
+
+	recv := tr.snapshotRecv
+	if recv == nil || !recv.match(c) {
+		recv = &snapshotRecv{
+			committer: c.Committer.Clone(),
+			offset:    c.SnapshotOffset,
+			size:      c.SnapshotSize,
+			data:      make([]byte, 0, c.SnapshotSize),
+		}
+		tr.snapshotRecv = recv
+	}
+
+	if c.ChunkOffset != int64(len(recv.data)) ||
+		c.ChunkOffset+int64(len(c.Data)) > recv.size {
+
+		lg.Infow("hdl-snapshot-chunk: unexpected chunk",
+			"ChunkOffset", c.ChunkOffset,
+			"len", len(c.Data),
+			"received", len(recv.data),
+			"size", recv.size)
+		return reply(false), false
+	}
+
+	recv.data = append(recv.data, c.Data...)
+
+	if !c.Done {
+		return reply(false), true
+	}
+
+	tr.snapshotRecv = nil
+
+	if int64(len(recv.data)) != recv.size {
+		lg.Infow("hdl-snapshot-chunk: incomplete snapshot",
+			"received", len(recv.data),
+			"size", recv.size)
+		return reply(false), false
+	}
+
+	snap := &Snapshot{}
+	err := snap.Unmarshal(recv.data)
+	if err == nil {
+		err = tr.hdlInstallSnapshot(c.Committer, snap)
+	}
+	if err != nil {
+		lg.Infow("hdl-snapshot-chunk: fail to install", "err", err)
+		return reply(false), false
+	}
+
+	return reply(true), true
+}
+
+// hdlInstallSnapshot replaces the local state with snap, which is received
+// from committer.
+// The state machine is restored from it in ApplyLoop().
+func (tr *TRaft) hdlInstallSnapshot(committer *LeaderId, snap *Snapshot) error {
+
+	me := tr.Status[tr.Id]
+
+	if tr.snapshot != nil && snap.Offset <= tr.snapshot.Offset {
+		return nil
+	}
+
+	if committer.Cmp(me.Committer) > 0 {
+		tr.discardUncommitted()
+	}
+
+	if tr.dir != "" {
+		err := saveSnapshot(tr.dir, snap)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Logs applied locally but not in snap will be applied again to the
+	// restored state machine.
+	me.Applied = NewTailBitmap(0)
+	tr.applyGen++
+	tr.snapshotToRestore = snap
+
+	tr.installSnapshot(snap)
+	me.Committer = committer.Clone()
+
+	tr.saveCommitted()
+	tr.syncLogs()
+	tr.persistHardState()
+	tr.notifyApply()
+
+	lg.Infow("install-snapshot",
+		"Id", tr.Id,
+		"Offset", snap.Offset,
+		"Committer", me.Committer.ShortStr(),
+		"Accepted", me.Accepted.ShortStr())
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package traft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedSnapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	bm := NewTailBitmap
+
+	cases := []struct {
+		accepted *TailBitmap
+		offset   int64
+		want     bool
+	}{
+		{bm(0), 0, false},
+		{bm(0), 1, true},
+		{bm(64), 64, false},
+		{bm(64), 65, true},
+		{bm(0, 0, 1, 2), 3, false},
+		{bm(0, 0, 2), 3, true},
+		{bm(0, 1, 2), 3, true},
+		{bm(128), 64, false},
+	}
+
+	for i, c := range cases {
+		got := needSnapshot(c.accepted, c.offset)
+		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
+	}
+}
+
+// newSnapshotForTest builds a snapshot of `n` logs `k<i%3>=i`.
+func newSnapshotForTest(ta *require.Assertions, n int64) *Snapshot {
+	st := newKVState()
+	for i := int64(0); i < n; i++ {
+		st.Apply(&Record{
+			Seq: i,
+			Cmd: NewCmdI64("set", fmt.Sprintf("k%d", i%3), i),
+		})
+	}
+	data, err := st.Snapshot()
+	ta.Nil(err)
+
+	return &Snapshot{
+		Offset:  n,
+		Applied: NewTailBitmap(n),
+		Data:    data,
+	}
+}
+
+func snapshotChunks(ta *require.Assertions, committer *LeaderId, snap *Snapshot, size int) []*SnapshotChunk {
+	data, err := snap.Marshal()
+	ta.Nil(err)
+
+	chunks := []*SnapshotChunk{}
+	total := int64(len(data))
+	for pos := int64(0); pos < total; pos += int64(size) {
+		end := pos + int64(size)
+		if end > total {
+			end = total
+		}
+		chunks = append(chunks, &SnapshotChunk{
+			Committer:      committer,
+			SnapshotOffset: snap.Offset,
+			SnapshotSize:   total,
+			ChunkOffset:    pos,
+			Data:           data[pos:end],
+			Done:           end == total,
+		})
+	}
+	return chunks
+}
+
+func TestTRaft_hdlSnapshotChunk(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	id := int64(2)
+	tr := NewTRaft(id, map[int64]string{1: ":5501", 2: ":5502"})
+	defer tr.Stop()
+
+	me := tr.Status[id]
+	me.VotedFor = lid(3, 1)
+	me.VoteExpireAt = uSecondI64() + leaderLease
+
+	// an uncommitted log by an older committer
+	me.Committer = lid(1, 1)
+	tr.appendLogs(NewRecord(lid(1, 1), 70, NewCmdI64("set", "x", 70)))
+	me.Accepted.Set(70)
+
+	snap := newSnapshotForTest(ta, 64)
+	chunks := snapshotChunks(ta, lid(3, 1), snap, 10)
+	ta.True(len(chunks) > 2)
+
+	// illegal committer
+	{
+		c := *chunks[0]
+		c.Committer = lid(2, 1)
+		reply, ok := tr.hdlSnapshotChunk(&c)
+		ta.False(ok)
+		ta.False(reply.OK)
+		ta.Equal(lid(3, 1), reply.VotedFor)
+		ta.Nil(tr.snapshotRecv)
+	}
+
+	reply, ok := tr.hdlSnapshotChunk(chunks[0])
+	ta.True(ok)
+	ta.False(reply.OK)
+	ta.Equal(int64(10), reply.NextChunkOffset)
+
+	// out of order chunk is refused with the expected offset
+	reply, ok = tr.hdlSnapshotChunk(chunks[2])
+	ta.False(ok)
+	ta.False(reply.OK)
+	ta.Equal(int64(10), reply.NextChunkOffset)
+
+	// resend a received chunk
+	reply, ok = tr.hdlSnapshotChunk(chunks[0])
+	ta.False(ok)
+	ta.Equal(int64(10), reply.NextChunkOffset)
+
+	for _, c := range chunks[1:] {
+		reply, ok = tr.hdlSnapshotChunk(c)
+		ta.True(ok)
+	}
+
+	ta.True(reply.OK)
+	ta.Nil(tr.snapshotRecv)
+	ta.True(snap.Equal(tr.snapshot))
+
+	// the state machine is restored in ApplyLoop()
+	ta.Equal(int64(1), tr.applyGen)
+	ta.True(snap.Equal(tr.snapshotToRestore))
+
+	ta.Equal(int64(64), tr.logs.FirstIndex())
+	ta.True(tr.logs.Get(70).Empty())
+	ta.Equal(lid(3, 1), me.Committer)
+	ta.Equal(NewTailBitmap(64), me.Accepted)
+	ta.Equal(NewTailBitmap(64), me.Committed)
+	ta.Equal(NewTailBitmap(64), me.Applied)
+
+	// an older snapshot is ignored
+	old := newSnapshotForTest(ta, 3)
+	for _, c := range snapshotChunks(ta, lid(3, 1), old, 1024) {
+		reply, ok = tr.hdlSnapshotChunk(c)
+		ta.True(ok)
+	}
+	ta.True(reply.OK)
+	ta.True(snap.Equal(tr.snapshot))
+}
+
+func TestTRaft_sendSnapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(n int) { snapshotChunkSize = n }(snapshotChunkSize)
+	snapshotChunkSize = 16
+
+	lid := NewLeaderId
+
+	ids := []int64{1, 2, 3}
+	trafts := serveCluster(ids)
+	defer func() {
+		for _, s := range trafts {
+			s.Stop()
+		}
+	}()
+
+	leader := trafts[0]
+	follower := trafts[1]
+	committer := lid(3, 1)
+
+	query(follower.actionCh, "func", func() error {
+		me := follower.Status[follower.Id]
+		me.VotedFor = committer.Clone()
+		me.VoteExpireAt = uSecondI64() + leaderLease
+		return nil
+	})
+
+	snap := newSnapshotForTest(ta, 100)
+	chunks := snapshotChunks(ta, committer, snap, snapshotChunkSize)
+
+	// the follower has received some chunks through a broken stream.
+	for _, c := range chunks[:3] {
+		rst := query(follower.actionCh, "snapshot_chunk", c)
+		ta.True(rst.ok)
+	}
+
+	reply, err := leader.sendSnapshot(committer, leader.Config.Members[2], snap)
+	ta.Nil(err)
+	ta.True(reply.OK)
+	ta.Equal(NewTailBitmap(100), reply.Accepted)
+
+	waitFor(ta, follower, func() bool {
+		return follower.snapshotToRestore == nil
+	})
+
+	query(follower.actionCh, "func", func() error {
+		ta.True(snap.Equal(follower.snapshot))
+		ta.Equal(int64(100), follower.logs.FirstIndex())
+		ta.Equal(NewTailBitmap(100), follower.Status[follower.Id].Applied)
+		return nil
+	})
+
+	// restored by ApplyLoop()
+	st := newKVState()
+	ta.Nil(st.Restore(snap.Data))
+	ta.Equal(kvsIn(st), kvsIn(follower.sm.(*kvState)))
+
+	// the other follower has not voted for committer.
+	reply, err = leader.sendSnapshot(committer, leader.Config.Members[3], snap)
+	ta.NotNil(err)
+	ta.False(reply.OK)
+	ta.Equal(lid(0, 3), reply.VotedFor)
+}
@@ -1,27 +1,145 @@
 package traft
 
-import context "context"
+import (
+	context "context"
+	"io"
+)
 
 func (tr *TRaft) Vote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
-	rst := query(tr.actionCh, "vote", req)
+	rst := tr.queryOrStop("vote", req)
+	if rst == nil {
+		return nil, ErrStopped
+	}
 	return rst.v.(*VoteReply), nil
 }
 
+func (tr *TRaft) PreVote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
+	rst := tr.queryOrStop("pre_vote", req)
+	if rst == nil {
+		return nil, ErrStopped
+	}
+	return rst.v.(*VoteReply), nil
+}
+
+func (tr *TRaft) TimeoutNow(ctx context.Context, req *TimeoutNowReq) (*TimeoutNowReply, error) {
+	rst := tr.queryOrStop("timeout_now", req)
+	if rst == nil {
+		return nil, ErrStopped
+	}
+	return rst.v.(*TimeoutNowReply), nil
+}
+
+func (tr *TRaft) FetchLogs(ctx context.Context, req *FetchLogsReq) (*FetchLogsReply, error) {
+	rst := tr.queryOrStop("fetch_logs", req)
+	if rst == nil {
+		return nil, ErrStopped
+	}
+	return rst.v.(*FetchLogsReply), nil
+}
+
 func (tr *TRaft) LogForward(ctx context.Context, req *LogForwardReq) (*LogForwardReply, error) {
 
 	// TODO: if a newer committer is seen, non-committed logs
 	// can be sure to stale and should be cleaned.
 
-	rst := query(tr.actionCh, "replicate", req)
+	rst := tr.queryOrStop("replicate", req)
+	if rst == nil {
+		return nil, ErrStopped
+	}
 	return rst.v.(*LogForwardReply), nil
 }
 
+// Propose proposes cmd and replies once the log is committed.
 func (tr *TRaft) Propose(ctx context.Context, cmd *Cmd) (*ProposeReply, error) {
+	return tr.proposeAndWait(ctx, &ProposeReq{Cmd: cmd})
+}
+
+// ProposeApplied proposes cmd and replies once the log is applied, with the
+// result of applying it.
+func (tr *TRaft) ProposeApplied(ctx context.Context, cmd *Cmd) (*ProposeReply, error) {
+	return tr.proposeAndWait(ctx, &ProposeReq{Cmd: cmd, WaitApplied: true})
+}
+
+// proposeAndWait returns ctx.Err() if ctx is done before the proposal
+// finishes. The log may still be committed later.
+func (tr *TRaft) proposeAndWait(ctx context.Context, req *ProposeReq) (*ProposeReply, error) {
 	finCh := make(chan *ProposeReply, 1)
-	query(tr.actionCh, "propose", &proposeReq{cmd, finCh})
+	rst := tr.queryOrStop("propose", &proposeReq{req, finCh})
+	if rst == nil {
+		return nil, ErrStopped
+	}
 
 	lg.Infow("waitingFor:finCh")
-	rst := <-finCh
-	lg.Infow("got:finCh", "rst", rst)
-	return rst, nil
+	select {
+	case reply := <-finCh:
+		lg.Infow("got:finCh", "reply", reply)
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-tr.shutdown:
+		return nil, ErrStopped
+	}
+}
+
+// TransferLeadership moves leadership to req.TargetId, e.g., before the leader
+// is taken down for maintenance.
+// Proposals to the leader are refused until it finishes.
+func (tr *TRaft) TransferLeadership(ctx context.Context, req *TransferLeadershipReq) (*TransferLeadershipReply, error) {
+	leader, err := tr.transferLeadership(req.TargetId)
+	if err == ErrStopped {
+		return nil, err
+	}
+
+	reply := &TransferLeadershipReply{
+		OK:          err == nil,
+		OtherLeader: leader,
+	}
+	if err != nil {
+		reply.Err = err.Error()
+	}
+	return reply, nil
+}
+
+// ChangeMembers adds and removes members. A progress is sent each time the
+// change reaches a stage. If the change fails, the last progress has Err set.
+func (tr *TRaft) ChangeMembers(req *ChangeMembersReq, stream TRaft_ChangeMembersServer) error {
+	last, err := tr.changeMembers(stream.Context(), req, func(pr *ChangeMembersProgress) {
+		err := stream.Send(pr)
+		if err != nil {
+			lg.Infow("change-members:fail-to-send-progress", "err", err)
+		}
+	})
+	if err == ErrStopped {
+		return err
+	}
+
+	if err != nil {
+		last.Err = err.Error()
+		return stream.Send(last)
+	}
+	return nil
+}
+
+// InstallSnapshot receives snapshot chunks from leader.
+// The stream is closed once the snapshot is installed or a chunk is refused.
+// A refused sender resumes from InstallSnapshotReply.NextChunkOffset.
+func (tr *TRaft) InstallSnapshot(stream TRaft_InstallSnapshotServer) error {
+	for {
+		c, err := stream.Recv()
+		if err == io.EOF {
+			// sender closed the stream before sending the last chunk.
+			return stream.SendAndClose(&InstallSnapshotReply{OK: false})
+		}
+		if err != nil {
+			return err
+		}
+
+		rst := tr.queryOrStop("snapshot_chunk", c)
+		if rst == nil {
+			return ErrStopped
+		}
+		if !rst.ok || c.Done {
+			return stream.SendAndClose(rst.v.(*InstallSnapshotReply))
+		}
+	}
 }
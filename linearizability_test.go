@@ -0,0 +1,580 @@
+package traft
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var linDuration = flag.Duration("lin.duration", time.Second,
+	"how long to run randomized linearizability tests")
+
+// kvOp is a `set` proposed by a client. Its output is the previous value of
+// the key, returned by kvState.Apply().
+type kvOp struct {
+	client int
+	key    string
+	value  int64
+
+	// the previous value of the key, -1 means absent.
+	prev int64
+
+	// prev is unknown if the proposal is not replied, e.g., it timed out or
+	// was refused by a former leader, or if the log was overridden by a later
+	// one before being applied.
+	prevKnown bool
+
+	call int64
+	ret  int64
+}
+
+func (o *kvOp) String() string {
+	prev := "?"
+	if o.prevKnown {
+		prev = fmt.Sprintf("%d", o.prev)
+	}
+	return fmt.Sprintf("c%d:set(%s,%d)->%s[%d,%d]", o.client, o.key, o.value, prev, o.call, o.ret)
+}
+
+// kvHistory records the call and return of every proposal.
+// Clients may record concurrently.
+type kvHistory struct {
+	mu  sync.Mutex
+	ops []*kvOp
+}
+
+// call records a proposal being sent. Until it is replied, it may take effect
+// at any time after `at`, or never.
+func (h *kvHistory) call(client int, key string, value int64, at int64) *kvOp {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	op := &kvOp{
+		client: client,
+		key:    key,
+		value:  value,
+		call:   at,
+		ret:    math.MaxInt64,
+	}
+	h.ops = append(h.ops, op)
+	return op
+}
+
+// ret records a proposal replied OK with the result of applying it.
+func (h *kvHistory) ret(op *kvOp, reply *ProposeReply, at int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	op.ret = at
+	op.prev = -1
+
+	if len(reply.Result) == 0 {
+		// absent or overridden, can not tell.
+		return
+	}
+
+	cmd := &Cmd{}
+	err := cmd.Unmarshal(reply.Result)
+	if err != nil {
+		panic(err)
+	}
+	op.prev = cmd.GetVI64()
+	op.prevKnown = true
+}
+
+// discard removes a proposal that is refused before becoming a log, thus it
+// never takes effect.
+func (h *kvHistory) discard(op *kvOp) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, o := range h.ops {
+		if o == op {
+			h.ops = append(h.ops[:i], h.ops[i+1:]...)
+			return
+		}
+	}
+}
+
+// refusedBeforeLog returns true if a proposal is refused before it becomes a
+// log, thus it never takes effect.
+func refusedBeforeLog(reply *ProposeReply) bool {
+	return !reply.OK &&
+		(reply.Err == ErrNotLeader.Error() ||
+			reply.Err == "vote expired" ||
+			reply.Err == ErrTransferring.Error())
+}
+
+// checkLinearizable checks if there is a total order of ops that is
+// consistent with real time and in which every op sees the value set by the
+// op right before it.
+// Ops on different keys are independent, thus every key is checked alone.
+// It returns the ops of the first key that can not be linearized.
+func checkLinearizable(ops []*kvOp) (bool, []*kvOp) {
+	byKey := map[string][]*kvOp{}
+	keys := []string{}
+	for _, o := range ops {
+		if _, ok := byKey[o.key]; !ok {
+			keys = append(keys, o.key)
+		}
+		byKey[o.key] = append(byKey[o.key], o)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !newLinChecker(byKey[k]).check() {
+			return false, byKey[k]
+		}
+	}
+	return true, nil
+}
+
+// linChecker searches for a linearization of ops on one key, in the way of
+// Wing & Gong with the memoization by Lowe: a search state that is a set of
+// linearized ops and a value of the key is never visited twice.
+type linChecker struct {
+	ops     []*kvOp
+	visited map[string]bool
+}
+
+func newLinChecker(ops []*kvOp) *linChecker {
+	sorted := append([]*kvOp{}, ops...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].call < sorted[j].call
+	})
+	return &linChecker{
+		ops:     sorted,
+		visited: map[string]bool{},
+	}
+}
+
+func (c *linChecker) check() bool {
+	done := make([]uint64, (len(c.ops)+63)/64)
+	return c.search(done, len(c.ops), -1)
+}
+
+// search returns true if ops not in `done` can be linearized after the key is
+// set to `value`.
+func (c *linChecker) search(done []uint64, left int, value int64) bool {
+	if left == 0 {
+		return true
+	}
+
+	k := fmt.Sprintf("%x:%d", done, value)
+	if c.visited[k] {
+		return false
+	}
+	c.visited[k] = true
+
+	// An op can be the next only if no other pending op returned before it
+	// was called.
+	minRet := int64(math.MaxInt64)
+	for i, o := range c.ops {
+		if done[i>>6]&(1<<uint(i&63)) == 0 && o.ret < minRet {
+			minRet = o.ret
+		}
+	}
+
+	for i, o := range c.ops {
+		if o.call > minRet {
+			// sorted by call
+			break
+		}
+		if done[i>>6]&(1<<uint(i&63)) != 0 {
+			continue
+		}
+
+		for _, next := range o.step(value) {
+			done[i>>6] |= 1 << uint(i&63)
+			ok := c.search(done, left-1, next)
+			done[i>>6] &^= 1 << uint(i&63)
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// step returns the possible values of the key after applying o to a key with
+// value `v`.
+// An op with unknown output may have been overridden without being seen by
+// anyone, or never taken effect, thus the value may stay the same.
+func (o *kvOp) step(v int64) []int64 {
+	if o.prevKnown {
+		if o.prev != v {
+			return nil
+		}
+		return []int64{o.value}
+	}
+	return []int64{o.value, v}
+}
+
+func TestCheckLinearizable(t *testing.T) {
+
+	ta := require.New(t)
+
+	inf := int64(math.MaxInt64)
+
+	op := func(key string, value, prev, call, ret int64) *kvOp {
+		return &kvOp{
+			key:       key,
+			value:     value,
+			prev:      prev,
+			prevKnown: prev != -2,
+			call:      call,
+			ret:       ret,
+		}
+	}
+
+	cases := []struct {
+		ops  []*kvOp
+		want bool
+	}{
+		{[]*kvOp{}, true},
+		{[]*kvOp{op("x", 1, -1, 0, 1)}, true},
+		{[]*kvOp{op("x", 1, 5, 0, 1)}, false},
+		// sequential
+		{[]*kvOp{op("x", 1, -1, 0, 1), op("x", 2, 1, 2, 3)}, true},
+		{[]*kvOp{op("x", 1, -1, 0, 1), op("x", 2, -1, 2, 3)}, false},
+		// concurrent: 2 is linearized before 1
+		{[]*kvOp{op("x", 1, 2, 0, 3), op("x", 2, -1, 1, 2)}, true},
+		// stale read after a write returned
+		{[]*kvOp{op("x", 1, -1, 0, 1), op("x", 2, 1, 2, 3), op("x", 3, 1, 4, 5)}, false},
+		// keys are independent
+		{[]*kvOp{op("x", 1, -1, 0, 1), op("y", 2, -1, 2, 3)}, true},
+		// unknown op may take effect at any time after called
+		{[]*kvOp{op("x", 1, -2, 0, inf), op("x", 2, -1, 2, 3), op("x", 3, 1, 4, 5)}, true},
+		// or never
+		{[]*kvOp{op("x", 1, -2, 0, inf), op("x", 2, -1, 2, 3), op("x", 3, 2, 4, 5)}, true},
+		// but not before called
+		{[]*kvOp{op("x", 2, -1, 0, 1), op("x", 1, -2, 2, inf), op("x", 3, 1, 0, 1)}, false},
+		// an overridden op is not seen by the one overrides it
+		{[]*kvOp{op("x", 1, -2, 0, 1), op("x", 2, -1, 0, 1)}, true},
+	}
+
+	for i, c := range cases {
+		got, _ := checkLinearizable(c.ops)
+		ta.Equal(c.want, got, "%d-th: case: %+v", i+1, c)
+	}
+}
+
+// linClient proposes one `set` at a time to the replica it believes is the
+// leader, and records them in a kvHistory.
+type linClient struct {
+	id     int
+	leader int64
+}
+
+// linWorkload runs clients and random faults in a simulator, and returns the
+// history.
+func linWorkload(s *simulator, nClients int, d time.Duration) *kvHistory {
+
+	h := &kvHistory{}
+
+	keys := []string{"x", "y", "z"}
+	nextValue := int64(0)
+	timeout := time.Second * 2
+
+	var issue func(c *linClient)
+	issue = func(c *linClient) {
+		key := keys[s.rnd.Intn(len(keys))]
+		value := nextValue
+		nextValue++
+
+		op := h.call(c.id, key, value, s.clock.Now())
+		finished := false
+
+		next := func() {
+			finished = true
+			if s.clock.Now() < s.start+int64(d) {
+				s.after(time.Duration(s.rnd.Int63n(int64(time.Millisecond*50))), 0, "client", func(int64) {
+					issue(c)
+				})
+			}
+		}
+
+		req := &ProposeReq{
+			Cmd:         NewCmdI64("set", key, value),
+			WaitApplied: true,
+		}
+		s.proposeReq(0, c.leader, req, func(reply *ProposeReply) {
+			if finished {
+				return
+			}
+
+			if reply.OK {
+				h.ret(op, reply, s.clock.Now())
+			} else {
+				if refusedBeforeLog(reply) {
+					h.discard(op)
+				}
+				// otherwise it is unknown whether it is committed.
+
+				if reply.OtherLeader != nil && reply.OtherLeader.Id != c.leader {
+					c.leader = reply.OtherLeader.Id
+				} else {
+					c.leader = s.ids[s.rnd.Intn(len(s.ids))]
+				}
+			}
+			next()
+		})
+
+		s.after(timeout, 0, "client-timeout", func(int64) {
+			if !finished {
+				c.leader = s.ids[s.rnd.Intn(len(s.ids))]
+				next()
+			}
+		})
+	}
+
+	for i := 0; i < nClients; i++ {
+		c := &linClient{id: i, leader: s.ids[s.rnd.Intn(len(s.ids))]}
+		s.after(0, 0, "client", func(int64) { issue(c) })
+	}
+
+	// faults
+	var nemesis func(int64)
+	nemesis = func(int64) {
+		switch s.rnd.Intn(5) {
+		case 0:
+			ids := append([]int64{}, s.ids...)
+			s.rnd.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+			n := 1 + s.rnd.Intn(len(ids)-1)
+			s.partition(ids[:n], ids[n:])
+		case 1:
+			s.heal()
+		case 2:
+			s.crash(s.ids[s.rnd.Intn(len(s.ids))])
+		default:
+			for _, id := range s.ids {
+				s.restart(id)
+			}
+		}
+
+		if s.clock.Now() < s.start+int64(d) {
+			s.after(time.Duration(s.rnd.Int63n(int64(time.Second*2))), 0, "nemesis", nemesis)
+		}
+	}
+	s.after(time.Second, 0, "nemesis", nemesis)
+
+	s.fault = LinkFault{
+		DropRate: 0.05,
+		DupRate:  0.05,
+		MinDelay: time.Millisecond,
+		MaxDelay: time.Millisecond * 20,
+	}
+
+	s.run(d)
+
+	// let pending proposals finish
+	s.heal()
+	for _, id := range s.ids {
+		s.restart(id)
+	}
+	s.run(timeout)
+
+	return h
+}
+
+func TestTRaft_linearizable(t *testing.T) {
+
+	ta := require.New(t)
+
+	runOnce := func(seed int64) {
+		t.Logf("replay with: -sim.seed=%d", seed)
+
+		s := newSimulator(seed, []int64{1, 2, 3}, t.TempDir())
+		defer s.stop()
+
+		h := linWorkload(s, 4, time.Second*20)
+
+		nOK := 0
+		for _, o := range h.ops {
+			if o.ret != math.MaxInt64 {
+				nOK++
+			}
+		}
+		ta.True(nOK > 0, "seed: %d, no proposal succeeded", seed)
+
+		ok, ops := checkLinearizable(h.ops)
+		if !ok {
+			ss := []string{}
+			for _, o := range ops {
+				ss = append(ss, o.String())
+			}
+			ta.Fail("not linearizable",
+				"ops:\n%s\ntrace:\n%s",
+				strings.Join(ss, "\n"), strings.Join(s.trace, "\n"))
+		}
+	}
+
+	if *simSeed != 0 {
+		runOnce(*simSeed)
+		return
+	}
+
+	start := time.Now()
+	seed := time.Now().UnixNano()
+	n := 0
+	for n == 0 || time.Since(start) < *linDuration {
+		runOnce(seed + int64(n))
+		n++
+	}
+	t.Logf("checked %d histories", n)
+}
+
+
+// linClusterWorkload runs concurrent clients and random faults against real
+// replicas in a faultCluster, and returns the history.
+// Unlike linWorkload, the replicas run their own goroutines and the clock is
+// the wall clock.
+func linClusterWorkload(c *faultCluster, rnd *rand.Rand, nClients int, d time.Duration) *kvHistory {
+
+	h := &kvHistory{}
+
+	keys := []string{"x", "y", "z"}
+	timeout := time.Second * 2
+
+	start := time.Now()
+	now := func() int64 { return int64(time.Since(start)) }
+
+	var nextValue int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < nClients; i++ {
+
+		// a client has its own randomness source: rand.Rand is not safe for
+		// concurrent use.
+		crnd := rand.New(rand.NewSource(rnd.Int63()))
+
+		wg.Add(1)
+		go func(cid int) {
+			defer wg.Done()
+
+			leader := c.ids[crnd.Intn(len(c.ids))]
+
+			for time.Since(start) < d {
+				tr := c.get(leader)
+				if tr == nil {
+					leader = c.ids[crnd.Intn(len(c.ids))]
+					time.Sleep(time.Millisecond * 10)
+					continue
+				}
+
+				key := keys[crnd.Intn(len(keys))]
+				value := atomic.AddInt64(&nextValue, 1)
+
+				op := h.call(cid, key, value, now())
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				reply, err := tr.ProposeApplied(ctx, NewCmdI64("set", key, value))
+				cancel()
+
+				switch {
+				case err != nil:
+					// timed out or the replica is killed: it is unknown
+					// whether it is committed.
+					leader = c.ids[crnd.Intn(len(c.ids))]
+
+				case reply.OK:
+					h.ret(op, reply, now())
+
+				default:
+					if refusedBeforeLog(reply) {
+						h.discard(op)
+					}
+
+					if reply.OtherLeader != nil && reply.OtherLeader.Id != leader {
+						leader = reply.OtherLeader.Id
+					} else {
+						leader = c.ids[crnd.Intn(len(c.ids))]
+						time.Sleep(time.Millisecond * 10)
+					}
+				}
+			}
+		}(i)
+	}
+
+	c.net.SetFault(LinkFault{
+		DropRate: 0.05,
+		DupRate:  0.05,
+		MaxDelay: time.Millisecond * 10,
+	})
+
+	// faults
+	for time.Since(start) < d {
+		time.Sleep(time.Duration(rnd.Int63n(int64(time.Millisecond * 500))))
+
+		switch rnd.Intn(4) {
+		case 0:
+			ids := append([]int64{}, c.ids...)
+			rnd.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+			n := 1 + rnd.Intn(len(ids)-1)
+			c.net.Partition(ids[:n], ids[n:])
+		case 1:
+			c.net.Heal()
+		case 2:
+			id := c.ids[rnd.Intn(len(c.ids))]
+			if c.get(id) != nil {
+				c.kill(id)
+			}
+		default:
+			for _, id := range c.ids {
+				if c.get(id) == nil {
+					c.start(id)
+				}
+			}
+		}
+	}
+
+	// let pending proposals finish
+	c.net.Heal()
+	for _, id := range c.ids {
+		if c.get(id) == nil {
+			c.start(id)
+		}
+	}
+	wg.Wait()
+
+	return h
+}
+
+func TestFault_linearizable(t *testing.T) {
+
+	ta := require.New(t)
+
+	seed := seedForTest(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+	h := linClusterWorkload(c, rand.New(rand.NewSource(seed)), 4, time.Second*3)
+
+	nOK := 0
+	for _, o := range h.ops {
+		if o.ret != math.MaxInt64 {
+			nOK++
+		}
+	}
+	ta.True(nOK > 0, "no proposal succeeded")
+	t.Logf("%d proposals, %d replied", len(h.ops), nOK)
+
+	ok, ops := checkLinearizable(h.ops)
+	if !ok {
+		ss := []string{}
+		for _, o := range ops {
+			ss = append(ss, o.String())
+		}
+		ta.Fail("not linearizable", "ops:\n%s", strings.Join(ss, "\n"))
+	}
+}
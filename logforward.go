@@ -1,107 +1,15 @@
 package traft
 
-import (
-	context "context"
-	"time"
-
-	"github.com/pkg/errors"
-)
-
-// the result of forwarding logs from leader to follower
-type logForwardRst struct {
-	from  *ReplicaInfo
-	reply *LogForwardReply
-	err   error
-}
-
-// forward log from leader to follower
-func (tr *TRaft) forwardLog(
-	committer *LeaderId,
-	config *ClusterConfig,
-	logs []*Record,
-	callback func(*logForwardRst),
-) {
-
-	lsns := []int64{logs[0].Seq, logs[len(logs)-1].Seq + 1}
-	lg.Infow("forward", "LSNs", lsns, "cmtr", committer)
-
-	req := &LogForwardReq{
-		Committer: committer,
-		Logs:      logs,
-	}
-
-	id := tr.Id
-
-	ch := make(chan *logForwardRst)
-
-	for _, m := range config.Members {
-		if m.Id == id {
-			continue
-		}
-
-		go func(ri ReplicaInfo) {
-			rpcTo(ri.Addr, func(cli TRaftClient, ctx context.Context) {
-				reply, err := cli.LogForward(ctx, req)
-				ch <- &logForwardRst{&ri, reply, err}
-			})
-		}(*m)
-	}
-
-	received := uint64(0)
-	// I vote myself
-	received |= 1 << uint(config.Members[id].Position)
-
-	forwardTimeout := uSecond() + time.Second
-
-	waiting := len(config.Members) - 1
-	for waiting > 0 {
-		select {
-		case <-time.After(forwardTimeout - uSecond()):
-			// timeout
-			// TODO cancel timer
-			lg.Infow("forward:timeout", "cmtr", committer.ShortStr())
-			callback(&logForwardRst{
-				err: errors.Wrapf(ErrTimeout, "forward"),
-			})
-			return
-		case res := <-ch:
-			waiting--
-			if res.err != nil {
-				continue
-			}
-
-			if res.reply.OK {
-				received |= 1 << uint(res.from.Position)
-				if config.IsQuorum(received) {
-
-					rst := query(tr.actionCh, "func", func() error {
-						return tr.leaderUpdateCommitted(
-							committer, lsns,
-						)
-					})
-
-					if rst.err == nil {
-						lg.Infow("forward:a-quorum-done")
-						callback(&logForwardRst{})
-					} else {
-						// TODO let the root cause to generate the error
-						callback(&logForwardRst{
-							err: errors.Wrapf(ErrLeaderLost, "forward"),
-						})
-					}
-					return
-				}
-			}
-		}
+func (tr *TRaft) hdlLogForward(req *LogForwardReq) *LogForwardReply {
+	if req.Heartbeat {
+		return tr.hdlHeartbeat(req)
 	}
-}
 
-func (tr *TRaft) hdlLogForward(req *LogForwardReq) *LogForwardReply {
 	id := tr.Id
 	me := tr.Status[id]
-	now := uSecondI64()
+	now := tr.clock.Now()
+	tr.followGreaterCommitter(req.Committer)
 	cr := req.Committer.Cmp(me.VotedFor)
-	// TODO what if req.Committer > me.VotedFor?
 	if cr != 0 || now > me.VoteExpireAt {
 		lg.Infow("hdl-replicate: illegal committer",
 			"req.Commiter", req.Committer,
@@ -109,8 +17,9 @@ func (tr *TRaft) hdlLogForward(req *LogForwardReq) *LogForwardReply {
 			"me.VoteExpireAt-now", me.VoteExpireAt-now)
 
 		return &LogForwardReply{
-			OK:       false,
-			VotedFor: me.VotedFor.Clone(),
+			OK:        false,
+			VotedFor:  me.VotedFor.Clone(),
+			Committer: me.Committer.Clone(),
 		}
 	}
 
@@ -122,57 +31,91 @@ func (tr *TRaft) hdlLogForward(req *LogForwardReq) *LogForwardReply {
 		)
 
 		// if req.Committer is newer, discard all non-committed logs
-		me.Accepted = me.Committed.Clone()
-
-		i := len(tr.Logs) - 1
-		for ; i >= 0; i-- {
-			r := tr.Logs[i]
-			if r.Empty() {
-				continue
-			}
-
-			if me.Accepted.Get(r.Seq) == 0 {
-				tr.Logs[i] = &Record{}
-			}
-		}
+		tr.discardUncommitted()
 	}
 
 	// add new logs
 
 	newlogs := req.Logs
 	for _, r := range newlogs {
-		lsn := r.Seq
-		idx := lsn - tr.LogOffset
-
-		for int(idx) >= len(tr.Logs) {
-			tr.Logs = append(tr.Logs, &Record{})
-		}
-
-		if !tr.Logs[idx].Empty() && !tr.Logs[idx].Equal(r) {
+		prev := tr.logs.Get(r.Seq)
+		if !prev.Empty() && !prev.Equal(r) {
 			panic("wtf")
 		}
-		tr.Logs[idx] = r
+		tr.appendLogs(r)
 
 		me.Accepted.Union(r.Overrides)
+		me.Accepted.Set(r.Seq)
 	}
 
 	// TODO refine me
 	// remove empty logs at top
-	for len(tr.Logs) > 0 {
-		l := len(tr.Logs)
-		if tr.Logs[l-1].Empty() {
-			tr.Logs = tr.Logs[:l-1]
-		} else {
-			break
-		}
+	last := tr.logs.LastIndex()
+	for last >= tr.logs.FirstIndex() && tr.logs.Get(last).Empty() {
+		last--
+	}
+	if last < tr.logs.LastIndex() {
+		tr.truncateLogs(last + 1)
 	}
 
 	me.Committer = req.Committer.Clone()
+	me.VoteExpireAt = now + leaderLease
+
+	tr.followerUpdateCommitted(req.Committer, req.Committed)
+
+	// logs must be durable before telling the leader they are accepted.
+	tr.syncLogs()
+	tr.persistHardState()
 
 	return &LogForwardReply{
 		OK:        true,
 		VotedFor:  me.VotedFor.Clone(),
 		Accepted:  me.Accepted.Clone(),
 		Committed: me.Committed.Clone(),
+		Committer: me.Committer.Clone(),
+	}
+}
+
+// followGreaterCommitter makes this replica vote for a committer greater than
+// the one it voted for.
+// A committer has been granted by a quorum, e.g., this replica may miss the
+// vote request and would refuse the leader forever without it.
+// It must be called from Loop().
+func (tr *TRaft) followGreaterCommitter(committer *LeaderId) {
+	me := tr.Status[tr.Id]
+
+	if committer.Cmp(me.VotedFor) <= 0 {
+		return
+	}
+
+	lg.Infow("follow-greater-committer",
+		"committer", committer,
+		"me.VotedFor", me.VotedFor)
+
+	me.VotedFor = committer.Clone()
+	me.VoteExpireAt = tr.clock.Now() + leaderLease
+	tr.persistHardState()
+	tr.replyLeaderLost()
+}
+
+// discardUncommitted removes all logs that are not committed.
+// It is called when a newer committer is seen: logs proposed by an older
+// committer may never be committed.
+func (tr *TRaft) discardUncommitted() {
+	me := tr.Status[tr.Id]
+	me.Accepted = me.Committed.Clone()
+
+	for _, r := range tr.allLogs() {
+		if r.Empty() {
+			continue
+		}
+
+		if me.Accepted.Get(r.Seq) == 0 {
+			tr.appendLogs(&Record{Seq: r.Seq})
+			tr.replyProposer(r.Seq, &ProposeReply{
+				OK:  false,
+				Err: ErrLeaderLost.Error(),
+			})
+		}
 	}
 }
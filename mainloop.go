@@ -27,6 +27,18 @@ func query(queryCh chan<- *queryBody, operation string, arg interface{}) *queryR
 	return rst
 }
 
+// queryOrStop is the same as query except it returns nil if TRaft is
+// stopped.
+func (tr *TRaft) queryOrStop(operation string, arg interface{}) *queryRst {
+	rstCh := make(chan *queryRst)
+	select {
+	case tr.actionCh <- &queryBody{operation, arg, rstCh}:
+	case <-tr.shutdown:
+		return nil
+	}
+	return <-rstCh
+}
+
 // Loop handles actions from other components.
 func (tr *TRaft) Loop() {
 
@@ -35,6 +47,8 @@ func (tr *TRaft) Loop() {
 
 	id := tr.Id
 
+	tr.resumeReplicators()
+
 	for {
 		select {
 		case <-shutdown:
@@ -61,55 +75,30 @@ func (tr *TRaft) Loop() {
 				a.rstCh <- &queryRst{
 					v: tr.hdlVoteReq(a.arg.(*VoteReq)),
 				}
+			case "pre_vote":
+				a.rstCh <- &queryRst{
+					v: tr.hdlPreVoteReq(a.arg.(*VoteReq)),
+				}
+			case "timeout_now":
+				a.rstCh <- &queryRst{
+					v: tr.hdlTimeoutNow(a.arg.(*TimeoutNowReq)),
+				}
+			case "fetch_logs":
+				a.rstCh <- &queryRst{
+					v: tr.hdlFetchLogsReq(a.arg.(*FetchLogsReq)),
+				}
 			case "set_voted":
-				leadst := a.arg.(*LeaderStatus)
-				me := tr.Status[id]
-				if leadst.VotedFor.Cmp(me.VotedFor) >= 0 {
-					me.VotedFor = leadst.VotedFor.Clone()
-					me.VoteExpireAt = leadst.VoteExpireAt
-					a.rstCh <- &queryRst{ok: true}
-				} else {
-					a.rstCh <- &queryRst{ok: false}
+				a.rstCh <- &queryRst{
+					ok: tr.hdlSetVoted(a.arg.(*LeaderStatus)),
 				}
 			case "update_leaderAndLog":
 				// TODO rename this operation
-
-				lal := a.arg.(*leaderAndVotes)
-				leadst := lal.leaderStat
-				votes := lal.votes
-
-				me := tr.Status[id]
-
-				if leadst.VotedFor.Cmp(me.VotedFor) == 0 {
-					me.VotedFor = leadst.VotedFor.Clone()
-					me.VoteExpireAt = leadst.VoteExpireAt
-
-					tr.internalMergeLogs(votes)
-					// TODO update Committer to this replica
-					// then going on replicating these logs to others.
-					//
-					// TODO update local view of status of other replicas.
-					for _, v := range votes {
-						if v.Committer.Equal(me.Committer) {
-							tr.Status[v.Id].Accepted = v.Accepted.Clone()
-						} else {
-							// if committers are different, the leader can no be
-							// sure whether a follower has identical logs
-							tr.Status[v.Id].Accepted = v.Committed.Clone()
-						}
-						tr.Status[v.Id].Committed = v.Committed.Clone()
-
-						tr.Status[v.Id].Committer = v.Committer.Clone()
-					}
-					me.Committer = leadst.VotedFor.Clone()
-					a.rstCh <- &queryRst{ok: true}
-				} else {
-					a.rstCh <- &queryRst{ok: false}
-				}
+				ok := tr.hdlVoteWon(a.arg.(*leaderAndVotes))
+				a.rstCh <- &queryRst{ok: ok}
 
 			case "propose":
 				p := a.arg.(*proposeReq)
-				tr.hdlPropose(p.cmd, p.finCh)
+				tr.hdlPropose(p.req, p.finCh)
 				a.rstCh <- &queryRst{}
 
 			case "replicate":
@@ -121,6 +110,24 @@ func (tr *TRaft) Loop() {
 					v:  reply,
 				}
 
+			case "snapshot_chunk":
+				// receive a snapshot chunk from leader
+				c := a.arg.(*SnapshotChunk)
+				reply, ok := tr.hdlSnapshotChunk(c)
+				a.rstCh <- &queryRst{
+					ok: ok,
+					v:  reply,
+				}
+
+			case "applicable":
+				a.rstCh <- &queryRst{
+					v: tr.applicableLogs(),
+				}
+
+			case "applied":
+				tr.hdlApplied(a.arg.(*appliedReq))
+				a.rstCh <- &queryRst{}
+
 			case "commit":
 				c := a.arg.(*commitReq)
 				err := tr.leaderUpdateCommitted(
@@ -0,0 +1,470 @@
+package traft
+
+// Members are changed with a joint config, as the raft paper describes:
+// A config log with a joint config of the current members and the new ones is
+// proposed first. Once it is committed, the leader proposes a config log with
+// only the new members.
+//
+// A replica uses the config in the last config log it has, whether it is
+// committed or not. Thus with a joint config, a leader has to be granted by,
+// and a log has to be accepted by, a quorum of both the former and the new
+// members.
+
+import (
+	context "context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Stages a member change reaches, reported by ChangeMembersProgress.
+const (
+	StageProposed       = "proposed"
+	StageJointCommitted = "joint-committed"
+	StageCommitted      = "committed"
+	StageSteppedDown    = "stepped-down"
+)
+
+// changeMembersCheckInterval is how often the leader checks if the joint
+// config is committed, or if it has stepped down after removing itself.
+var changeMembersCheckInterval = time.Millisecond * 10
+
+// AddMember adds replica id at addr to the cluster.
+// The replica should have been started with the addresses of the current
+// members and itself. It gets the config from the logs the leader forwards.
+//
+// progress, if not nil, is called each time the change reaches a stage.
+// It returns the last progress and waits until the new config is committed.
+// If this replica is not the leader, it returns ErrNotLeader and the progress
+// has the leader to send the request to.
+func (tr *TRaft) AddMember(ctx context.Context, id int64, addr string,
+	progress func(*ChangeMembersProgress)) (*ChangeMembersProgress, error) {
+
+	return tr.changeMembers(ctx, &ChangeMembersReq{
+		Add: []*ReplicaInfo{{Id: id, Addr: addr}},
+	}, progress)
+}
+
+// RemoveMember removes member id from the cluster.
+// If the leader removes itself, it waits until the leader steps down, then
+// the other members elect a new one.
+// See AddMember for progress and the returned values.
+func (tr *TRaft) RemoveMember(ctx context.Context, id int64,
+	progress func(*ChangeMembersProgress)) (*ChangeMembersProgress, error) {
+
+	return tr.changeMembers(ctx, &ChangeMembersReq{
+		Remove: []int64{id},
+	}, progress)
+}
+
+// ReplaceMember replaces member oldId with replica id at addr, in one member
+// change.
+// See AddMember for progress and the returned values.
+func (tr *TRaft) ReplaceMember(ctx context.Context, oldId, id int64, addr string,
+	progress func(*ChangeMembersProgress)) (*ChangeMembersProgress, error) {
+
+	return tr.changeMembers(ctx, &ChangeMembersReq{
+		Add:    []*ReplicaInfo{{Id: id, Addr: addr}},
+		Remove: []int64{oldId},
+	}, progress)
+}
+
+// changeMembers proposes the member change in req on the leader, then
+// reports the progress until the new config is committed, and until the leader
+// steps down if it removes itself.
+func (tr *TRaft) changeMembers(ctx context.Context, req *ChangeMembersReq,
+	progress func(*ChangeMembersProgress)) (*ChangeMembersProgress, error) {
+
+	if progress == nil {
+		progress = func(*ChangeMembersProgress) {}
+	}
+
+	finCh := make(chan *ProposeReply, 1)
+
+	var last *ChangeMembersProgress
+	var committer *LeaderId
+	rst := tr.queryOrStop("func", func() error {
+		var err error
+		last, err = tr.hdlChangeMembers(req, finCh)
+		committer = tr.Status[tr.Id].VotedFor.Clone()
+		return err
+	})
+	if rst == nil {
+		return nil, ErrStopped
+	}
+	if rst.err != nil {
+		return last, rst.err
+	}
+	progress(last)
+
+	joint := last
+
+	for {
+		select {
+		case reply := <-finCh:
+			if !reply.OK {
+				return &ChangeMembersProgress{
+					Stage:       last.Stage,
+					OtherLeader: reply.OtherLeader,
+				}, errors.Wrapf(ErrLeaderLost, "changing members: %s", reply.Err)
+			}
+
+			if last.Stage == StageProposed {
+				last = &ChangeMembersProgress{
+					Stage:  StageJointCommitted,
+					Config: joint.Config,
+					Seq:    joint.Seq,
+				}
+				progress(last)
+			}
+
+			last = &ChangeMembersProgress{
+				Stage:  StageCommitted,
+				Config: joint.Config.Next,
+				Seq:    reply.Seq,
+			}
+			progress(last)
+
+			if _, ok := last.Config.Members[tr.Id]; ok {
+				return last, nil
+			}
+			return tr.waitSteppedDown(ctx, last, progress)
+
+		case <-tr.clock.After(changeMembersCheckInterval):
+			if last.Stage != StageProposed {
+				continue
+			}
+
+			committed := false
+			rst := tr.queryOrStop("func", func() error {
+				me := tr.Status[tr.Id]
+				committed = me.VotedFor.Equal(committer) &&
+					me.Committed.Get(joint.Seq) != 0
+				return nil
+			})
+			if rst == nil {
+				return nil, ErrStopped
+			}
+
+			if committed {
+				last = &ChangeMembersProgress{
+					Stage:  StageJointCommitted,
+					Config: joint.Config,
+					Seq:    joint.Seq,
+				}
+				progress(last)
+			}
+
+		case <-ctx.Done():
+			return last, ctx.Err()
+
+		case <-tr.shutdown:
+			return nil, ErrStopped
+		}
+	}
+}
+
+// waitSteppedDown waits until this replica, removed from the committed config
+// `last`, is no longer the leader.
+func (tr *TRaft) waitSteppedDown(ctx context.Context, last *ChangeMembersProgress,
+	progress func(*ChangeMembersProgress)) (*ChangeMembersProgress, error) {
+
+	for {
+		steppedDown := false
+		rst := tr.queryOrStop("func", func() error {
+			me := tr.Status[tr.Id]
+			steppedDown = me.VotedFor.Id != tr.Id || me.VoteExpireAt == 0
+			return nil
+		})
+		if rst == nil {
+			return nil, ErrStopped
+		}
+
+		if steppedDown {
+			last = &ChangeMembersProgress{
+				Stage:  StageSteppedDown,
+				Config: last.Config,
+				Seq:    last.Seq,
+			}
+			progress(last)
+			return last, nil
+		}
+
+		select {
+		case <-tr.clock.After(changeMembersCheckInterval):
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-tr.shutdown:
+			return nil, ErrStopped
+		}
+	}
+}
+
+// hdlChangeMembers proposes the joint config to apply the change in req, if
+// this replica is the leader.
+// A new member is put at the least position no current member uses, and the
+// new config has majority quorums.
+// finCh receives the reply once the new config is committed.
+// It must be called from Loop().
+func (tr *TRaft) hdlChangeMembers(req *ChangeMembersReq, finCh chan<- *ProposeReply) (*ChangeMembersProgress, error) {
+	me := tr.Status[tr.Id]
+	leader := me.VotedFor.Clone()
+
+	pr := &ChangeMembersProgress{}
+
+	if leader.Id != tr.Id ||
+		!leader.Equal(me.Committer) ||
+		tr.clock.Now() > me.VoteExpireAt {
+		pr.OtherLeader = leader
+		return pr, ErrNotLeader
+	}
+
+	if tr.transferring != nil {
+		return pr, errors.Wrapf(ErrTransferring, "to: %d", tr.transferring.target)
+	}
+
+	if tr.Config.Next != nil || !tr.configCommitted() {
+		return pr, ErrConfigChanging
+	}
+
+	next := &ClusterConfig{Members: tr.Config.Clone().Members}
+
+	for _, id := range req.Remove {
+		if _, ok := next.Members[id]; !ok {
+			return pr, errors.Wrapf(ErrNotMember, "remove: %d", id)
+		}
+		delete(next.Members, id)
+	}
+
+	used := tr.Config.membersSet()
+	for _, m := range req.Add {
+		if _, ok := next.Members[m.Id]; ok {
+			return pr, errors.Wrapf(ErrInvalidConfig, "%d is already a member", m.Id)
+		}
+
+		if m.Addr == "" {
+			return pr, errors.Wrapf(ErrInvalidConfig, "no address for %d", m.Id)
+		}
+
+		pos := int64(0)
+		if prev, ok := tr.Config.Members[m.Id]; ok {
+			// removed and added again, e.g., to change its address.
+			pos = prev.Position
+		} else {
+			for used.Has(pos) {
+				pos++
+			}
+			used.Add(pos)
+		}
+
+		next.Members[m.Id] = &ReplicaInfo{
+			Id:       m.Id,
+			Addr:     m.Addr,
+			Position: pos,
+		}
+	}
+
+	joint, err := tr.jointConfigTo(next)
+	if err != nil {
+		return pr, err
+	}
+
+	lsn := tr.propose(NewCmdConfig(joint), false, finCh)
+
+	lg.Infow("change-members:proposed",
+		"lsn", lsn,
+		"config", joint.ShortStr())
+
+	pr.Stage = StageProposed
+	pr.Config = joint
+	pr.Seq = lsn
+	return pr, nil
+}
+
+// lastConfig returns the config in the last config log before lsn, and the
+// lsn of that log.
+// If there is no such log, it returns baseConfig and -1.
+// It must be called from Loop().
+func (tr *TRaft) lastConfig(before int64) (*ClusterConfig, int64) {
+	for lsn := before - 1; lsn >= tr.logs.FirstIndex(); lsn-- {
+		conf := tr.logs.Get(lsn).GetCmd().GetVClusterConfig()
+		if conf != nil {
+			return conf, lsn
+		}
+	}
+	return tr.baseConfig, -1
+}
+
+// loadConfig sets the cluster config to the one in the last config log.
+// It is called when a config log is added or removed.
+// It must be called from Loop(), or before Loop() starts.
+func (tr *TRaft) loadConfig() {
+	conf, lsn := tr.lastConfig(tr.logs.LastIndex() + 1)
+
+	tr.configLsn = lsn
+	tr.setConfig(conf.Clone())
+
+	lg.Infow("load-config",
+		"Id", tr.Id,
+		"lsn", lsn,
+		"config", conf.ShortStr())
+}
+
+// mayChangeConfig loads the config again if recs are config logs after the
+// current one, or overwrite the current one.
+// It must be called from Loop(), after recs are written.
+func (tr *TRaft) mayChangeConfig(recs []*Record) {
+	for _, r := range recs {
+		if r.Seq == tr.configLsn ||
+			r.Seq > tr.configLsn && r.GetCmd().GetVClusterConfig() != nil {
+			tr.loadConfig()
+			return
+		}
+	}
+}
+
+// configCommitted returns true if the log of the current config is committed.
+// It must be called from Loop().
+func (tr *TRaft) configCommitted() bool {
+	return tr.configLsn < 0 || tr.Status[tr.Id].Committed.Get(tr.configLsn) != 0
+}
+
+// jointConfigTo returns the joint config to change the members to those in
+// conf. Majority quorums are built if conf has no quorums.
+// Only one change is allowed at a time.
+// It must be called from Loop().
+func (tr *TRaft) jointConfigTo(conf *ClusterConfig) (*ClusterConfig, error) {
+
+	if tr.Config.Next != nil || !tr.configCommitted() {
+		return nil, ErrConfigChanging
+	}
+
+	next := conf.Clone()
+	next.Next = nil
+	if len(next.Quorums) == 0 && next.QuorumRule == nil {
+		next.setMajorityQuorums()
+	}
+
+	err := tr.Config.checkNext(next)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.Config.jointConfig(next), nil
+}
+
+// finishJointConfig proposes the config log with only the new members, once
+// the joint config is committed and the new members hold all committed logs
+// before it.
+// The proposer of the joint config waits for the new one instead.
+// It must be called from Loop().
+func (tr *TRaft) finishJointConfig() {
+
+	me := tr.Status[tr.Id]
+
+	if tr.Config.Next == nil || !tr.configCommitted() {
+		return
+	}
+
+	if me.VotedFor.Id != tr.Id || !me.VotedFor.Equal(me.Committer) {
+		return
+	}
+
+	jointLsn := tr.configLsn
+
+	if !tr.nextHoldsCommitted(me.VotedFor, jointLsn) {
+		// replicators forward the missing logs to the new members.
+		return
+	}
+
+	rec := tr.AddLog(NewCmdConfig(tr.Config.Next))
+	me.Accepted.Union(rec.Overrides)
+	tr.syncLogs()
+
+	tr.sendMsg("finish-joint-config",
+		"lsn", rec.Seq,
+		"config", tr.Config)
+
+	if p, ok := tr.proposing[jointLsn]; ok {
+		delete(tr.proposing, jointLsn)
+		tr.proposing[rec.Seq] = p
+	}
+
+	tr.leaderCommitAccepted(me.VotedFor)
+	tr.notifyReplicators()
+}
+
+// nextHoldsCommitted returns true if every log committed before lsn is
+// accepted by a quorum of the new members in tr.Config.Next, in the view of
+// leader `committer`.
+//
+// Logs are committed out of order: a log before the joint config may have
+// been committed by a quorum of the former members only. If the former members
+// are dropped before the new ones hold it, a leader elected by the new members
+// may not see it.
+// It must be called from Loop().
+func (tr *TRaft) nextHoldsCommitted(committer *LeaderId, lsn int64) bool {
+
+	me := tr.Status[tr.Id]
+	next := tr.Config.Next
+
+	// Accepted of the members that accepted logs from committer, by position.
+	// All of them have the logs before `from`.
+	accepted := map[int64]*TailBitmap{}
+	acked := MemberSet{}
+	from := lsn
+	for _, m := range next.Members {
+		st := tr.Status[m.Id]
+		if st == nil || !committer.Equal(st.Committer) {
+			continue
+		}
+		accepted[m.Position] = st.Accepted
+		acked.Add(m.Position)
+		if st.Accepted.Offset < from {
+			from = st.Accepted.Offset
+		}
+	}
+
+	if !next.IsQuorumSet(acked) {
+		return false
+	}
+
+	for i := from; i < lsn; i++ {
+		if me.Committed.Get(i) == 0 {
+			continue
+		}
+
+		acked := MemberSet{}
+		for pos, tb := range accepted {
+			if tb.Get(i) != 0 {
+				acked.Add(pos)
+			}
+		}
+		if !next.IsQuorumSet(acked) {
+			return false
+		}
+	}
+	return true
+}
+
+// stepDownIfRemoved gives up the leadership once the config without this
+// replica is committed. Then the members elect a new leader.
+// It must be called from Loop().
+func (tr *TRaft) stepDownIfRemoved() {
+
+	me := tr.Status[tr.Id]
+
+	if tr.Config.Members[tr.Id] != nil || !tr.configCommitted() {
+		return
+	}
+
+	if me.VotedFor.Id != tr.Id || me.VoteExpireAt == 0 {
+		return
+	}
+
+	tr.sendMsg("step-down:removed",
+		"committer", me.VotedFor,
+		"config", tr.Config)
+
+	me.VoteExpireAt = 0
+}
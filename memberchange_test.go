@@ -0,0 +1,300 @@
+package traft
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_changeConfig(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	leader := lid(2, 1)
+	tr.initTraft(leader, leader, []int64{}, nil, nil, leader)
+	tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+
+	// replace 3 with 4
+	conf := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, ":5501", 0},
+			2: {2, ":5502", 1},
+			4: {4, ":5504", 3},
+		},
+	}
+
+	ch := make(chan *ProposeReply, 1)
+	tr.hdlPropose(&ProposeReq{Cmd: NewCmdConfig(conf)}, ch)
+
+	ta.Equal("1,2,3,4→1,2,4", tr.Config.ShortStr())
+	ta.Equal(int64(0), tr.configLsn)
+	ta.NotNil(tr.Status[4])
+
+	// one change at a time
+	ch2 := make(chan *ProposeReply, 1)
+	tr.hdlPropose(&ProposeReq{Cmd: NewCmdConfig(conf)}, ch2)
+	reply := <-ch2
+	ta.False(reply.OK)
+	ta.Equal(ErrConfigChanging.Error(), reply.Err)
+
+	// 1, 2 are a quorum of both {1, 2, 3} and {1, 2, 4}.
+	err := tr.hdlForwardReply(leader, 2, &LogForwardReply{
+		OK:        true,
+		VotedFor:  leader,
+		Committer: leader,
+		Accepted:  bm(1),
+		Committed: bm(0),
+	})
+	ta.Nil(err)
+
+	ta.Equal("1,2,4", tr.Config.ShortStr())
+	ta.Equal(int64(1), tr.configLsn)
+	ta.Equal(0, len(ch), "the proposer waits for the final config")
+
+	err = tr.hdlForwardReply(leader, 4, &LogForwardReply{
+		OK:        true,
+		VotedFor:  leader,
+		Committer: leader,
+		Accepted:  bm(2),
+		Committed: bm(0),
+	})
+	ta.Nil(err)
+
+	reply = <-ch
+	ta.True(reply.OK)
+	ta.Equal(int64(1), reply.Seq)
+	ta.Equal(bm(2), tr.Status[1].Committed)
+}
+
+func TestTRaft_loadConfig(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(1, 2), lid(1, 2), []int64{0}, nil, []int64{0}, lid(1, 2))
+
+	next := &ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			1: {1, ":5501", 0},
+			2: {2, ":5502", 1},
+		},
+		Quorums: []uint64{3},
+	}
+	joint := tr.Config.jointConfig(next)
+
+	// a config takes effect once the log is written, before committed.
+	tr.appendLogs(NewRecord(lid(1, 2), 1, NewCmdConfig(joint)))
+	tr.Status[1].Accepted.Set(1)
+	ta.Equal("1,2,3→1,2", tr.Config.ShortStr())
+	ta.Equal(int64(1), tr.configLsn)
+
+	tr.appendLogs(NewRecord(lid(1, 2), 2, NewCmdI64("set", "x", 2)))
+	tr.Status[1].Accepted.Set(2)
+	ta.Equal(int64(1), tr.configLsn)
+
+	// discarded by a newer leader
+	tr.discardUncommitted()
+	ta.Equal("1,2,3", tr.Config.ShortStr())
+	ta.Equal(int64(-1), tr.configLsn)
+
+	tr.appendLogs(NewRecord(lid(2, 2), 1, NewCmdConfig(next)))
+	tr.Status[1].Accepted.Set(1)
+	ta.Equal("1,2", tr.Config.ShortStr())
+
+	// the config is kept in snapshot after logs are reclaimed.
+	conf, lsn := tr.lastConfig(2)
+	ta.Equal(int64(1), lsn)
+
+	tr.installSnapshot(&Snapshot{Offset: 2, Config: conf})
+	ta.Equal("1,2", tr.Config.ShortStr())
+	ta.Equal(int64(-1), tr.configLsn)
+}
+
+func TestTRaft_hdlChangeMembers(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	ri := func(id int64, addr string) *ReplicaInfo {
+		return &ReplicaInfo{Id: id, Addr: addr}
+	}
+
+	cases := []struct {
+		votedFor *LeaderId
+		req      *ChangeMembersReq
+
+		wantErr       error
+		wantConfig    string
+		wantPositions map[int64]int64
+	}{
+		{
+			lid(2, 1), &ChangeMembersReq{Add: []*ReplicaInfo{ri(4, ":5504")}},
+			nil, "1,2,3,4→1,2,3,4", map[int64]int64{1: 0, 2: 1, 3: 2, 4: 3},
+		},
+		{
+			lid(2, 1), &ChangeMembersReq{Remove: []int64{2}},
+			nil, "1,2,3→1,3", map[int64]int64{1: 0, 3: 2},
+		},
+		{
+			// the position of 2 is in use by the former config.
+			lid(2, 1), &ChangeMembersReq{Add: []*ReplicaInfo{ri(4, ":5504")}, Remove: []int64{2}},
+			nil, "1,2,3,4→1,3,4", map[int64]int64{1: 0, 3: 2, 4: 3},
+		},
+		{
+			// change address
+			lid(2, 1), &ChangeMembersReq{Add: []*ReplicaInfo{ri(2, ":6602")}, Remove: []int64{2}},
+			nil, "1,2,3→1,2,3", map[int64]int64{1: 0, 2: 1, 3: 2},
+		},
+		{
+			lid(2, 1), &ChangeMembersReq{Add: []*ReplicaInfo{ri(2, ":6602")}},
+			ErrInvalidConfig, "1,2,3", nil,
+		},
+		{
+			lid(2, 1), &ChangeMembersReq{Add: []*ReplicaInfo{ri(4, "")}},
+			ErrInvalidConfig, "1,2,3", nil,
+		},
+		{
+			lid(2, 1), &ChangeMembersReq{Remove: []int64{4}},
+			ErrNotMember, "1,2,3", nil,
+		},
+		{
+			lid(2, 1), &ChangeMembersReq{Remove: []int64{1, 2, 3}},
+			ErrInvalidConfig, "1,2,3", nil,
+		},
+		{
+			lid(2, 2), &ChangeMembersReq{Remove: []int64{2}},
+			ErrNotLeader, "1,2,3", nil,
+		},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+		tr.initTraft(c.votedFor, c.votedFor, []int64{}, nil, nil, c.votedFor)
+		tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+
+		finCh := make(chan *ProposeReply, 1)
+		pr, err := tr.hdlChangeMembers(c.req, finCh)
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantConfig, tr.Config.ShortStr(), "%d-th: case: %+v", i+1, c)
+
+		if c.wantErr == ErrNotLeader {
+			ta.Equal(c.votedFor, pr.OtherLeader, "%d-th: case: %+v", i+1, c)
+		}
+
+		if c.wantErr == nil {
+			ta.Equal(StageProposed, pr.Stage, "%d-th: case: %+v", i+1, c)
+			ta.Equal(int64(0), pr.Seq, "%d-th: case: %+v", i+1, c)
+
+			positions := map[int64]int64{}
+			for id, m := range pr.Config.Next.Members {
+				positions[id] = m.Position
+			}
+			ta.Equal(c.wantPositions, positions, "%d-th: case: %+v", i+1, c)
+			ta.True(pr.Config.Next.IsQuorumSet(pr.Config.Next.membersSet()),
+				"%d-th: case: %+v", i+1, c)
+		}
+
+		tr.Stop()
+	}
+
+	// one change at a time
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+	tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+
+	_, err := tr.hdlChangeMembers(&ChangeMembersReq{Remove: []int64{3}}, make(chan *ProposeReply, 1))
+	ta.Nil(err)
+
+	_, err = tr.hdlChangeMembers(&ChangeMembersReq{Remove: []int64{2}}, make(chan *ProposeReply, 1))
+	ta.Equal(ErrConfigChanging, errors.Cause(err))
+}
+
+func TestTRaft_finishJointConfig(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	tr.StartMainLoop()
+	defer tr.Stop()
+
+	leader := lid(2, 1)
+
+	// replace all of the members.
+	// 0, 1, 2 are committed by the former members.
+	inLoop(tr, func() {
+		tr.initTraft(leader, leader, []int64{0, 1, 2}, nil, []int64{0, 1, 2}, leader)
+		tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+
+		_, err := tr.hdlChangeMembers(&ChangeMembersReq{
+			Add: []*ReplicaInfo{
+				{Id: 4, Addr: ":5504"},
+				{Id: 5, Addr: ":5505"},
+				{Id: 6, Addr: ":5506"},
+			},
+			Remove: []int64{1, 2, 3},
+		}, make(chan *ProposeReply, 1))
+		ta.Nil(err)
+	})
+
+	forwardReply := func(fid int64, accepted *TailBitmap) {
+		inLoop(tr, func() {
+			err := tr.hdlForwardReply(leader, fid, &LogForwardReply{
+				OK:        true,
+				VotedFor:  leader,
+				Committer: leader,
+				Accepted:  accepted,
+				Committed: bm(0),
+			})
+			ta.Nil(err)
+		})
+	}
+
+	configOf := func() (string, int64) {
+		var conf string
+		var last int64
+		inLoop(tr, func() {
+			conf = tr.Config.ShortStr()
+			last = tr.logs.LastIndex()
+		})
+		return conf, last
+	}
+
+	// the joint config at 3 is committed by {1, 2} and {4, 5}, but 4 and 5
+	// have none of the logs before it.
+	forwardReply(2, bm(4))
+	forwardReply(4, bm(0, 3))
+	forwardReply(5, bm(0, 3))
+
+	ta.Equal(bm(4), statusOf(tr, 1).Committed)
+	conf, last := configOf()
+	ta.Equal("1,2,3,4,5,6→4,5,6", conf)
+	ta.Equal(int64(3), last, "the final config waits for 4, 5 to hold 0, 1, 2")
+
+	// 4 alone is not a quorum of {4, 5, 6}.
+	forwardReply(4, bm(4))
+	conf, last = configOf()
+	ta.Equal("1,2,3,4,5,6→4,5,6", conf)
+	ta.Equal(int64(3), last)
+
+	forwardReply(5, bm(4))
+	conf, last = configOf()
+	ta.Equal("4,5,6", conf)
+	ta.Equal(int64(4), last)
+}
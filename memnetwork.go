@@ -0,0 +1,418 @@
+package traft
+
+import (
+	context "context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	grpc "google.golang.org/grpc"
+)
+
+// LinkFault describes how messages from one replica to another are disturbed.
+type LinkFault struct {
+	// DropRate is the probability that a request or its reply is lost.
+	DropRate float64
+
+	// DupRate is the probability that a request is delivered twice.
+	DupRate float64
+
+	// A request is delayed by a random duration in [MinDelay, MaxDelay].
+	// Requests sent at the same time may arrive out of order.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// MemNetwork connects replicas in one process without real network.
+// Messages are copied as if they are sent through a wire, and are disturbed
+// according to the LinkFault of every link.
+// It is used to run a whole cluster in a test.
+type MemNetwork struct {
+	mu sync.Mutex
+
+	// replicas indexed by address.
+	servers map[string]TRaftServer
+
+	// the default LinkFault.
+	fault LinkFault
+
+	// LinkFault of specific links, indexed by [from, to].
+	links map[[2]int64]LinkFault
+
+	// links cut by a partition, indexed by [from, to].
+	// A cut link drops every request, no matter what LinkFault it has.
+	cut map[[2]int64]bool
+
+	// decides what happens to every request. It is protected by mu.
+	rnd *rand.Rand
+
+	// delays and timeouts are measured with it.
+	clock Clock
+}
+
+// MemNetworkOption configures a MemNetwork when it is created.
+type MemNetworkOption func(*MemNetwork)
+
+// WithNetworkSeed seeds the randomness of dropping, duplicating and delaying
+// requests, e.g., to replay a test with the same seed.
+// By default it is seeded with the current time.
+func WithNetworkSeed(seed int64) MemNetworkOption {
+	return func(n *MemNetwork) {
+		n.rnd = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithNetworkClock specifies the Clock that delays and timeouts are measured
+// with, usually the same one replicas use.
+// By default it is the wall clock.
+func WithNetworkClock(c Clock) MemNetworkOption {
+	return func(n *MemNetwork) {
+		n.clock = c
+	}
+}
+
+func NewMemNetwork(opts ...MemNetworkOption) *MemNetwork {
+	n := &MemNetwork{
+		servers: map[string]TRaftServer{},
+		links:   map[[2]int64]LinkFault{},
+		cut:     map[[2]int64]bool{},
+	}
+
+	for _, o := range opts {
+		o(n)
+	}
+
+	if n.rnd == nil {
+		n.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if n.clock == nil {
+		n.clock = realClock{}
+	}
+
+	return n
+}
+
+// Register adds a replica that receives requests sent to addr.
+// A former one with the same addr is replaced.
+func (n *MemNetwork) Register(addr string, srv TRaftServer) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.servers[addr] = srv
+}
+
+// Unregister removes the replica at addr. Requests to it fail with
+// ErrUnreachable.
+func (n *MemNetwork) Unregister(addr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.servers, addr)
+}
+
+// SetFault sets the LinkFault of all links without a specific one.
+func (n *MemNetwork) SetFault(f LinkFault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.fault = f
+}
+
+// SetLinkFault sets the LinkFault of the link from replica `from` to `to`.
+func (n *MemNetwork) SetLinkFault(from, to int64, f LinkFault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.links[[2]int64{from, to}] = f
+}
+
+// Partition splits replicas into groups. Replicas in different groups can not
+// reach each other, while links inside a group are not affected.
+// Replicas not in any group are not affected either.
+// It cuts links in addition to former partitions.
+func (n *MemNetwork) Partition(groups ...[]int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, ga := range groups {
+		for j, gb := range groups {
+			if i == j {
+				continue
+			}
+			for _, a := range ga {
+				for _, b := range gb {
+					n.cut[[2]int64{a, b}] = true
+				}
+			}
+		}
+	}
+}
+
+// Cut cuts the link from replica `from` to `to`, in one direction.
+// Requests from `from` are dropped while those from `to` are still delivered,
+// though the replies to them are lost too.
+func (n *MemNetwork) Cut(from, to int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cut[[2]int64{from, to}] = true
+}
+
+// Heal restores all links cut by Partition or Cut.
+// LinkFault-s are kept.
+func (n *MemNetwork) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cut = map[[2]int64]bool{}
+}
+
+// Transport returns the Transport for replica `id` to send requests.
+func (n *MemNetwork) Transport(id int64) Transport {
+	return &memTransport{
+		net:  n,
+		from: id,
+	}
+}
+
+// delivery is what happens to one request.
+type delivery struct {
+	srv       TRaftServer
+	dropReq   bool
+	dropReply bool
+	dup       bool
+	delay     time.Duration
+	dupDelay  time.Duration
+}
+
+func (n *MemNetwork) plan(from int64, to *ReplicaInfo) *delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, ok := n.links[[2]int64{from, to.Id}]
+	if !ok {
+		f = n.fault
+	}
+
+	delay := func() time.Duration {
+		d := f.MinDelay
+		if f.MaxDelay > f.MinDelay {
+			d += time.Duration(n.rnd.Int63n(int64(f.MaxDelay - f.MinDelay)))
+		}
+		return d
+	}
+
+	return &delivery{
+		srv:       n.servers[to.Addr],
+		dropReq:   n.cut[[2]int64{from, to.Id}] || n.rnd.Float64() < f.DropRate,
+		dropReply: n.cut[[2]int64{to.Id, from}] || n.rnd.Float64() < f.DropRate,
+		dup:       n.rnd.Float64() < f.DupRate,
+		delay:     delay(),
+		dupDelay:  delay(),
+	}
+}
+
+// send delivers a request from replica `from` to `to` by calling handle with
+// the receiver, and returns what handle returns.
+func (n *MemNetwork) send(from int64, to *ReplicaInfo,
+	handle func(context.Context, TRaftServer) (interface{}, error)) (interface{}, error) {
+
+	d := n.plan(from, to)
+	if d.srv == nil {
+		return nil, errors.Wrapf(ErrUnreachable, "%d:%s", to.Id, to.Addr)
+	}
+
+	call := func(delay time.Duration) (interface{}, error) {
+		<-n.clock.After(delay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+		defer cancel()
+
+		return handle(ctx, d.srv)
+	}
+
+	if d.dup {
+		go call(d.dupDelay)
+	}
+
+	if d.dropReq {
+		<-n.clock.After(d.delay)
+		return nil, errors.Wrapf(ErrUnreachable, "request to %d dropped", to.Id)
+	}
+
+	type rst struct {
+		v   interface{}
+		err error
+	}
+
+	ch := make(chan *rst, 1)
+	go func() {
+		v, err := call(d.delay)
+		ch <- &rst{v, err}
+	}()
+
+	select {
+	case r := <-ch:
+		if d.dropReply {
+			return nil, errors.Wrapf(ErrUnreachable, "reply from %d dropped", to.Id)
+		}
+		return r.v, r.err
+	case <-n.clock.After(rpcTimeout):
+		return nil, errors.Wrapf(ErrTimeout, "send to %d", to.Id)
+	}
+}
+
+// wireMsg is a message can be sent through a wire.
+type wireMsg interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// copyMsg copies src to dst by marshaling it, thus the sender and receiver
+// do not share any memory.
+func copyMsg(src, dst wireMsg) {
+	b, err := src.Marshal()
+	if err != nil {
+		lg.Panicw("fail to marshal", "msg", src, "err", err)
+	}
+	err = dst.Unmarshal(b)
+	if err != nil {
+		lg.Panicw("fail to unmarshal", "err", err)
+	}
+}
+
+// memTransport sends requests through a MemNetwork for one replica.
+type memTransport struct {
+	net  *MemNetwork
+	from int64
+}
+
+func (t *memTransport) SendVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		r := &VoteReq{}
+		copyMsg(req, r)
+		return srv.Vote(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &VoteReply{}
+	copyMsg(v.(*VoteReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) SendPreVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		r := &VoteReq{}
+		copyMsg(req, r)
+		return srv.PreVote(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &VoteReply{}
+	copyMsg(v.(*VoteReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) SendLogForward(to *ReplicaInfo, req *LogForwardReq) (*LogForwardReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		r := &LogForwardReq{}
+		copyMsg(req, r)
+		return srv.LogForward(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &LogForwardReply{}
+	copyMsg(v.(*LogForwardReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) SendTimeoutNow(to *ReplicaInfo, req *TimeoutNowReq) (*TimeoutNowReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		r := &TimeoutNowReq{}
+		copyMsg(req, r)
+		return srv.TimeoutNow(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &TimeoutNowReply{}
+	copyMsg(v.(*TimeoutNowReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) SendFetchLogs(to *ReplicaInfo, req *FetchLogsReq) (*FetchLogsReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		r := &FetchLogsReq{}
+		copyMsg(req, r)
+		return srv.FetchLogs(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &FetchLogsReply{}
+	copyMsg(v.(*FetchLogsReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) SendSnapshotChunks(to *ReplicaInfo, chunks []*SnapshotChunk) (*InstallSnapshotReply, error) {
+	v, err := t.net.send(t.from, to, func(ctx context.Context, srv TRaftServer) (interface{}, error) {
+		stream := &memSnapshotStream{ctx: ctx}
+		for _, c := range chunks {
+			cc := &SnapshotChunk{}
+			copyMsg(c, cc)
+			stream.chunks = append(stream.chunks, cc)
+		}
+
+		err := srv.InstallSnapshot(stream)
+		if err != nil {
+			return nil, err
+		}
+		return stream.reply, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &InstallSnapshotReply{}
+	copyMsg(v.(*InstallSnapshotReply), reply)
+	return reply, nil
+}
+
+func (t *memTransport) UpdateConfig(conf *ClusterConfig) {}
+func (t *memTransport) Close()                           {}
+
+// memSnapshotStream feeds chunks to TRaftServer.InstallSnapshot.
+type memSnapshotStream struct {
+	// only Context() of ServerStream is used.
+	grpc.ServerStream
+
+	ctx    context.Context
+	chunks []*SnapshotChunk
+	reply  *InstallSnapshotReply
+}
+
+func (s *memSnapshotStream) Context() context.Context { return s.ctx }
+
+func (s *memSnapshotStream) Recv() (*SnapshotChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return c, nil
+}
+
+func (s *memSnapshotStream) SendAndClose(reply *InstallSnapshotReply) error {
+	s.reply = reply
+	return nil
+}
@@ -0,0 +1,291 @@
+package traft
+
+import (
+	context "context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// countServer is a TRaftServer counts received votes.
+type countServer struct {
+	mu    sync.Mutex
+	votes []*VoteReq
+}
+
+func (s *countServer) Vote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.votes = append(s.votes, req)
+	return &VoteReply{VotedFor: req.Candidate}, nil
+}
+
+func (s *countServer) PreVote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
+	return &VoteReply{VotedFor: req.Candidate}, nil
+}
+
+func (s *countServer) LogForward(ctx context.Context, req *LogForwardReq) (*LogForwardReply, error) {
+	return &LogForwardReply{OK: true}, nil
+}
+
+func (s *countServer) Propose(ctx context.Context, cmd *Cmd) (*ProposeReply, error) {
+	return &ProposeReply{OK: true}, nil
+}
+
+func (s *countServer) ProposeApplied(ctx context.Context, cmd *Cmd) (*ProposeReply, error) {
+	return &ProposeReply{OK: true}, nil
+}
+
+func (s *countServer) TransferLeadership(ctx context.Context, req *TransferLeadershipReq) (*TransferLeadershipReply, error) {
+	return &TransferLeadershipReply{OK: true}, nil
+}
+
+func (s *countServer) TimeoutNow(ctx context.Context, req *TimeoutNowReq) (*TimeoutNowReply, error) {
+	return &TimeoutNowReply{OK: true}, nil
+}
+
+func (s *countServer) FetchLogs(ctx context.Context, req *FetchLogsReq) (*FetchLogsReply, error) {
+	return &FetchLogsReply{}, nil
+}
+
+func (s *countServer) ChangeMembers(req *ChangeMembersReq, stream TRaft_ChangeMembersServer) error {
+	return nil
+}
+
+func (s *countServer) InstallSnapshot(stream TRaft_InstallSnapshotServer) error {
+	return stream.SendAndClose(&InstallSnapshotReply{OK: true})
+}
+
+func (s *countServer) nVotes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.votes)
+}
+
+func TestMemNetwork_send(t *testing.T) {
+
+	ta := require.New(t)
+
+	n := NewMemNetwork()
+	srv := &countServer{}
+	n.Register("mem-2", srv)
+
+	tp := n.Transport(1)
+	to := &ReplicaInfo{Id: 2, Addr: "mem-2"}
+	req := &VoteReq{Candidate: NewLeaderId(1, 1)}
+
+	reply, err := tp.SendVote(to, req)
+	ta.Nil(err)
+	ta.Equal(NewLeaderId(1, 1), reply.VotedFor)
+	ta.Equal(1, srv.nVotes())
+
+	// the receiver does not share memory with the sender
+	ta.True(req != srv.votes[0])
+	ta.True(req.Candidate != srv.votes[0].Candidate)
+
+	// unregistered
+	_, err = tp.SendVote(&ReplicaInfo{Id: 3, Addr: "mem-3"}, req)
+	ta.Equal(ErrUnreachable, errors.Cause(err))
+
+	// drop request or reply
+	n.SetFault(LinkFault{DropRate: 1})
+	_, err = tp.SendVote(to, req)
+	ta.Equal(ErrUnreachable, errors.Cause(err))
+
+	// another link is not affected
+	n.SetLinkFault(3, 2, LinkFault{})
+	_, err = n.Transport(3).SendVote(to, req)
+	ta.Nil(err)
+
+	// duplicate
+	n.SetFault(LinkFault{DupRate: 1})
+	before := srv.nVotes()
+	_, err = tp.SendVote(to, req)
+	ta.Nil(err)
+	for i := 0; i < 100 && srv.nVotes() < before+2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	ta.Equal(before+2, srv.nVotes())
+
+	// delay
+	n.SetFault(LinkFault{MinDelay: time.Millisecond * 20, MaxDelay: time.Millisecond * 30})
+	start := time.Now()
+	_, err = tp.SendVote(to, req)
+	ta.Nil(err)
+	ta.True(time.Since(start) >= time.Millisecond*20)
+
+	// snapshot stream
+	n.SetFault(LinkFault{})
+	sreply, err := tp.SendSnapshotChunks(to, []*SnapshotChunk{{Done: true}})
+	ta.Nil(err)
+	ta.True(sreply.OK)
+}
+
+func TestMemNetwork_seedAndClock(t *testing.T) {
+
+	ta := require.New(t)
+
+	// the same seed, the same faults.
+	drops := func(seed int64) []bool {
+		n := NewMemNetwork(WithNetworkSeed(seed))
+		n.SetFault(LinkFault{DropRate: 0.5})
+
+		rst := []bool{}
+		for i := 0; i < 64; i++ {
+			d := n.plan(1, &ReplicaInfo{Id: 2, Addr: "mem-2"})
+			rst = append(rst, d.dropReq, d.dropReply)
+		}
+		return rst
+	}
+	ta.Equal(drops(1), drops(1))
+	ta.NotEqual(drops(1), drops(2))
+
+	// a request is delayed by the clock.
+	clock := NewFakeClock(0)
+	n := NewMemNetwork(WithNetworkClock(clock))
+	n.Register("mem-2", &countServer{})
+	n.SetFault(LinkFault{MinDelay: rpcTimeout / 2, MaxDelay: rpcTimeout / 2})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := n.Transport(1).SendVote(&ReplicaInfo{Id: 2, Addr: "mem-2"},
+			&VoteReq{Candidate: NewLeaderId(1, 1)})
+		errCh <- err
+	}()
+
+	// wait for the delay and the timeout to be set.
+	nTimers := func() int {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return len(clock.timers)
+	}
+	for i := 0; i < 1000 && nTimers() < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	ta.Equal(2, nTimers())
+
+	select {
+	case <-errCh:
+		ta.Fail("delivered before the clock moves")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	clock.Advance(rpcTimeout / 2)
+	ta.Nil(<-errCh)
+}
+
+func TestMemNetwork_partition(t *testing.T) {
+
+	ta := require.New(t)
+
+	n := NewMemNetwork()
+	for _, id := range []int64{1, 2, 3} {
+		n.Register(fmt.Sprintf("mem-%d", id), &countServer{})
+	}
+
+	reachable := func(from, to int64) bool {
+		ri := &ReplicaInfo{Id: to, Addr: fmt.Sprintf("mem-%d", to)}
+		_, err := n.Transport(from).SendVote(ri, &VoteReq{Candidate: NewLeaderId(1, from)})
+		return err == nil
+	}
+
+	cases := []struct {
+		fault func()
+		want  map[[2]int64]bool
+	}{
+		{
+			func() {},
+			map[[2]int64]bool{{1, 2}: true, {2, 1}: true, {1, 3}: true, {3, 1}: true, {2, 3}: true, {3, 2}: true},
+		},
+		{
+			func() { n.Partition([]int64{1}, []int64{2, 3}) },
+			map[[2]int64]bool{{1, 2}: false, {2, 1}: false, {1, 3}: false, {3, 1}: false, {2, 3}: true, {3, 2}: true},
+		},
+		{
+			// the reply of a request from 2 to 1 is lost too.
+			func() { n.Cut(1, 2) },
+			map[[2]int64]bool{{1, 2}: false, {2, 1}: false, {1, 3}: true, {3, 1}: true, {2, 3}: true, {3, 2}: true},
+		},
+		{
+			// replicas not in any group are not affected
+			func() { n.Partition([]int64{1}, []int64{2}) },
+			map[[2]int64]bool{{1, 2}: false, {2, 1}: false, {1, 3}: true, {3, 1}: true, {2, 3}: true, {3, 2}: true},
+		},
+	}
+
+	for i, c := range cases {
+		n.Heal()
+		c.fault()
+		for link, want := range c.want {
+			ta.Equal(want, reachable(link[0], link[1]), "%d-th: link: %v", i+1, link)
+		}
+	}
+
+	// Heal keeps LinkFault-s
+	n.Partition([]int64{1}, []int64{2, 3})
+	n.SetLinkFault(2, 3, LinkFault{DropRate: 1})
+	n.Heal()
+	ta.True(reachable(1, 2))
+	ta.False(reachable(2, 3))
+}
+
+func TestMemNetwork_cluster(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(d time.Duration) {
+		replicateRetryMin = d
+	}(replicateRetryMin)
+	replicateRetryMin = time.Millisecond
+
+	n := NewMemNetwork()
+	n.SetFault(LinkFault{
+		DropRate: 0.05,
+		DupRate:  0.1,
+		MaxDelay: time.Millisecond * 5,
+	})
+
+	ts := serveMemCluster(n, []int64{1, 2, 3})
+	defer stopAll(ts)
+
+	for _, tr := range ts {
+		tr.StartVoteLoop()
+	}
+
+	// propose to every replica until one of them accepts.
+	propose := func(cmd string) *ProposeReply {
+		for i := 0; i < 1000; i++ {
+			for _, tr := range ts {
+				reply, err := tr.Propose(context.Background(), toCmd(cmd))
+				if err == nil && reply.OK {
+					return reply
+				}
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		ta.Fail("no leader accepts proposal")
+		return nil
+	}
+
+	for _, cmd := range []string{"x=1", "y=2", "x=3"} {
+		propose(cmd)
+	}
+
+	// Every replica applies the same state.
+	for _, tr := range ts {
+		tr := tr
+		waitFor(ta, tr, func() bool {
+			kvs := tr.sm.(*kvState)
+			kvs.mu.Lock()
+			defer kvs.mu.Unlock()
+			x, y := kvs.get("x"), kvs.get("y")
+			return x.Equal(NewCmdI64("set", "x", 3)) && y.Equal(NewCmdI64("set", "y", 2))
+		})
+	}
+}
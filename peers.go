@@ -0,0 +1,105 @@
+package traft
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+)
+
+// rpcTimeout is the max time an rpc to other replica takes.
+var rpcTimeout = time.Second
+
+// peerBackoff is how a broken connection to another replica reconnects.
+var peerBackoff = backoff.Config{
+	BaseDelay:  time.Millisecond * 10,
+	Multiplier: 1.6,
+	Jitter:     0.2,
+	MaxDelay:   time.Second,
+}
+
+// peers keeps a long-lived connection to every other replica, indexed by
+// replica id.
+// A connection is created when it is used for the first time, and it
+// reconnects by itself with a backoff once it is broken.
+type peers struct {
+	mu    sync.Mutex
+	conns map[int64]*peerConn
+}
+
+type peerConn struct {
+	addr string
+	conn *grpc.ClientConn
+}
+
+func newPeers() *peers {
+	return &peers{
+		conns: map[int64]*peerConn{},
+	}
+}
+
+// client returns a client to replica ri.
+// If the address of ri changes, the connection to the former address is
+// closed.
+func (p *peers) client(ri *ReplicaInfo) (TRaftClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc, ok := p.conns[ri.Id]
+	if ok && pc.addr == ri.Addr {
+		return NewTRaftClient(pc.conn), nil
+	}
+
+	if ok {
+		pc.conn.Close()
+		delete(p.conns, ri.Id)
+	}
+
+	conn, err := grpc.Dial(ri.Addr,
+		grpc.WithInsecure(),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           peerBackoff,
+			MinConnectTimeout: rpcTimeout,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %d:%s", ri.Id, ri.Addr)
+	}
+
+	p.conns[ri.Id] = &peerConn{
+		addr: ri.Addr,
+		conn: conn,
+	}
+
+	return NewTRaftClient(conn), nil
+}
+
+// updateConfig closes connections to replicas that are removed from the
+// cluster or whose address is changed.
+func (p *peers) updateConfig(conf *ClusterConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, pc := range p.conns {
+		m, ok := conf.Members[id]
+		if ok && m.Addr == pc.addr {
+			continue
+		}
+
+		pc.conn.Close()
+		delete(p.conns, id)
+	}
+}
+
+// close closes all connections.
+func (p *peers) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, pc := range p.conns {
+		pc.conn.Close()
+		delete(p.conns, id)
+	}
+}
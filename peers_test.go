@@ -0,0 +1,92 @@
+package traft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestPeers_client(t *testing.T) {
+
+	ta := require.New(t)
+
+	p := newPeers()
+	defer p.close()
+
+	_, err := p.client(&ReplicaInfo{Id: 2, Addr: ":5502"})
+	ta.Nil(err)
+	c2 := p.conns[2].conn
+
+	// reuse connection
+	_, err = p.client(&ReplicaInfo{Id: 2, Addr: ":5502"})
+	ta.Nil(err)
+	ta.True(c2 == p.conns[2].conn)
+
+	// address changed
+	_, err = p.client(&ReplicaInfo{Id: 2, Addr: ":5512"})
+	ta.Nil(err)
+	ta.True(c2 != p.conns[2].conn)
+	ta.Equal(":5512", p.conns[2].addr)
+	ta.Equal(connectivity.Shutdown, c2.GetState())
+
+	_, err = p.client(&ReplicaInfo{Id: 3, Addr: ":5503"})
+	ta.Nil(err)
+	c3 := p.conns[3].conn
+
+	p.updateConfig(&ClusterConfig{
+		Members: map[int64]*ReplicaInfo{
+			2: {Id: 2, Addr: ":5502"},
+			3: {Id: 3, Addr: ":5503"},
+		},
+	})
+	ta.Equal(1, len(p.conns))
+	ta.True(c3 == p.conns[3].conn)
+
+	p.updateConfig(&ClusterConfig{
+		Members: map[int64]*ReplicaInfo{},
+	})
+	ta.Equal(0, len(p.conns))
+	ta.Equal(connectivity.Shutdown, c3.GetState())
+}
+
+func TestGRPCTransport_reconnect(t *testing.T) {
+
+	ta := require.New(t)
+
+	ids := []int64{1, 2}
+	ts := serveCluster(ids)
+	defer stopAll(ts)
+
+	ri := ts[0].Config.Members[2]
+
+	vote := func() error {
+		_, err := ts[0].transport.SendVote(ri, &VoteReq{
+			Candidate: NewLeaderId(1, 1),
+			Accepted:  NewTailBitmap(0),
+		})
+		return err
+	}
+
+	ta.Nil(vote())
+
+	// a stopped replica returns error
+	ts[1].Stop()
+	ta.NotNil(vote())
+
+	// reconnect when it is back
+	ts[1] = NewTRaft(2, map[int64]string{1: ts[0].Config.Members[1].Addr, 2: ri.Addr})
+	ts[1].StartServer()
+	ts[1].StartMainLoop()
+
+	var err error
+	for i := 0; i < 100; i++ {
+		err = vote()
+		if err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	ta.Nil(err)
+}
@@ -2,14 +2,21 @@ package traft
 
 // request sent to Loop() to propose a cmd
 type proposeReq struct {
-	cmd   *Cmd
+	req   *ProposeReq
 	finCh chan *ProposeReply
 }
 
-func (tr *TRaft) hdlPropose(cmd *Cmd, finCh chan<- *ProposeReply) {
+// proposal is a proposed log waiting to reply to its proposer.
+type proposal struct {
+	committer   *LeaderId
+	waitApplied bool
+	finCh       chan<- *ProposeReply
+}
+
+func (tr *TRaft) hdlPropose(req *ProposeReq, finCh chan<- *ProposeReply) {
 	id := tr.Id
 	me := tr.Status[id]
-	now := uSecondI64()
+	now := tr.clock.Now()
 
 	if now > me.VoteExpireAt {
 		lg.Infow("hdl-propose:VoteExpired", "me.VoteExpireAt-now", me.VoteExpireAt-now)
@@ -31,25 +38,120 @@ func (tr *TRaft) hdlPropose(cmd *Cmd, finCh chan<- *ProposeReply) {
 		return
 	}
 
+	if tr.transferring != nil {
+		finCh <- &ProposeReply{
+			OK:  false,
+			Err: ErrTransferring.Error(),
+		}
+		return
+	}
+
+	cmd := req.Cmd
+	if conf := cmd.GetVClusterConfig(); conf != nil {
+		// change to conf through a joint config.
+		joint, err := tr.jointConfigTo(conf)
+		if err != nil {
+			finCh <- &ProposeReply{
+				OK:  false,
+				Err: err.Error(),
+			}
+			return
+		}
+		cmd = NewCmdConfig(joint)
+	}
+
+	tr.propose(cmd, req.WaitApplied, finCh)
+}
+
+// propose adds a log of cmd on the leader and forwards it to other replicas.
+// finCh receives the reply once the log is committed, or applied if
+// waitApplied.
+// It returns the lsn of the log.
+// It must be called from Loop().
+func (tr *TRaft) propose(cmd *Cmd, waitApplied bool, finCh chan<- *ProposeReply) int64 {
+	me := tr.Status[tr.Id]
+
 	rec := tr.AddLog(cmd)
 	lg.Infow("hdl-propose:added-rec", "rec", rec.ShortStr(), "rec.Overrides:", rec.Overrides.DebugStr())
 
 	me.Accepted.Union(rec.Overrides)
+	tr.syncLogs()
 
-	go tr.forwardLog(
-		me.VotedFor.Clone(),
-		tr.Config.Clone(),
-		[]*Record{rec},
-		func(rst *logForwardRst) {
-			if rst.err != nil {
-				finCh <- &ProposeReply{
-					OK:  false,
-					Err: rst.err.Error(),
-				}
-			} else {
-				finCh <- &ProposeReply{
-					OK: true,
-				}
-			}
+	// reply to proposer when it is committed or applied
+	lsn := rec.Seq
+	tr.proposing[lsn] = &proposal{
+		committer:   me.VotedFor.Clone(),
+		waitApplied: waitApplied,
+		finCh:       finCh,
+	}
+
+	// a single replica cluster commits it at once.
+	tr.leaderCommitAccepted(me.VotedFor)
+	tr.notifyReplicators()
+
+	return lsn
+}
+
+// replyCommitted replies to the proposer of a committed log, unless it waits
+// for the log to be applied.
+// It must be called from Loop().
+func (tr *TRaft) replyCommitted(lsn int64) {
+	p, ok := tr.proposing[lsn]
+	if !ok || p.waitApplied {
+		return
+	}
+
+	tr.replyProposer(lsn, &ProposeReply{OK: true})
+}
+
+// replyApplied replies to the proposer of an applied log.
+// The result is sent only if the proposer waits for it.
+// It must be called from Loop().
+func (tr *TRaft) replyApplied(lsn int64, result []byte) {
+	p, ok := tr.proposing[lsn]
+	if !ok {
+		return
+	}
+
+	reply := &ProposeReply{OK: true}
+	if p.waitApplied {
+		reply.Result = result
+	}
+	tr.replyProposer(lsn, reply)
+}
+
+// replyProposer sends reply to the one proposed the log at lsn, if there is.
+// It must be called from Loop().
+func (tr *TRaft) replyProposer(lsn int64, reply *ProposeReply) {
+	p, ok := tr.proposing[lsn]
+	if !ok {
+		return
+	}
+
+	reply.Seq = lsn
+	if reply.OK {
+		reply.Committer = p.committer
+	}
+
+	delete(tr.proposing, lsn)
+	p.finCh <- reply
+}
+
+// replyLeaderLost replies to proposals made by a former leadership of this
+// replica. They may never be committed, thus the proposers should not wait.
+// It must be called from Loop().
+func (tr *TRaft) replyLeaderLost() {
+	me := tr.Status[tr.Id]
+
+	for lsn, p := range tr.proposing {
+		if p.committer.Equal(me.VotedFor) {
+			continue
+		}
+
+		tr.replyProposer(lsn, &ProposeReply{
+			OK:          false,
+			Err:         "leader lost",
+			OtherLeader: me.VotedFor.Clone(),
 		})
+	}
 }
@@ -0,0 +1,76 @@
+package traft
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_replyLeaderLost(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+
+	oldCh := make(chan *ProposeReply, 1)
+	curCh := make(chan *ProposeReply, 1)
+	tr.proposing[1] = &proposal{committer: lid(1, 1), finCh: oldCh}
+	tr.proposing[2] = &proposal{committer: lid(2, 1), finCh: curCh}
+
+	tr.replyLeaderLost()
+
+	reply := <-oldCh
+	ta.False(reply.OK)
+	ta.Equal(int64(1), reply.Seq)
+	ta.Equal(lid(2, 1), reply.OtherLeader)
+
+	ta.Equal(0, len(curCh))
+	ta.Equal(1, len(tr.proposing))
+
+	// voted for another leader
+	tr.Status[1].VotedFor = lid(3, 2)
+	tr.replyLeaderLost()
+
+	reply = <-curCh
+	ta.False(reply.OK)
+	ta.Empty(tr.proposing)
+}
+
+func TestTRaft_Propose_ctxTimeout(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	// replica 2 and 3 are not running, no quorum accepts the log.
+	n := NewMemNetwork()
+	tr := NewTRaft(1, map[int64]string{1: "mem-1", 2: "mem-2", 3: "mem-3"},
+		WithTransport(n.Transport(1)))
+	n.Register("mem-1", tr)
+	tr.StartMainLoop()
+	defer tr.Stop()
+
+	inLoop(tr, func() {
+		tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+		tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+	})
+
+	for _, propose := range []func(context.Context, *Cmd) (*ProposeReply, error){
+		tr.Propose,
+		tr.ProposeApplied,
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		reply, err := propose(ctx, NewCmdI64("set", "x", 1))
+		cancel()
+
+		ta.Nil(reply)
+		ta.Equal(context.DeadlineExceeded, err)
+	}
+}
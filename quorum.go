@@ -1,14 +1,294 @@
 package traft
 
-import "math/bits"
+import (
+	"math/bits"
+
+	"github.com/pkg/errors"
+)
+
+// maxListedMembers is the max number of members of a cluster whose majority
+// quorums are listed in ClusterConfig.Quorums by default.
+// The number of quorums grows exponentially with members, thus a larger
+// cluster uses a QuorumRule.
+var maxListedMembers = 9
+
+// MemberSet is a set of members: the member at position i is in it if the
+// (i%64)-th bit of the (i/64)-th word is set.
+type MemberSet []uint64
+
+// NewMemberSet returns a set of members at positions.
+func NewMemberSet(positions ...int64) MemberSet {
+	s := MemberSet{}
+	for _, p := range positions {
+		s.Add(p)
+	}
+	return s
+}
+
+// Add puts the member at position pos into the set.
+func (s *MemberSet) Add(pos int64) {
+	i := int(pos >> 6)
+	for len(*s) <= i {
+		*s = append(*s, 0)
+	}
+	(*s)[i] |= 1 << uint(pos&63)
+}
+
+// Has returns true if the member at position pos is in the set.
+func (s MemberSet) Has(pos int64) bool {
+	i := int(pos >> 6)
+	return i < len(s) && s[i]&(1<<uint(pos&63)) != 0
+}
+
+// low returns the set of members at position 0 to 63, as a listed quorum is.
+func (s MemberSet) low() uint64 {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[0]
+}
+
+// without returns a copy of s without members in q, a set of members at
+// position 0 to 63.
+func (s MemberSet) without(q uint64) MemberSet {
+	rst := append(MemberSet{}, s...)
+	if len(rst) > 0 {
+		rst[0] &^= q
+	}
+	return rst
+}
+
+// isListedQuorum returns true if s includes any of quorums.
+func isListedQuorum(quorums []uint64, s MemberSet) bool {
+	for _, q := range quorums {
+		if s.low()&q == q {
+			return true
+		}
+	}
+	return false
+}
+
+// isQuorum returns true if s is a quorum by rule r.
+func (r *QuorumRule) isQuorum(s MemberSet) bool {
+	votes := int64(0)
+	for pos, v := range r.Votes {
+		if s.Has(pos) {
+			votes += v
+		}
+	}
+
+	for _, g := range r.Groups {
+		if votes >= r.Threshold {
+			break
+		}
+		if g.isQuorum(s) {
+			votes++
+		}
+	}
+
+	return votes >= r.Threshold
+}
+
+// totalVotes returns the votes of all members and groups.
+func (r *QuorumRule) totalVotes() int64 {
+	total := int64(len(r.Groups))
+	for _, v := range r.Votes {
+		total += v
+	}
+	return total
+}
+
+// check returns an error if r votes with a member not in members, or no set
+// of members is a quorum by r.
+func (r *QuorumRule) check(members MemberSet) error {
+	for pos, v := range r.Votes {
+		if !members.Has(pos) {
+			return errors.Wrapf(ErrInvalidConfig, "no member at position %d", pos)
+		}
+		if v < 0 {
+			return errors.Wrapf(ErrInvalidConfig, "position %d has negative votes: %d", pos, v)
+		}
+	}
+
+	if r.Threshold <= 0 || r.Threshold > r.totalVotes() {
+		return errors.Wrapf(ErrInvalidConfig, "threshold %d of %d votes",
+			r.Threshold, r.totalVotes())
+	}
+
+	for _, g := range r.Groups {
+		err := g.check(members)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIntersect returns nil if every quorum of r intersects every quorum of
+// other.
+// It is only able to tell for two rules of the same shape: the same votes and
+// the same number of groups. If the two thresholds add up to more than all
+// votes, two quorums share a member or a group, and the quorums of the shared
+// group intersect.
+func (r *QuorumRule) checkIntersect(other *QuorumRule) error {
+	if len(r.Votes) != len(other.Votes) || len(r.Groups) != len(other.Groups) {
+		return errors.Wrapf(ErrInvalidConfig, "can not tell if rules of different shapes intersect")
+	}
+
+	for pos, v := range r.Votes {
+		if other.Votes[pos] != v {
+			return errors.Wrapf(ErrInvalidConfig, "can not tell if rules of different votes intersect")
+		}
+	}
+
+	if r.Threshold+other.Threshold <= r.totalVotes() {
+		return errors.Wrapf(ErrInvalidConfig, "threshold %d and %d of %d votes do not intersect",
+			r.Threshold, other.Threshold, r.totalVotes())
+	}
+
+	for i, g := range r.Groups {
+		err := g.checkIntersect(other.Groups[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listedRule returns a rule with the same quorums as those listed.
+func listedRule(quorums []uint64) *QuorumRule {
+	r := &QuorumRule{Threshold: 1}
+	for _, q := range quorums {
+		g := &QuorumRule{
+			Votes:     map[int64]int64{},
+			Threshold: int64(bits.OnesCount64(q)),
+		}
+		for b := q; b != 0; b &= b - 1 {
+			g.Votes[int64(bits.TrailingZeros64(b))] = 1
+		}
+		r.Groups = append(r.Groups, g)
+	}
+	return r
+}
+
+// majorityRule returns a rule in which a quorum is a majority of members in
+// `members`.
+func majorityRule(members MemberSet) *QuorumRule {
+	r := &QuorumRule{Votes: map[int64]int64{}}
+	for i, w := range members {
+		for b := w; b != 0; b &= b - 1 {
+			r.Votes[int64(i<<6+bits.TrailingZeros64(b))] = 1
+		}
+	}
+	r.Threshold = int64(len(r.Votes)/2 + 1)
+	return r
+}
 
 func buildMajorityQuorums(mask uint64) []uint64 {
+	return buildSizedQuorums(mask, bits.OnesCount64(mask)/2+1)
+}
+
+// buildSizedQuorums returns every set of n positions in mask.
+func buildSizedQuorums(mask uint64, n int) []uint64 {
 	rst := make([]uint64, 0)
-	major := bits.OnesCount64(mask)/2 + 1
-	for i := uint64(0); i <= mask; i++ {
-		if i&mask == i && bits.OnesCount64(i) == major {
+
+	// subsets of mask, in ascending order.
+	for i := uint64(0); ; i = (i - mask) & mask {
+		if bits.OnesCount64(i) == n {
 			rst = append(rst, i)
 		}
+		if i == mask {
+			break
+		}
+	}
+	return rst
+}
+
+// buildWeightedQuorums returns the minimal sets of positions that have more
+// than half of all votes. weights is the votes of every position.
+func buildWeightedQuorums(weights map[int64]int64) []uint64 {
+	total := int64(0)
+	mask := uint64(0)
+	for p, w := range weights {
+		total += w
+		if w > 0 {
+			mask |= 1 << uint(p)
+		}
+	}
+
+	rst := make([]uint64, 0)
+	for i := uint64(0); ; i = (i - mask) & mask {
+		votes, least := int64(0), total
+		for b := i; b != 0; b &= b - 1 {
+			w := weights[int64(bits.TrailingZeros64(b))]
+			votes += w
+			if least > w {
+				least = w
+			}
+		}
+
+		// It is minimal if it is no longer a quorum without any of its
+		// members.
+		if votes*2 > total && (votes-least)*2 <= total {
+			rst = append(rst, i)
+		}
+
+		if i == mask {
+			break
+		}
+	}
+	return rst
+}
+
+// buildGridQuorums returns quorums each of which is a full row and a full
+// column of a grid of positions.
+// Any two of them intersect, where the row of one crosses the column of the
+// other.
+func buildGridQuorums(grid [][]int64) []uint64 {
+	rst := make([]uint64, 0)
+	for _, row := range grid {
+		rowMask := uint64(0)
+		for _, p := range row {
+			rowMask |= 1 << uint(p)
+		}
+
+		for c := range row {
+			q := rowMask
+			for _, r := range grid {
+				q |= 1 << uint(r[c])
+			}
+			rst = append(rst, q)
+		}
+	}
+	return rst
+}
+
+// buildHierarchicalQuorums returns quorums each of which is a majority in each
+// of a majority of groups, e.g., a majority of replicas in each of a majority
+// of datacenters.
+func buildHierarchicalQuorums(groups [][]int64) []uint64 {
+	subs := make([][]uint64, len(groups))
+	for i, g := range groups {
+		mask := uint64(0)
+		for _, p := range g {
+			mask |= 1 << uint(p)
+		}
+		subs[i] = buildMajorityQuorums(mask)
+	}
+
+	rst := make([]uint64, 0)
+	for _, gs := range buildMajorityQuorums(1<<uint(len(groups)) - 1) {
+		qs := []uint64{0}
+		for b := gs; b != 0; b &= b - 1 {
+			var next []uint64
+			for _, q := range qs {
+				for _, s := range subs[bits.TrailingZeros64(b)] {
+					next = append(next, q|s)
+				}
+			}
+			qs = next
+		}
+		rst = append(rst, qs...)
 	}
 	return rst
 }
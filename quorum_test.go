@@ -5,6 +5,7 @@ import (
 	"math/bits"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -58,6 +59,233 @@ func TestBuildMajorityQuorums(t *testing.T) {
 	}
 }
 
+func TestBuildWeightedQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		input map[int64]int64
+		want  []string
+	}{
+		{
+			input: map[int64]int64{0: 1, 1: 1, 2: 1},
+			want: []string{
+				"11000000",
+				"10100000",
+				"01100000",
+			},
+		},
+		{
+			// 0 alone has half of votes, not more.
+			input: map[int64]int64{0: 2, 1: 1, 2: 1},
+			want: []string{
+				"11000000",
+				"10100000",
+			},
+		},
+		{
+			input: map[int64]int64{0: 3, 1: 1, 2: 1},
+			want: []string{
+				"10000000",
+			},
+		},
+		{
+			// no vote
+			input: map[int64]int64{0: 1, 1: 1, 3: 0, 4: 1},
+			want: []string{
+				"11000000",
+				"10001000",
+				"01001000",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		got := buildWeightedQuorums(c.input)
+		ta.Equal(c.want, fmtBitmap(got), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestBuildGridQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		input [][]int64
+		want  []string
+	}{
+		{
+			input: [][]int64{{0, 1}, {2, 3}},
+			want: []string{
+				"11100000",
+				"11010000",
+				"10110000",
+				"01110000",
+			},
+		},
+		{
+			input: [][]int64{{0, 1, 2}},
+			want: []string{
+				"11100000",
+				"11100000",
+				"11100000",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		got := buildGridQuorums(c.input)
+		ta.Equal(c.want, fmtBitmap(got), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestBuildHierarchicalQuorums(t *testing.T) {
+
+	ta := require.New(t)
+
+	got := buildHierarchicalQuorums([][]int64{{0, 1, 2}, {3, 4}, {5}})
+	ta.Equal([]string{
+		// group 0 and 1
+		"11011000",
+		"10111000",
+		"01111000",
+		// group 0 and 2
+		"11000100",
+		"10100100",
+		"01100100",
+		// group 1 and 2
+		"00011100",
+	}, fmtBitmap(got))
+
+	// 3 datacenters with 3 replicas in each.
+	groups := [][]int64{{0, 1, 2}, {3, 4, 5}, {6, 7, 8}}
+	qs := buildHierarchicalQuorums(groups)
+	ta.Equal(3*3*3, len(qs))
+
+	for _, q := range qs {
+		ta.Equal(4, bits.OnesCount64(q))
+	}
+
+	for _, g := range groups {
+		lost := uint64(0)
+		for _, p := range g {
+			lost |= 1 << uint(p)
+		}
+		cc := &ClusterConfig{Quorums: qs}
+		ta.True(cc.IsQuorum((1<<9-1)&^lost), "survive the loss of %v", g)
+	}
+}
+
+func TestMemberSet(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := NewMemberSet(1, 63, 64, 130)
+	ta.Equal(MemberSet{1<<1 | 1<<63, 1, 1 << 2}, s)
+
+	for _, p := range []int64{1, 63, 64, 130} {
+		ta.True(s.Has(p), "position: %d", p)
+	}
+	for _, p := range []int64{0, 62, 65, 129, 131, 1000} {
+		ta.False(s.Has(p), "position: %d", p)
+	}
+
+	ta.Equal(uint64(1<<1|1<<63), s.low())
+	ta.Equal(MemberSet{1 << 63, 1, 1 << 2}, s.without(2|4))
+	ta.Equal(MemberSet{1<<1 | 1<<63, 1, 1 << 2}, s, "s is not changed")
+
+	ta.Equal(uint64(0), MemberSet{}.low())
+	ta.Equal(MemberSet{}, MemberSet{}.without(1))
+}
+
+func TestQuorumRule_isQuorum(t *testing.T) {
+
+	ta := require.New(t)
+
+	// majority of 100
+	all := MemberSet{}
+	for i := int64(0); i < 100; i++ {
+		all.Add(i)
+	}
+	r := majorityRule(all)
+	ta.Equal(int64(51), r.Threshold)
+
+	s := MemberSet{}
+	for i := int64(99); i >= 50; i-- {
+		s.Add(i)
+	}
+	ta.False(r.isQuorum(s))
+	s.Add(0)
+	ta.True(r.isQuorum(s))
+
+	// listed quorums are the same as the rule built from them.
+	listed := []uint64{3, 6, 12, 9}
+	lr := listedRule(listed)
+	for i := uint64(0); i < 16; i++ {
+		ta.Equal(isListedQuorum(listed, MemberSet{i}), lr.isQuorum(MemberSet{i}), "set: %b", i)
+	}
+
+	// 2 of 3 groups, a group is a quorum with votes of 0 and 1, or 2.
+	g := &QuorumRule{Votes: map[int64]int64{0: 1, 1: 1, 2: 2}, Threshold: 2}
+	r = &QuorumRule{
+		Groups:    []*QuorumRule{g, majorityRule(NewMemberSet(3, 4, 5)), majorityRule(NewMemberSet(70))},
+		Threshold: 2,
+	}
+
+	cases := []struct {
+		input MemberSet
+		want  bool
+	}{
+		{NewMemberSet(0, 1), false},
+		{NewMemberSet(0, 1, 3, 4), true},
+		{NewMemberSet(2, 3, 4), true},
+		{NewMemberSet(2, 70), true},
+		{NewMemberSet(0, 3, 70), false},
+		{NewMemberSet(3, 4, 70), true},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, r.isQuorum(c.input), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestQuorumRule_checkIntersect(t *testing.T) {
+
+	ta := require.New(t)
+
+	votes := map[int64]int64{0: 1, 1: 1, 2: 1, 3: 1, 4: 1}
+	counting := func(threshold int64) *QuorumRule {
+		return &QuorumRule{Votes: votes, Threshold: threshold}
+	}
+	groups := func(threshold int64, gs ...*QuorumRule) *QuorumRule {
+		return &QuorumRule{Groups: gs, Threshold: threshold}
+	}
+
+	cases := []struct {
+		a, b    *QuorumRule
+		wantErr error
+	}{
+		{counting(3), counting(3), nil},
+		{counting(2), counting(4), nil},
+		{counting(1), counting(5), nil},
+		{counting(2), counting(3), ErrInvalidConfig},
+		{counting(3), &QuorumRule{Votes: map[int64]int64{0: 1, 1: 1, 2: 1}, Threshold: 3}, ErrInvalidConfig},
+		{counting(3), &QuorumRule{Votes: map[int64]int64{0: 1, 1: 1, 2: 1, 3: 1, 4: 2}, Threshold: 4}, ErrInvalidConfig},
+		{groups(2, counting(3), counting(3)), groups(2, counting(3), counting(3)), nil},
+		{groups(2, counting(2), counting(3)), groups(1, counting(4), counting(3)), nil},
+		// 1 + 1 is not more than 2 groups
+		{groups(1, counting(3), counting(3)), groups(1, counting(3), counting(3)), ErrInvalidConfig},
+		// group 0 is shared but does not intersect
+		{groups(2, counting(2), counting(3)), groups(2, counting(3), counting(3)), ErrInvalidConfig},
+		{groups(2, counting(3), counting(3)), groups(2, counting(3)), ErrInvalidConfig},
+	}
+
+	for i, c := range cases {
+		err := c.a.checkIntersect(c.b)
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+	}
+}
+
 func fmtBitmap(vs []uint64) []string {
 	rst := make([]string, 0)
 	for _, v := range vs {
@@ -0,0 +1,357 @@
+package traft
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// replicateRetryMin and replicateRetryMax bound the backoff between two
+// failed attempts to forward logs to a follower.
+var (
+	replicateRetryMin = time.Millisecond * 10
+	replicateRetryMax = time.Second
+)
+
+// maxForwardLogs is the max number of logs in one LogForwardReq.
+// Logs proposed concurrently are forwarded in one batch.
+var maxForwardLogs = 256
+
+// maxInflight is the max number of LogForwardReq sent to a follower without
+// being replied.
+var maxInflight = 4
+
+// replicator forwards logs of a leader to one follower.
+type replicator struct {
+	committer *LeaderId
+	to        ReplicaInfo
+
+	// notified when there are new logs to forward.
+	notifyCh chan struct{}
+
+	// What follows is only accessed in Loop().
+
+	// lsns being forwarded, they are not sent again until replied.
+	sending map[int64]bool
+
+	// number of LogForwardReq not yet replied.
+	inflight int
+
+	// how long to wait after the last failed forwarding.
+	backoff time.Duration
+}
+
+// startReplicators starts a ReplicateLoop for every follower.
+// Replicators of a former leadership quit by themselves once they find the
+// leadership is lost.
+// It must be called from Loop().
+func (tr *TRaft) startReplicators(committer *LeaderId) {
+
+	tr.replicators = map[int64]*replicator{}
+
+	for _, m := range tr.Config.Members {
+		if m.Id == tr.Id {
+			continue
+		}
+		tr.startReplicator(committer, m)
+	}
+}
+
+// resumeReplicators starts replicators if this replica is a leader that is
+// restarted: it goes on forwarding logs within its lease.
+// It must be called from Loop(), or before Loop() starts.
+func (tr *TRaft) resumeReplicators() {
+	me := tr.Status[tr.Id]
+	if me.VotedFor.Id == tr.Id && me.VotedFor.Equal(me.Committer) {
+		tr.startReplicators(me.VotedFor)
+	}
+}
+
+func (tr *TRaft) startReplicator(committer *LeaderId, m *ReplicaInfo) {
+	rp := &replicator{
+		committer: committer.Clone(),
+		to:        *m,
+		notifyCh:  make(chan struct{}, 1),
+		sending:   map[int64]bool{},
+	}
+	tr.replicators[m.Id] = rp
+
+	tr.runReplicator(rp)
+}
+
+// updateReplicators starts replicators for members added to the config, if
+// this replica is the leader.
+// Replicators of removed members quit by themselves.
+// It must be called from Loop().
+func (tr *TRaft) updateReplicators() {
+
+	me := tr.Status[tr.Id]
+	if tr.replicators == nil ||
+		me.VotedFor.Id != tr.Id ||
+		!me.VotedFor.Equal(me.Committer) {
+		return
+	}
+
+	for id := range tr.replicators {
+		if tr.Config.Members[id] == nil {
+			delete(tr.replicators, id)
+		}
+	}
+
+	for _, m := range tr.Config.Members {
+		if m.Id == tr.Id {
+			continue
+		}
+		if _, ok := tr.replicators[m.Id]; !ok {
+			tr.startReplicator(me.VotedFor, m)
+		}
+	}
+}
+
+// notifyReplicators wakes up every replicator when there are new logs.
+// It must be called from Loop().
+func (tr *TRaft) notifyReplicators() {
+	for _, rp := range tr.replicators {
+		select {
+		case rp.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// the result of forwarding a batch of logs to a follower.
+type forwardRst struct {
+	req   *LogForwardReq
+	reply *LogForwardReply
+	err   error
+}
+
+// ReplicateLoop keeps forwarding logs a follower does not have, until this
+// replica is no longer the leader `rp.committer`.
+// Up to maxInflight batches are sent without waiting for the former ones to be
+// replied.
+// A failed forwarding is retried with an exponential backoff.
+func (tr *TRaft) ReplicateLoop(rp *replicator) {
+
+	// buffered so that a sender never blocks after this loop quits.
+	doneCh := make(chan *forwardRst, maxInflight)
+
+	for {
+		var req *LogForwardReq
+		rst := tr.queryOrStop("func", func() error {
+			var err error
+			req, err = tr.nextForward(rp)
+			return err
+		})
+		if rst == nil || rst.err != nil {
+			lg.Infow("replicate:quit", "to", rp.to.Id, "cmtr", rp.committer.ShortStr())
+			return
+		}
+
+		if req != nil {
+			go func() {
+				res := &forwardRst{req: req}
+				res.reply, res.err = tr.transport.SendLogForward(&rp.to, req)
+				doneCh <- res
+			}()
+			continue
+		}
+
+		// Wait for a reply, or new logs, or check the leadership once in a
+		// while.
+		var res *forwardRst
+		select {
+		case <-tr.shutdown:
+			return
+		case <-rp.notifyCh:
+			continue
+		case <-tr.clock.After(heartbeatInterval):
+			continue
+		case res = <-doneCh:
+		}
+
+		var backoff time.Duration
+		rst = tr.queryOrStop("func", func() error {
+			var err error
+			backoff, err = tr.hdlForwardRst(rp, res)
+			return err
+		})
+		if rst == nil || rst.err != nil {
+			return
+		}
+
+		if backoff == 0 {
+			go tr.maybeSendSnapshot(rp.committer, &rp.to, res.reply.Accepted)
+			continue
+		}
+
+		tr.sleep(backoff)
+	}
+}
+
+// nextForward returns the next batch of logs rp forwards, or nil if there is
+// none or too many batches are not yet replied.
+// It returns ErrLeaderLost if this replica is no longer the leader
+// `rp.committer`.
+// It must be called from Loop().
+func (tr *TRaft) nextForward(rp *replicator) (*LogForwardReq, error) {
+
+	if rp.inflight >= maxInflight {
+		return nil, nil
+	}
+
+	req, err := tr.logsToForward(rp.committer, rp.to.Id, rp.sending)
+	if err != nil || req == nil {
+		return nil, err
+	}
+
+	for _, r := range req.Logs {
+		rp.sending[r.Seq] = true
+	}
+	rp.inflight++
+
+	lg.Infow("replicate:forward",
+		"to", rp.to.Id,
+		"cmtr", rp.committer.ShortStr(),
+		"inflight", rp.inflight,
+		"logs", RecordsShortStr(req.Logs, ""))
+
+	return req, nil
+}
+
+// hdlForwardRst handles the result of forwarding a batch returned by
+// nextForward().
+// It returns how long to wait before forwarding again: 0 if the follower
+// accepted it, or a backoff growing exponentially with every failure.
+// It returns ErrLeaderLost if this replica is no longer the leader
+// `rp.committer`.
+// It must be called from Loop().
+func (tr *TRaft) hdlForwardRst(rp *replicator, res *forwardRst) (time.Duration, error) {
+
+	rp.inflight--
+	for _, r := range res.req.Logs {
+		delete(rp.sending, r.Seq)
+	}
+
+	if res.err == nil && res.reply.OK {
+		rp.backoff = 0
+		return 0, tr.hdlForwardReply(rp.committer, rp.to.Id, res.reply)
+	}
+
+	if rp.backoff == 0 {
+		rp.backoff = replicateRetryMin
+	} else {
+		rp.backoff *= 2
+		if rp.backoff > replicateRetryMax {
+			rp.backoff = replicateRetryMax
+		}
+	}
+
+	lg.Infow("replicate:retry",
+		"to", rp.to.Id,
+		"err", res.err,
+		"reply", res.reply,
+		"backoff", rp.backoff)
+
+	return rp.backoff, nil
+}
+
+// logsToForward builds a LogForwardReq with at most maxForwardLogs logs a
+// follower lacks, except those in `sending`.
+// It returns nil if there is no log to forward.
+// Logs reclaimed by a snapshot are not included.
+// Only a leader that has won the election forwards logs: a follower adopts the
+// committer of forwarded logs.
+// It must be called from Loop().
+func (tr *TRaft) logsToForward(committer *LeaderId, fid int64, sending map[int64]bool) (*LogForwardReq, error) {
+
+	me := tr.Status[tr.Id]
+	if !committer.Equal(me.VotedFor) || !committer.Equal(me.Committer) {
+		return nil, errors.Wrapf(ErrLeaderLost,
+			"committer: %s, current %s",
+			committer.ShortStr(), me.VotedFor.ShortStr(),
+		)
+	}
+
+	if tr.Config.Members[fid] == nil {
+		return nil, errors.Wrapf(ErrNotMember, "follower: %d", fid)
+	}
+
+	st := tr.Status[fid]
+
+	// logs accepted from another committer may be different from mine.
+	// Only the committed ones are the same.
+	known := st.Committed
+	if committer.Equal(st.Committer) {
+		known = st.Accepted
+	}
+
+	missing := me.Accepted.Clone()
+	missing.Diff(known)
+
+	logs := make([]*Record, 0)
+
+	end := missing.Len()
+	for lsn := known.Offset; lsn < end && len(logs) < maxForwardLogs; lsn++ {
+		if missing.Get(lsn) == 0 || sending[lsn] {
+			continue
+		}
+
+		r := tr.logs.Get(lsn)
+		if r.Empty() {
+			// reclaimed or overridden by a later log.
+			continue
+		}
+		logs = append(logs, r)
+	}
+
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	return &LogForwardReq{
+		Committer: committer.Clone(),
+		Logs:      logs,
+		Committed: me.Committed.Clone(),
+	}, nil
+}
+
+// hdlForwardReply updates the leader's view of a follower with the reply of a
+// log forwarding or a heartbeat, then commits logs accepted by a quorum.
+// It must be called from Loop().
+func (tr *TRaft) hdlForwardReply(committer *LeaderId, fid int64, reply *LogForwardReply) error {
+
+	me := tr.Status[tr.Id]
+	if !committer.Equal(me.VotedFor) {
+		return errors.Wrapf(ErrLeaderLost,
+			"committer: %s, current %s",
+			committer.ShortStr(), me.VotedFor.ShortStr(),
+		)
+	}
+
+	st := tr.Status[fid]
+
+	// Accepted is only counted with the committer the follower accepted its
+	// logs from: logs from another committer may differ from the leader's.
+	cr := reply.Committer.Cmp(st.Committer)
+	if cr > 0 {
+		st.Committer = reply.Committer.Clone()
+		st.Accepted = reply.Accepted.Clone()
+	} else if cr == 0 {
+		// Accepted never shrinks with the same committer.
+		// A delayed reply does not bring it back.
+		st.Accepted.Union(reply.Accepted)
+	}
+	// A reply with an older committer is delayed. The follower has accepted
+	// logs from a newer one since.
+
+	// committed logs are the same, whoever committed them.
+	st.Committed.Union(reply.Committed)
+
+	tr.leaderCommitAccepted(committer)
+
+	// new members may have just got the committed logs the final config
+	// waits for.
+	tr.finishJointConfig()
+	return nil
+}
@@ -0,0 +1,309 @@
+package traft
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_logsToForward(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(n int) {
+		maxForwardLogs = n
+	}(maxForwardLogs)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		committer *LeaderId
+		accepted  *TailBitmap
+		committed *TailBitmap
+		sending   map[int64]bool
+		want      []int64
+	}{
+		{nil, bm(0), bm(0), nil, []int64{0, 1, 2, 4}},
+		{lid(2, 1), bm(0, 0, 2), bm(0), nil, []int64{1, 4}},
+		{lid(2, 1), bm(0, 0, 1, 2, 4), bm(0), nil, nil},
+		{lid(2, 1), bm(5), bm(0), nil, nil},
+		// logs from another committer are not trusted
+		{lid(1, 2), bm(5), bm(0, 0), nil, []int64{1, 2, 4}},
+		{lid(1, 2), bm(5), bm(3), nil, []int64{4}},
+		// logs being sent
+		{lid(2, 1), bm(0, 0, 2), bm(0), map[int64]bool{1: true}, []int64{4}},
+		{lid(2, 1), bm(0, 0, 2), bm(0), map[int64]bool{1: true, 4: true}, nil},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502"})
+		// log 3 is absent
+		tr.initTraft(lid(2, 1), lid(2, 1), []int64{0, 1, 2, 3, 4}, map[int64]bool{3: true}, nil, lid(2, 1))
+
+		st := tr.Status[2]
+		st.Committer = c.committer
+		st.Accepted = c.accepted
+		st.Committed = c.committed
+
+		req, err := tr.logsToForward(lid(2, 1), 2, c.sending)
+		ta.Nil(err)
+
+		if c.want == nil {
+			ta.Nil(req, "%d-th: case: %+v", i+1, c)
+		} else {
+			lsns := []int64{}
+			for _, r := range req.Logs {
+				lsns = append(lsns, r.Seq)
+			}
+			ta.Equal(c.want, lsns, "%d-th: case: %+v", i+1, c)
+			ta.Equal(lid(2, 1), req.Committer)
+		}
+
+		tr.Stop()
+	}
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502"})
+	defer tr.Stop()
+
+	// a batch has at most maxForwardLogs logs
+	maxForwardLogs = 3
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{0, 1, 2, 3, 4}, nil, nil, lid(2, 1))
+	req, err := tr.logsToForward(lid(2, 1), 2, nil)
+	ta.Nil(err)
+	ta.Equal("[<002#001:000{set(x, 0)}-0→0><002#001:001{set(x, 1)}-0→0><002#001:002{set(x, 2)}-0→0>]",
+		RecordsShortStr(req.Logs, ""))
+
+	// not a leader any more
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{0, 1}, nil, nil, lid(3, 2))
+	_, err = tr.logsToForward(lid(2, 1), 2, nil)
+	ta.Equal(ErrLeaderLost, errors.Cause(err))
+
+	// voted for itself but not yet won
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{0, 1}, nil, nil, lid(3, 1))
+	_, err = tr.logsToForward(lid(3, 1), 2, nil)
+	ta.Equal(ErrLeaderLost, errors.Cause(err))
+}
+
+func TestTRaft_hdlForwardReply(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		stCommitter *LeaderId
+		stAccepted  *TailBitmap
+		reply       *LogForwardReply
+
+		wantCommitter *LeaderId
+		wantAccepted  *TailBitmap
+		wantCommitted *TailBitmap
+	}{
+		{
+			lid(1, 2), bm(0),
+			&LogForwardReply{Committer: lid(2, 1), Accepted: bm(2), Committed: bm(0)},
+			lid(2, 1), bm(2), bm(2),
+		},
+		{
+			// logs from another committer are not counted.
+			lid(1, 2), bm(0),
+			&LogForwardReply{Committer: lid(1, 2), Accepted: bm(2), Committed: bm(0)},
+			lid(1, 2), bm(2), bm(0),
+		},
+		{
+			// a delayed reply from before accepting logs from the leader.
+			lid(2, 1), bm(2),
+			&LogForwardReply{Committer: lid(1, 2), Accepted: bm(0, 0, 1), Committed: bm(0)},
+			lid(2, 1), bm(2), bm(2),
+		},
+		{
+			lid(2, 1), bm(0, 0),
+			&LogForwardReply{Committer: lid(2, 1), Accepted: bm(0, 1), Committed: bm(0)},
+			lid(2, 1), bm(2), bm(2),
+		},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+		tr.initTraft(lid(2, 1), lid(2, 1), []int64{0, 1}, nil, nil, lid(2, 1))
+
+		st := tr.Status[2]
+		st.Committer = c.stCommitter
+		st.Accepted = c.stAccepted
+
+		err := tr.hdlForwardReply(lid(2, 1), 2, c.reply)
+		ta.Nil(err, "%d-th: case: %+v", i+1, c)
+
+		ta.Equal(c.wantCommitter, st.Committer, "%d-th: case: %+v", i+1, c)
+		ta.True(st.Accepted.Includes(c.wantAccepted) && c.wantAccepted.Includes(st.Accepted),
+			"%d-th: case: %+v, got: %s", i+1, c, st.Accepted.DebugStr())
+
+		me := tr.Status[1]
+		ta.True(me.Committed.Includes(c.wantCommitted) && c.wantCommitted.Includes(me.Committed),
+			"%d-th: case: %+v, got: %s", i+1, c, me.Committed.DebugStr())
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_ReplicateLoop(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	defer func(d time.Duration) {
+		replicateRetryMin = d
+	}(replicateRetryMin)
+	replicateRetryMin = time.Millisecond
+
+	ts := serveCluster([]int64{1, 2, 3})
+	defer stopAll(ts)
+
+	leader := lid(2, 1)
+
+	var logs []*Record
+	query(ts[0].actionCh, "func", func() error {
+		me := ts[0].Status[1]
+		me.VotedFor = leader.Clone()
+		me.Committer = leader.Clone()
+		ts[0].addlogs("x=0", "y=1", "x=2", "z=3")
+		logs = ts[0].allLogs()
+		return nil
+	})
+
+	vote := func(tr *TRaft, v *LeaderId) {
+		query(tr.actionCh, "func", func() error {
+			me := tr.Status[tr.Id]
+			me.VotedFor = v.Clone()
+			me.VoteExpireAt = uSecondI64() + int64(time.Second*10)
+			return nil
+		})
+	}
+
+	logsOf := func(tr *TRaft) string {
+		var s string
+		query(tr.actionCh, "func", func() error {
+			s = RecordsShortStr(tr.allLogs(), "")
+			return nil
+		})
+		return s
+	}
+
+	// ts[1] has holes in its logs and the leader knows it.
+	vote(ts[1], leader)
+	query(ts[1].actionCh, "func", func() error {
+		ts[1].hdlLogForward(&LogForwardReq{
+			Committer: leader,
+			Logs:      []*Record{logs[1], logs[3]},
+		})
+		return nil
+	})
+	query(ts[0].actionCh, "func", func() error {
+		st := ts[0].Status[2]
+		st.Committer = leader.Clone()
+		st.Accepted = bm(0, 1, 3)
+		return nil
+	})
+
+	// ts[2] refuses logs until it votes for the leader.
+	vote(ts[2], lid(3, 2))
+
+	query(ts[0].actionCh, "func", func() error {
+		ts[0].startReplicators(leader)
+		return nil
+	})
+
+	waitFor(ta, ts[1], func() bool {
+		return ts[1].Status[2].Accepted.Includes(bm(4))
+	})
+	ta.Equal(logsOf(ts[0]), logsOf(ts[1]))
+
+	waitFor(ta, ts[0], func() bool {
+		me := ts[0].Status[1]
+		return me.Committed.Includes(bm(4)) && ts[0].Status[2].Accepted.Includes(bm(4))
+	})
+
+	ta.Equal("[]", logsOf(ts[2]))
+
+	vote(ts[2], leader)
+	waitFor(ta, ts[2], func() bool {
+		return ts[2].Status[3].Accepted.Includes(bm(4))
+	})
+	ta.Equal(logsOf(ts[0]), logsOf(ts[2]))
+
+	// new logs are forwarded at once
+	query(ts[0].actionCh, "func", func() error {
+		ts[0].addlogs("y=4")
+		ts[0].notifyReplicators()
+		return nil
+	})
+
+	for _, tr := range ts[1:] {
+		waitFor(ta, tr, func() bool {
+			return tr.Status[tr.Id].Accepted.Includes(bm(5))
+		})
+		ta.Equal(logsOf(ts[0]), logsOf(tr))
+	}
+}
+
+// BenchmarkReplicate measures throughput of concurrent proposals on a 3 replica
+// cluster with different batch sizes.
+func BenchmarkReplicate(b *testing.B) {
+
+	defer func(n int) {
+		maxForwardLogs = n
+	}(maxForwardLogs)
+
+	leader := NewLeaderId(2, 1)
+
+	for _, batch := range []int{1, 16, 256} {
+		b.Run(fmt.Sprintf("batch=%d", batch), func(b *testing.B) {
+
+			maxForwardLogs = batch
+
+			ts := serveCluster([]int64{1, 2, 3})
+			defer stopAll(ts)
+
+			for _, tr := range ts {
+				tr := tr
+				query(tr.actionCh, "func", func() error {
+					me := tr.Status[tr.Id]
+					me.VotedFor = leader.Clone()
+					me.VoteExpireAt = uSecondI64() + int64(time.Hour)
+					if tr.Id == leader.Id {
+						me.Committer = leader.Clone()
+						tr.startReplicators(leader)
+					}
+					return nil
+				})
+			}
+
+			b.SetParallelism(64)
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					finCh := make(chan *ProposeReply, 1)
+					query(ts[0].actionCh, "propose", &proposeReq{
+						req:   &ProposeReq{Cmd: NewCmdI64("set", "x", 1)},
+						finCh: finCh,
+					})
+					reply := <-finCh
+					if !reply.OK {
+						b.Errorf("propose failed: %s", reply.Err)
+						return
+					}
+				}
+			})
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+		})
+	}
+}
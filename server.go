@@ -3,9 +3,7 @@ package traft
 // TRaftServer impl
 
 import (
-	context "context"
 	fmt "fmt"
-	"math/rand"
 	"time"
 
 	"github.com/openacid/low/mathext/util"
@@ -29,7 +27,9 @@ func (tr *TRaft) initTraft(
 ) {
 	id := tr.Id
 
-	tr.LogOffset, tr.Logs = buildPseudoLogs(author, lsns, nilLogs)
+	offset, logs := buildPseudoLogs(author, lsns, nilLogs)
+	tr.logs = NewMemStorage(offset)
+	tr.appendLogs(logs...)
 
 	tr.Status[id].Committer = committer.Clone()
 	tr.Status[id].Accepted = NewTailBitmap(0, lsns...)
@@ -60,7 +60,7 @@ func buildPseudoLogs(
 	last := lsns[len(lsns)-1]
 	start := lsns[0]
 	for i := start; i <= last; i++ {
-		logs = append(logs, &Record{})
+		logs = append(logs, &Record{Seq: i})
 	}
 
 	for _, lsn := range lsns {
@@ -90,6 +90,66 @@ type leaderAndVotes struct {
 	votes      []*VoteReply
 }
 
+// hdlSetVoted makes this replica vote for a candidate, usually itself, if it
+// has not voted for a greater one.
+// It must be called from Loop().
+func (tr *TRaft) hdlSetVoted(leadst *LeaderStatus) bool {
+	me := tr.Status[tr.Id]
+	if leadst.VotedFor.Cmp(me.VotedFor) < 0 {
+		return false
+	}
+
+	me.VotedFor = leadst.VotedFor.Clone()
+	me.VoteExpireAt = leadst.VoteExpireAt
+	tr.persistHardState()
+	tr.replyLeaderLost()
+	return true
+}
+
+// hdlVoteWin makes this replica the leader after it is granted by a quorum:
+// it merges logs from the voters and becomes the committer of them.
+// It returns false if this replica has voted for another one during the
+// election.
+// It must be called from Loop().
+func (tr *TRaft) hdlVoteWin(lal *leaderAndVotes) bool {
+
+	leadst := lal.leaderStat
+	votes := lal.votes
+
+	me := tr.Status[tr.Id]
+
+	if leadst.VotedFor.Cmp(me.VotedFor) != 0 {
+		return false
+	}
+
+	me.VotedFor = leadst.VotedFor.Clone()
+	me.VoteExpireAt = leadst.VoteExpireAt
+
+	tr.internalMergeLogs(votes)
+	tr.syncLogs()
+	// TODO update Committer to this replica
+	// then going on replicating these logs to others.
+	//
+	// TODO update local view of status of other replicas.
+	for _, v := range votes {
+		if v.Committer.Equal(me.Committer) {
+			tr.Status[v.Id].Accepted = v.Accepted.Clone()
+		} else {
+			// if committers are different, the leader can no be
+			// sure whether a follower has identical logs
+			tr.Status[v.Id].Accepted = v.Committed.Clone()
+		}
+		tr.Status[v.Id].Committed = v.Committed.Clone()
+
+		tr.Status[v.Id].Committer = v.Committer.Clone()
+	}
+	me.Committer = leadst.VotedFor.Clone()
+	tr.persistHardState()
+	tr.replyLeaderLost()
+
+	return true
+}
+
 // find the max committer log to fill in local log holes.
 func (tr *TRaft) internalMergeLogs(votes []*VoteReply) {
 
@@ -105,7 +165,16 @@ func (tr *TRaft) internalMergeLogs(votes []*VoteReply) {
 	id := tr.Id
 	me := tr.Status[id]
 
+	// A voter with a smaller committer may have more logs, e.g., this replica
+	// has received only part of the logs from its committer.
+	// Committed ones among them must not be lost.
 	l := me.Accepted.Len()
+	for _, vr := range votes {
+		if vr.Accepted.Len() > l {
+			l = vr.Accepted.Len()
+		}
+	}
+
 	for i := me.Accepted.Offset; i < l; i++ {
 		if me.Accepted.Get(i) != 0 {
 			continue
@@ -142,7 +211,7 @@ func (tr *TRaft) internalMergeLogs(votes []*VoteReply) {
 			continue
 		}
 
-		tr.Logs[i-tr.LogOffset] = maxRec
+		tr.appendLogs(maxRec)
 		me.Accepted.Set(i)
 		// if isCommitted {
 		//     me.Committed.Set(i)
@@ -155,125 +224,280 @@ func (tr *TRaft) internalMergeLogs(votes []*VoteReply) {
 	}
 }
 
+// followerSleep is how often a follower checks if its leader is expired.
+var followerSleep = time.Millisecond * 200
+
+// maxStaleTermSleep is the max random time to wait before the next election,
+// after seeing a higher term.
+var maxStaleTermSleep = time.Millisecond * 200
+
+// voteTimeout is the max time to wait for vote replies.
+var voteTimeout = time.Second
+
+// election is a pre-vote or a vote VoteLoop() starts.
+type election struct {
+	// the candidate, this replica with a greater term.
+	leadst *LeaderStatus
+	logst  *LogStatus
+	config *ClusterConfig
+
+	preVote bool
+}
+
+// voteStep is what VoteLoop() does next.
+type voteStep struct {
+	// send heartbeat as leader `lead`.
+	lead *LeaderId
+
+	// start a pre-vote or a vote.
+	election *election
+
+	// fetch logs from it, because it refused the election for a greater log
+	// status.
+	fetchFrom *ReplicaInfo
+
+	// how long to wait before the next step, unless the leader asks to elect
+	// at once.
+	wait time.Duration
+}
+
 // run forever to elect itself as leader if there is no leader in this cluster.
+// What to do in every step is decided in Loop() by nextVoteStep() and
+// hdlElectionDone().
 func (tr *TRaft) VoteLoop() {
 
-	act := tr.actionCh
+	// query returns nil once TRaft is stopped and Loop() quits.
+	query := tr.queryOrStop
 
-	id := tr.Id
+	// the leader transferring its leadership asks me to elect at once.
+	electNow := false
 
-	// return true if shutting down
-	slp := tr.sleep
+	for {
+		var step *voteStep
+		rst := query("func", func() error {
+			step = tr.nextVoteStep(electNow)
+			return nil
+		})
+		if rst == nil {
+			return
+		}
+		electNow = false
 
-	maxStaleTermSleep := time.Millisecond * 200
-	heartBeatSleep := time.Millisecond * 200
-	followerSleep := time.Millisecond * 200
+		if step.lead != nil {
+			// I am a leader
+			start := tr.clock.Now()
+			tr.heartbeat(step.lead)
+			tr.sleep(heartbeatInterval - time.Duration(tr.clock.Now()-start))
+			continue
+		}
 
-	for tr.running {
-		leadst := query(act, "leaderStat", nil).v.(*LeaderStatus)
+		// a granted pre-vote is followed by a vote.
+		for step.election != nil {
+			el := step.election
 
-		now := uSecondI64()
+			send := tr.transport.SendVote
+			if el.preVote {
+				send = tr.transport.SendPreVote
+			}
 
-		// TODO refine this: wait until VoteExpireAt and watch for missing
-		// heartbeat.
-		if now < leadst.VoteExpireAt {
+			vt, err := tr.collectVotes(send, el.leadst.VotedFor, el.logst, el.config)
 
-			lg.Infow("leader-not-expired",
-				"Id", tr.Id,
-				"VotedFor", leadst.VotedFor,
-				"leadst.VoteExpireAt-now", leadst.VoteExpireAt-now)
+			rst = query("func", func() error {
+				step = tr.hdlElectionDone(el, vt, err)
+				return nil
+			})
+			if rst == nil {
+				return
+			}
+		}
 
-			if leadst.VotedFor.Id == tr.Id {
-				// I am a leader
-				// TODO heartbeat other replicas to keep leadership
-				slp(heartBeatSleep)
-			} else {
-				slp(followerSleep)
+		if step.fetchFrom != nil {
+			err := tr.fetchLogs(step.fetchFrom)
+			if err != nil {
+				lg.Infow("fetch-logs:fail", "from", step.fetchFrom.Id, "err", err)
 			}
+		}
 
-			continue
+		if step.wait > 0 {
+			electNow = tr.sleepOrElect(step.wait)
 		}
+	}
+}
+
+// nextVoteStep decides what VoteLoop() does next: send heartbeat as a leader,
+// wait for the leader to expire, or start an election.
+// With electNow, the pre-vote is skipped: voters still see the leader that asks
+// this replica to elect.
+// It must be called from Loop().
+func (tr *TRaft) nextVoteStep(electNow bool) *voteStep {
+
+	me := tr.Status[tr.Id]
+	now := tr.clock.Now()
+
+	// TODO refine this: wait until VoteExpireAt and watch for missing
+	// heartbeat.
+	if now < me.VoteExpireAt && !electNow {
 
-		// call for a new leader!!!
-		lg.Infow("leader-expired",
+		lg.Infow("leader-not-expired",
 			"Id", tr.Id,
-			"VotedFor", leadst.VotedFor,
-			"leadst.VoteExpireAt-now", leadst.VoteExpireAt-now)
-
-		logst := query(act, "logStat", nil).v.(*LogStatus)
-		config := query(act, "config", nil).v.(*ClusterConfig)
-
-		// vote myself
-		leadst.VotedFor.Term++
-		leadst.VotedFor.Id = tr.Id
-
-		{
-			// update local vote first
-			ok := query(act, "set_voted", leadst).ok
-			if !ok {
-				// voted for other replica
-				leadst = query(act, "leaderStat", nil).v.(*LeaderStatus)
-				lg.Infow("reload-leader",
-					"Id", id,
-					"leadst.VotedFor", leadst.VotedFor,
-					"leadst.VoteExpireAt", leadst.VoteExpireAt,
-				)
-				continue
-			}
+			"VotedFor", me.VotedFor,
+			"VoteExpireAt-now", me.VoteExpireAt-now)
+
+		if me.VotedFor.Id == tr.Id {
+			return &voteStep{lead: me.VotedFor.Clone()}
 		}
+		return &voteStep{wait: followerSleep}
+	}
 
-		tr.sendMsg("vote-start", leadst.VotedFor.ShortStr(), logst)
+	// call for a new leader!!!
+	lg.Infow("leader-expired",
+		"Id", tr.Id,
+		"VotedFor", me.VotedFor,
+		"VoteExpireAt-now", me.VoteExpireAt-now)
 
-		voted, err, higher := VoteOnce(
-			leadst.VotedFor,
-			logst,
-			config,
-		)
+	if tr.Config.Members[tr.Id] == nil {
+		// removed from the cluster, or not yet added.
+		return &voteStep{wait: followerSleep}
+	}
 
-		lg.Infow("vote-loop:result", "Id", tr.Id, "voted", voted, "err", err, "higher", higher)
+	leadst := ExportLeaderStatus(me)
+	leadst.VotedFor.Term++
+	leadst.VotedFor.Id = tr.Id
 
-		if voted != nil {
-			// granted by a quorum
+	el := &election{
+		leadst:  leadst,
+		logst:   ExportLogStatus(me),
+		config:  tr.Config.Clone(),
+		preVote: true,
+	}
 
-			leadst.VoteExpireAt = uSecondI64() + leaderLease
+	if electNow {
+		return tr.startVote(el)
+	}
 
-			lg.Infow("to-update-leader", "leadst", leadst.VoteExpireAt)
+	// do not bump my term unless a quorum would grant me.
+	return &voteStep{election: el}
+}
 
-			ok := query(act, "update_leaderAndLog", &leaderAndVotes{
-				leadst,
-				voted,
-			}).ok
+// startVote makes this replica vote for itself, the candidate of el, before
+// asking others to.
+// It must be called from Loop().
+func (tr *TRaft) startVote(el *election) *voteStep {
 
-			if ok {
-				tr.sendMsg("vote-win", leadst)
-				slp(heartBeatSleep)
-			} else {
-				tr.sendMsg("vote-fail", "reason:fail-to-update", leadst)
-				lg.Infow("reload-leader",
-					"Id", id,
-					"leadst.VotedFor", leadst.VotedFor,
-					"leadst.VoteExpireAt", leadst.VoteExpireAt,
-				)
+	if !tr.hdlSetVoted(el.leadst) {
+		// voted for other replica
+		lg.Infow("reload-leader",
+			"Id", tr.Id,
+			"leadst.VotedFor", el.leadst.VotedFor,
+			"leadst.VoteExpireAt", el.leadst.VoteExpireAt,
+		)
+		return &voteStep{}
+	}
+
+	el.preVote = false
+	tr.sendMsg("vote-start", el.leadst.VotedFor.ShortStr(), el.logst)
+
+	return &voteStep{election: el}
+}
+
+// hdlElectionDone handles the votes of election el and decides what
+// VoteLoop() does next.
+// err is not nil if voters did not reply in time.
+// It must be called from Loop().
+func (tr *TRaft) hdlElectionDone(el *election, vt *voteTally, err error) *voteStep {
+
+	var voted []*VoteReply
+	higher := vt.higherTerm
+	if err == nil {
+		voted, err, higher = vt.result()
+	}
+
+	lg.Infow("vote-loop:result",
+		"Id", tr.Id,
+		"preVote", el.preVote,
+		"voted", voted,
+		"err", err,
+		"higher", higher)
+
+	if el.preVote {
+		if err != nil {
+			tr.sendMsg("pre-vote-fail", "err", err)
+
+			step := &voteStep{wait: tr.retryVoteAfter(err)}
+			if errors.Cause(err) == ErrStaleLog {
+				// I can not be a leader until I have the logs some others
+				// have. Get them in case they are all gone.
+				step.fetchFrom = vt.betterLog
 			}
-			continue
+			return step
 		}
+		return tr.startVote(el)
+	}
 
+	if voted == nil {
 		tr.sendMsg("vote-fail", "err", err)
+		return &voteStep{wait: tr.retryVoteAfter(err)}
+	}
 
-		// not voted
-
-		switch errors.Cause(err) {
-		case ErrStaleTermId:
-			slp(time.Millisecond*5 + time.Duration(rand.Int63n(int64(maxStaleTermSleep))))
-			// leadst.VotedFor.Term = higher + 1
-		case ErrTimeout:
-			slp(time.Millisecond * 10)
-		case ErrStaleLog:
-			// I can not be the leader.
-			// sleep a day. waiting for others to elect to be a leader.
-			slp(time.Second * 86400)
-		}
+	// granted by a quorum
+
+	leadst := el.leadst
+	leadst.VoteExpireAt = tr.clock.Now() + leaderLease
+
+	if tr.hdlVoteWon(&leaderAndVotes{leadst, voted}) {
+		tr.sendMsg("vote-win", leadst)
+	} else {
+		tr.sendMsg("vote-fail", "reason:fail-to-update", leadst)
+		lg.Infow("reload-leader",
+			"Id", tr.Id,
+			"leadst.VotedFor", leadst.VotedFor,
+			"leadst.VoteExpireAt", leadst.VoteExpireAt,
+		)
 	}
+	return &voteStep{}
+}
+
+// hdlVoteWon makes this replica the leader with hdlVoteWin() and starts
+// forwarding logs.
+// It must be called from Loop().
+func (tr *TRaft) hdlVoteWon(lal *leaderAndVotes) bool {
+	ok := tr.hdlVoteWin(lal)
+	if ok {
+		tr.startReplicators(tr.Status[tr.Id].VotedFor)
+		// a joint config committed by a former leader.
+		tr.finishJointConfig()
+	}
+	return ok
+}
+
+// sleepOrElect is the same as sleep except it returns true at once if the
+// leader asks this replica to start an election.
+func (tr *TRaft) sleepOrElect(t time.Duration) bool {
+	select {
+	case <-tr.clock.After(t):
+	case <-tr.shutdown:
+	case <-tr.electCh:
+		return true
+	}
+	return false
+}
+
+// retryVoteAfter returns how long to wait before the next election, after an
+// election failed with err.
+func (tr *TRaft) retryVoteAfter(err error) time.Duration {
+	switch errors.Cause(err) {
+	case ErrStaleTermId:
+		return time.Millisecond*5 + time.Duration(tr.rnd.Int63n(int64(maxStaleTermSleep)))
+	case ErrTimeout:
+		return time.Millisecond * 10
+	case ErrLeaderAlive:
+		return followerSleep
+	case ErrStaleLog:
+		// I can not be the leader until I get logs from others.
+		// Keep watching in case they are gone too.
+		return followerSleep
+	}
+	return 0
 }
 
 // returns:
@@ -281,24 +505,61 @@ func (tr *TRaft) VoteLoop() {
 //				Otherwise returns nil.
 // error: ErrStaleLog, ErrStaleTermId, ErrTimeout.
 // higherTerm: if seen, upgrade term and retry
-func VoteOnce(
+func (tr *TRaft) VoteOnce(
 	candidate *LeaderId,
 	logStatus logStat,
 	config *ClusterConfig,
 ) ([]*VoteReply, error, int64) {
+	vt, err := tr.collectVotes(tr.transport.SendVote, candidate, logStatus, config)
+	if err != nil {
+		return nil, err, vt.higherTerm
+	}
+	return vt.result()
+}
+
+// PreVoteOnce asks voters whether they would grant candidate, before the
+// candidate votes for itself.
+// A replica that can not win, e.g., one partitioned away, does not increase
+// its term thus does not disrupt the leader when it comes back.
+//
+// It returns nil error if a quorum would grant candidate.
+// Otherwise it returns ErrStaleLog, ErrLeaderAlive, ErrStaleTermId or
+// ErrTimeout.
+// With ErrStaleLog it also returns the voter that has the greatest log
+// status.
+func (tr *TRaft) PreVoteOnce(
+	candidate *LeaderId,
+	logStatus logStat,
+	config *ClusterConfig,
+) (*ReplicaInfo, error) {
+	vt, err := tr.collectVotes(tr.transport.SendPreVote, candidate, logStatus, config)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err, _ = vt.result()
+	if errors.Cause(err) == ErrStaleLog {
+		return vt.betterLog, err
+	}
+	return nil, err
+}
+
+// collectVotes sends a vote request with `send` to every other member and
+// waits until the candidate is granted by a quorum or every voter replied.
+// It returns ErrTimeout if voters do not reply in time.
+func (tr *TRaft) collectVotes(
+	send func(*ReplicaInfo, *VoteReq) (*VoteReply, error),
+	candidate *LeaderId,
+	logStatus logStat,
+	config *ClusterConfig,
+) (*voteTally, error) {
 
 	// TODO vote need cluster id:
 	// a stale member may try to elect on another cluster.
 
 	id := candidate.Id
 
-	replies := make([]*VoteReply, 0)
-
-	req := &VoteReq{
-		Candidate: candidate,
-		Committer: logStatus.GetCommitter(),
-		Accepted:  logStatus.GetAccepted(),
-	}
+	req := newVoteReq(candidate, logStatus)
 
 	type voteRst struct {
 		from  *ReplicaInfo
@@ -306,7 +567,8 @@ func VoteOnce(
 		err   error
 	}
 
-	ch := make(chan *voteRst)
+	// buffered so that a voter replies late does not block.
+	ch := make(chan *voteRst, len(config.Members))
 
 	for _, rinfo := range config.Members {
 		if rinfo.Id == id {
@@ -314,77 +576,150 @@ func VoteOnce(
 		}
 
 		go func(rinfo ReplicaInfo, ch chan *voteRst) {
-			rpcTo(rinfo.Addr, func(cli TRaftClient, ctx context.Context) {
-
-				// lg.Infow("grpc-send-req", "addr", rinfo.Addr)
-				reply, err := cli.Vote(ctx, req)
-				// lg.Infow("grpc-recv-reply", "from", rinfo, "reply", reply, "err", err)
-
-				ch <- &voteRst{&rinfo, reply, err}
-			})
+			reply, err := send(&rinfo, req)
+			ch <- &voteRst{&rinfo, reply, err}
 		}(*rinfo, ch)
 	}
 
-	received := uint64(0)
-	// I vote myself
-	received |= 1 << uint(config.Members[id].Position)
-	higherTerm := int64(-1)
-	var logErr error
-	waitingFor := len(config.Members) - 1
+	vt := newVoteTally(candidate, logStatus, config)
+	timeout := tr.clock.After(voteTimeout)
 
-	for waitingFor > 0 {
+	for !vt.done() {
 		select {
 		case res := <-ch:
 
 			lg.Infow("vote-once:got-reply", "reply", res.reply, "err", res.err)
 
 			if res.err != nil {
-				waitingFor--
-				continue
+				vt.add(res.from, nil)
+			} else {
+				vt.add(res.from, res.reply)
 			}
 
-			repl := res.reply
+		case <-timeout:
+			// timeout
+			return vt, errors.Wrapf(ErrTimeout, "voting")
+		}
+	}
+
+	return vt, nil
+}
 
-			if repl.VotedFor.Equal(candidate) {
-				// vote granted
-				replies = append(replies, repl)
+// newVoteReq returns the request for a candidate with log status logStatus.
+func newVoteReq(candidate *LeaderId, logStatus logStat) *VoteReq {
+	return &VoteReq{
+		Candidate: candidate,
+		Committer: logStatus.GetCommitter(),
+		Accepted:  logStatus.GetAccepted(),
+	}
+}
 
-				received |= 1 << uint(res.from.Position)
-				if config.IsQuorum(received) {
-					// TODO cancel timer
-					return replies, nil, -1
-				}
-			} else {
-				if repl.VotedFor.Cmp(candidate) > 0 {
-					higherTerm = util.MaxI64(higherTerm, repl.VotedFor.Term)
-				}
+// voteTally collects vote replies to a candidate.
+type voteTally struct {
+	candidate *LeaderId
+	logStatus logStat
+	config    *ClusterConfig
 
-				if CmpLogStatus(repl, logStatus) > 0 {
-					// TODO cancel timer
-					logErr = errors.Wrapf(ErrStaleLog,
-						"local: committer:%s max-lsn:%d remote: committer:%s max-lsn:%d",
-						logStatus.GetCommitter().ShortStr(),
-						logStatus.GetAccepted().Len(),
-						repl.Committer.ShortStr(),
-						repl.Accepted.Len())
-				}
-			}
+	// replies of voters granted the candidate.
+	replies []*VoteReply
 
-			waitingFor--
+	// bitmap of positions of voters granted the candidate.
+	received MemberSet
 
-		case <-time.After(time.Second):
-			// timeout
-			// TODO cancel timer
-			return nil, errors.Wrapf(ErrTimeout, "voting"), higherTerm
+	higherTerm int64
+	logErr     error
+
+	// the voter with the greatest log status that is greater than the
+	// candidate's.
+	betterLog     *ReplicaInfo
+	betterLogStat logStat
+
+	// a voter refused a pre-vote because its leader is alive.
+	leaderAlive bool
+
+	// number of voters not yet replied.
+	waiting int
+}
+
+func newVoteTally(candidate *LeaderId, logStatus logStat, config *ClusterConfig) *voteTally {
+	return &voteTally{
+		candidate: candidate,
+		logStatus: logStatus,
+		config:    config,
+		replies:   make([]*VoteReply, 0),
+		// I vote myself
+		received:   NewMemberSet(config.Members[candidate.Id].Position),
+		higherTerm: -1,
+		waiting:    len(config.Members) - 1,
+	}
+}
+
+// add counts a reply from a voter. A nil reply means the voter is
+// unreachable.
+func (vt *voteTally) add(from *ReplicaInfo, repl *VoteReply) {
+	vt.waiting--
+
+	if repl == nil {
+		return
+	}
+
+	if repl.VotedFor.Equal(vt.candidate) {
+		// vote granted
+		vt.replies = append(vt.replies, repl)
+		vt.received.Add(from.Position)
+		return
+	}
+
+	if repl.VotedFor.Cmp(vt.candidate) > 0 {
+		vt.higherTerm = util.MaxI64(vt.higherTerm, repl.VotedFor.Term)
+	}
+
+	if CmpLogStatus(repl, vt.logStatus) > 0 {
+		if vt.betterLog == nil || CmpLogStatus(repl, vt.betterLogStat) > 0 {
+			vt.betterLog = from
+			vt.betterLogStat = repl
 		}
+
+		vt.logErr = errors.Wrapf(ErrStaleLog,
+			"local: committer:%s max-lsn:%d remote: committer:%s max-lsn:%d",
+			vt.logStatus.GetCommitter().ShortStr(),
+			vt.logStatus.GetAccepted().Len(),
+			repl.Committer.ShortStr(),
+			repl.Accepted.Len())
+		return
 	}
 
-	if logErr != nil {
-		return nil, logErr, higherTerm
+	if repl.VotedFor.Cmp(vt.candidate) < 0 {
+		vt.leaderAlive = true
 	}
+}
 
-	err := errors.Wrapf(ErrStaleTermId, "seen a higher term:%d", higherTerm)
-	return nil, err, higherTerm
+func (vt *voteTally) granted() bool {
+	return vt.config.IsVoteQuorumSet(vt.received)
+}
+
+// done returns true if the candidate is granted by a quorum or every voter
+// has replied.
+func (vt *voteTally) done() bool {
+	return vt.granted() || vt.waiting <= 0
+}
+
+// result returns the same as VoteOnce.
+func (vt *voteTally) result() ([]*VoteReply, error, int64) {
+	if vt.granted() {
+		return vt.replies, nil, -1
+	}
+
+	if vt.logErr != nil {
+		return nil, vt.logErr, vt.higherTerm
+	}
+
+	if vt.higherTerm < 0 && vt.leaderAlive {
+		return nil, ErrLeaderAlive, vt.higherTerm
+	}
+
+	err := errors.Wrapf(ErrStaleTermId, "seen a higher term:%d", vt.higherTerm)
+	return nil, err, vt.higherTerm
 }
 
 // Only a established leader should use this func.
@@ -397,22 +732,23 @@ func (tr *TRaft) AddLog(cmd *Cmd) *Record {
 		panic("wtf")
 	}
 
-	lsn := tr.LogOffset + int64(len(tr.Logs))
+	first := tr.logs.FirstIndex()
+	lsn := tr.logs.LastIndex() + 1
 
 	r := NewRecord(me.VotedFor, lsn, cmd)
 
 	// find the first interfering record.
 
-	var i int
-	for i = len(tr.Logs) - 1; i >= 0; i-- {
-		prev := tr.Logs[i]
+	var i int64
+	for i = lsn - 1; i >= first; i-- {
+		prev := tr.logs.Get(i)
 		if r.Interfering(prev) {
 			r.Overrides = prev.Overrides.Clone()
 			break
 		}
 	}
 
-	if i == -1 {
+	if i < first {
 		// there is not a interfering record.
 		r.Overrides = NewTailBitmap(0)
 	}
@@ -421,16 +757,53 @@ func (tr *TRaft) AddLog(cmd *Cmd) *Record {
 
 	// all log I do not know must be executed in order.
 	// Because I do not know of the intefering relations.
-	r.Depends = NewTailBitmap(tr.LogOffset)
+	r.Depends = NewTailBitmap(first)
 
 	// reduce bitmap size by removing unknown logs
-	r.Overrides.Union(NewTailBitmap(tr.LogOffset & ^63))
+	r.Overrides.Union(NewTailBitmap(first & ^63))
 
-	tr.Logs = append(tr.Logs, r)
+	tr.appendLogs(r)
 
 	return r
 }
 
+// hdlPreVoteReq replies as if it grants the candidate if this replica would:
+// the leader it follows is expired, or is the candidate itself, and the
+// candidate has all logs this replica has.
+// Nothing is changed: the candidate does not yet bump its term.
+// Whether the term of the candidate is high enough is left to the real vote.
+// It must be called from Loop().
+func (tr *TRaft) hdlPreVoteReq(req *VoteReq) *VoteReply {
+
+	me := tr.Status[tr.Id]
+
+	repl := &VoteReply{
+		Id:        tr.Id,
+		VotedFor:  me.VotedFor.Clone(),
+		Committer: me.Committer.Clone(),
+		Accepted:  me.Accepted.Clone(),
+		Committed: me.Committed.Clone(),
+	}
+
+	if CmpLogStatus(req, me) < 0 {
+		tr.sendMsg("hdl-pre-vote-req:reject-by-logstat",
+			"req.Candidate", req.Candidate,
+			"me.Committer", me.Committer,
+			"req.Committer", req.Committer)
+		return repl
+	}
+
+	if tr.clock.Now() < me.VoteExpireAt && me.VotedFor.Id != req.Candidate.Id {
+		tr.sendMsg("hdl-pre-vote-req:reject-by-lease",
+			"req.Candidate", req.Candidate,
+			"me.VotedFor", me.VotedFor)
+		return repl
+	}
+
+	repl.VotedFor = req.Candidate.Clone()
+	return repl
+}
+
 // no lock protect, must be called by TRaft.Loop()
 func (tr *TRaft) hdlVoteReq(req *VoteReq) *VoteReply {
 
@@ -493,9 +866,13 @@ func (tr *TRaft) hdlVoteReq(req *VoteReq) *VoteReply {
 		"me.VotedFor", me.VotedFor)
 
 	me.VotedFor = req.Candidate.Clone()
-	me.VoteExpireAt = uSecondI64() + leaderLease
+	me.VoteExpireAt = tr.clock.Now() + leaderLease
 	repl.VotedFor = req.Candidate.Clone()
 
+	// never forget a granted vote.
+	tr.persistHardState()
+	tr.replyLeaderLost()
+
 	// send back the logs I have but the candidate does not.
 
 	logs := make([]*Record, 0)
@@ -504,7 +881,10 @@ func (tr *TRaft) hdlVoteReq(req *VoteReq) *VoteReply {
 	end := me.Accepted.Len()
 	for i := start; i < end; i++ {
 		if me.Accepted.Get(i) != 0 && req.Accepted.Get(i) == 0 {
-			logs = append(logs, tr.Logs[i-tr.LogOffset])
+			r := tr.logs.Get(i)
+			if r != nil {
+				logs = append(logs, r)
+			}
 		}
 	}
 
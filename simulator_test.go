@@ -0,0 +1,788 @@
+package traft
+
+import (
+	"container/heap"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var simSeed = flag.Int64("sim.seed", 0, "seed of randomized simulation tests, 0 to use the current time")
+
+// seedForTest returns the seed specified by -sim.seed or a new one.
+// The seed is logged so that a failed simulation can be replayed.
+func seedForTest(t *testing.T) int64 {
+	seed := *simSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("replay with: -sim.seed=%d", seed)
+	return seed
+}
+
+// simulator drives replicas in one goroutine with a FakeClock.
+// It takes the same steps as VoteLoop(), ReplicateLoop() and ApplyLoop() do,
+// e.g., nextVoteStep(), nextForward() and applyLogs(), but every timer and
+// message is an event in a queue ordered by time, and all randomness comes
+// from the seed.
+// Thus a simulation with the same seed always has the same trace.
+type simulator struct {
+	seed  int64
+	rnd   *rand.Rand
+	clock *FakeClock
+	start int64
+
+	ids   []int64
+	trs   map[int64]*TRaft
+	nodes map[int64]*simNode
+
+	// where hard state of every replica is saved, to restart a replica.
+	dir     string
+	cluster map[int64]string
+
+	// log storage survives a crash.
+	storages map[int64]LogStorage
+
+	// the default LinkFault and those of specific links.
+	fault LinkFault
+	links map[[2]int64]LinkFault
+
+	events simEvents
+	seq    int64
+
+	// everything happened, one event per line.
+	trace []string
+}
+
+// simNode is what a real TRaft keeps in goroutines other than Loop().
+// It is lost when the replica crashes.
+type simNode struct {
+	// incarnation of the replica. It increments every restart.
+	gen  int64
+	down bool
+
+	// the election in progress.
+	vote *voteTally
+
+	// the heartbeat in progress.
+	hb *heartbeatTally
+
+	// what ReplicateLoop()-s of the replica do.
+	replicators []*simReplicator
+
+	proposals []*simProposal
+}
+
+// simReplicator drives a replicator as ReplicateLoop() does.
+type simReplicator struct {
+	rp *replicator
+
+	// after a failed forwarding, nothing is sent until then.
+	pausedUntil int64
+
+	quit bool
+}
+
+type simProposal struct {
+	id      int64
+	cmd     string
+	finCh   chan *ProposeReply
+	reply   *ProposeReply
+	onReply func(reply *ProposeReply)
+}
+
+type simEvent struct {
+	at  int64
+	seq int64
+
+	// the replica and its incarnation the event happens on.
+	// 0 is the simulator itself.
+	id  int64
+	gen int64
+
+	what string
+	f    func()
+}
+
+type simEvents []*simEvent
+
+func (e simEvents) Len() int { return len(e) }
+func (e simEvents) Less(i, j int) bool {
+	if e[i].at != e[j].at {
+		return e[i].at < e[j].at
+	}
+	return e[i].seq < e[j].seq
+}
+func (e simEvents) Swap(i, j int)       { e[i], e[j] = e[j], e[i] }
+func (e *simEvents) Push(x interface{}) { *e = append(*e, x.(*simEvent)) }
+func (e *simEvents) Pop() interface{} {
+	old := *e
+	x := old[len(old)-1]
+	*e = old[:len(old)-1]
+	return x
+}
+
+// newSimulator creates replicas `ids`.
+// Hard state is saved in dir so that a replica can be restarted after a crash.
+// An empty dir is only for a simulation without crash.
+func newSimulator(seed int64, ids []int64, dir string) *simulator {
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+
+	s := &simulator{
+		seed:     seed,
+		rnd:      rand.New(rand.NewSource(seed)),
+		clock:    NewFakeClock(start),
+		start:    start,
+		ids:      ids,
+		trs:      map[int64]*TRaft{},
+		nodes:    map[int64]*simNode{},
+		dir:      dir,
+		cluster:  map[int64]string{},
+		storages: map[int64]LogStorage{},
+		links:    map[[2]int64]LinkFault{},
+	}
+
+	for _, id := range ids {
+		s.cluster[id] = fmt.Sprintf("sim-%d", id)
+		s.storages[id] = NewMemStorage(0)
+	}
+
+	for _, id := range ids {
+		s.nodes[id] = &simNode{}
+		s.trs[id] = s.newReplica(id)
+	}
+
+	return s
+}
+
+// newReplica creates a replica from what survives a crash and starts its
+// VoteLoop at a random time.
+func (s *simulator) newReplica(id int64) *TRaft {
+	opts := []Option{
+		WithClock(s.clock),
+		WithRand(rand.New(rand.NewSource(s.rnd.Int63()))),
+		WithStorage(s.storages[id]),
+	}
+
+	if s.dir != "" {
+		dir := filepath.Join(s.dir, fmt.Sprintf("%d", id))
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithDir(dir))
+	}
+
+	tr := NewTRaft(id, s.cluster, opts...)
+	tr.runReplicator = func(rp *replicator) {
+		nd := s.nodes[id]
+		nd.replicators = append(nd.replicators, &simReplicator{rp: rp})
+
+		// they are started in random order.
+		sort.SliceStable(nd.replicators, func(i, j int) bool {
+			return nd.replicators[i].rp.to.Id < nd.replicators[j].rp.to.Id
+		})
+	}
+
+	// what Loop() does when it starts.
+	tr.resumeReplicators()
+
+	s.after(time.Duration(s.rnd.Int63n(int64(followerSleep))), id, "tick", s.tick)
+	return tr
+}
+
+func (s *simulator) stop() {
+	for _, tr := range s.trs {
+		tr.Stop()
+	}
+}
+
+// crash stops a replica. All its volatile state is lost.
+func (s *simulator) crash(id int64) {
+	nd := s.nodes[id]
+	if nd.down {
+		return
+	}
+
+	s.log(id, "crash")
+	nd.down = true
+	s.trs[id].Stop()
+}
+
+// restart starts a crashed replica with its durable state.
+func (s *simulator) restart(id int64) {
+	nd := s.nodes[id]
+	if !nd.down {
+		return
+	}
+
+	s.log(id, "restart")
+	s.nodes[id] = &simNode{gen: nd.gen + 1}
+	s.trs[id] = s.newReplica(id)
+}
+
+// partition drops all messages between replicas in different groups.
+// Replicas not in any group are isolated.
+func (s *simulator) partition(groups ...[]int64) {
+	group := map[int64]int{}
+	for i, g := range groups {
+		for _, id := range g {
+			group[id] = i + 1
+		}
+	}
+
+	s.log(0, "partition %v", groups)
+	s.links = map[[2]int64]LinkFault{}
+	for _, a := range s.ids {
+		for _, b := range s.ids {
+			if a != b && (group[a] == 0 || group[a] != group[b]) {
+				s.links[[2]int64{a, b}] = LinkFault{DropRate: 1}
+			}
+		}
+	}
+}
+
+// heal removes all partitions.
+func (s *simulator) heal() {
+	s.log(0, "heal")
+	s.links = map[[2]int64]LinkFault{}
+}
+
+// after schedules f to run on the current incarnation of replica id after d.
+func (s *simulator) after(d time.Duration, id int64, what string, f func(id int64)) {
+	var gen int64
+	if nd, ok := s.nodes[id]; ok {
+		gen = nd.gen
+	}
+	s.afterGen(d, id, gen, what, f)
+}
+
+// afterGen schedules f to run on replica id after d, if the replica is still
+// the incarnation `gen` and is not down.
+func (s *simulator) afterGen(d time.Duration, id, gen int64, what string, f func(id int64)) {
+	s.seq++
+	heap.Push(&s.events, &simEvent{
+		at:   s.clock.Now() + int64(d),
+		seq:  s.seq,
+		id:   id,
+		gen:  gen,
+		what: what,
+		f:    func() { f(id) },
+	})
+}
+
+func (s *simulator) log(id int64, format string, args ...interface{}) {
+	t := time.Duration(s.clock.Now() - s.start)
+	s.trace = append(s.trace,
+		fmt.Sprintf("%8.3fs %d: %s", t.Seconds(), id, fmt.Sprintf(format, args...)))
+}
+
+// run processes events for a duration of d.
+func (s *simulator) run(d time.Duration) {
+	end := s.clock.Now() + int64(d)
+
+	for len(s.events) > 0 && s.events[0].at <= end {
+		ev := heap.Pop(&s.events).(*simEvent)
+		s.clock.Set(ev.at)
+
+		nd, ok := s.nodes[ev.id]
+		if !ok {
+			ev.f()
+			continue
+		}
+
+		if nd.down || nd.gen != ev.gen {
+			continue
+		}
+
+		ev.f()
+
+		// what Loop() does after every action, and what ApplyLoop() and
+		// ReplicateLoop() do when they are notified.
+		tr := s.trs[ev.id]
+		tr.checkStatus()
+		s.apply(ev.id)
+		s.replicate(ev.id)
+		s.collectReplies()
+	}
+
+	s.clock.Set(end)
+}
+
+// propose schedules a proposal of cmd to replica id after d.
+func (s *simulator) propose(d time.Duration, id int64, cmd string) {
+	s.proposeReq(d, id, &ProposeReq{Cmd: toCmd(cmd)}, nil)
+}
+
+// proposeReq schedules a proposal to replica id after d.
+// onReply is called when it is replied. A proposal is never replied if the
+// replica is down.
+func (s *simulator) proposeReq(d time.Duration, id int64, req *ProposeReq, onReply func(*ProposeReply)) {
+	s.after(d, id, "propose", func(id int64) {
+		p := &simProposal{
+			id:      id,
+			cmd:     req.Cmd.ShortStr(),
+			finCh:   make(chan *ProposeReply, 1),
+			onReply: onReply,
+		}
+		s.nodes[id].proposals = append(s.nodes[id].proposals, p)
+
+		s.log(id, "propose %s", p.cmd)
+		s.trs[id].hdlPropose(req, p.finCh)
+	})
+}
+
+func (s *simulator) collectReplies() {
+	for _, id := range s.ids {
+		for _, p := range s.nodes[id].proposals {
+			if p.reply != nil {
+				continue
+			}
+			select {
+			case p.reply = <-p.finCh:
+				s.log(id, "reply %s: ok:%v seq:%d err:%s", p.cmd, p.reply.OK, p.reply.Seq, p.reply.Err)
+				if p.onReply != nil {
+					p.onReply(p.reply)
+				}
+			default:
+			}
+		}
+	}
+}
+
+// apply does what ApplyLoop() does, in the same goroutine.
+func (s *simulator) apply(id int64) {
+	tr := s.trs[id]
+	for {
+		a := tr.applyLogs(tr.applicableLogs())
+		if a == nil {
+			return
+		}
+		tr.hdlApplied(a)
+	}
+}
+
+// send delivers a request from replica `from` to `to` and calls onReply with
+// the reply or an error, the same as a MemNetwork does according to the
+// LinkFault of the link.
+// handle runs on `to` and onReply runs on `from`.
+func (s *simulator) send(from, to int64, what string,
+	handle func(tr *TRaft) wireMsg, onReply func(reply wireMsg, err error)) {
+
+	f, ok := s.links[[2]int64{from, to}]
+	if !ok {
+		f = s.fault
+	}
+
+	delay := func() time.Duration {
+		d := f.MinDelay
+		if f.MaxDelay > f.MinDelay {
+			d += time.Duration(s.rnd.Int63n(int64(f.MaxDelay - f.MinDelay)))
+		}
+		return d
+	}
+
+	// the reply is dropped if the sender restarted.
+	fromGen := s.nodes[from].gen
+
+	// only the first of the reply and the timeout is seen by the sender.
+	replied := false
+	reply := func(d time.Duration, r wireMsg, err error) {
+		s.afterGen(d, from, fromGen, what+"-reply", func(from int64) {
+			if !replied {
+				replied = true
+				onReply(r, err)
+			}
+		})
+	}
+
+	nd, ok := s.nodes[to]
+	if !ok || nd.down {
+		reply(0, nil, errors.Wrapf(ErrUnreachable, "%d", to))
+		return
+	}
+
+	// a replica crashed meanwhile never replies.
+	reply(rpcTimeout, nil, errors.Wrapf(ErrTimeout, "send to %d", to))
+
+	if s.rnd.Float64() < f.DupRate {
+		s.after(delay(), to, what+"-dup", func(to int64) {
+			handle(s.trs[to])
+		})
+	}
+
+	d := delay()
+
+	if s.rnd.Float64() < f.DropRate {
+		reply(d, nil, errors.Wrapf(ErrUnreachable, "request to %d dropped", to))
+		return
+	}
+
+	dropReply := s.rnd.Float64() < f.DropRate
+
+	s.after(d, to, what, func(to int64) {
+		r := handle(s.trs[to])
+		if dropReply {
+			reply(0, nil, errors.Wrapf(ErrUnreachable, "reply from %d dropped", to))
+			return
+		}
+		reply(0, r, nil)
+	})
+}
+
+// tick does one iteration of VoteLoop().
+// The simulator never transfers leadership, thus a replica never elects at
+// once.
+func (s *simulator) tick(id int64) {
+	s.doStep(id, s.trs[id].nextVoteStep(false))
+}
+
+// doStep does what VoteLoop() does for a voteStep.
+func (s *simulator) doStep(id int64, step *voteStep) {
+	switch {
+	case step.lead != nil:
+		s.heartbeat(id, step.lead)
+		s.after(heartbeatInterval, id, "tick", s.tick)
+
+	case step.election != nil:
+		s.elect(id, step.election)
+
+	default:
+		if step.fetchFrom != nil {
+			s.fetchLogs(id, step.fetchFrom.Id)
+		}
+		s.after(step.wait, id, "tick", s.tick)
+	}
+}
+
+// elect collects votes for a pre-vote or a vote, the same as collectVotes().
+func (s *simulator) elect(id int64, el *election) {
+	tr := s.trs[id]
+	nd := s.nodes[id]
+
+	preVote := el.preVote
+	what := "vote"
+	if preVote {
+		what = "pre-vote"
+	}
+
+	s.log(id, "%s-start %s", what, el.leadst.VotedFor.ShortStr())
+
+	vt := newVoteTally(el.leadst.VotedFor, el.logst, el.config)
+	nd.vote = vt
+
+	done := func(err error) {
+		if nd.vote != vt {
+			// a former election
+			return
+		}
+		nd.vote = nil
+
+		rerr := err
+		if rerr == nil {
+			_, rerr, _ = vt.result()
+		}
+		s.log(id, "%s-done %s: %v", what, el.leadst.VotedFor.ShortStr(), rerr)
+
+		s.doStep(id, tr.hdlElectionDone(el, vt, err))
+	}
+
+	req := newVoteReq(el.leadst.VotedFor, el.logst)
+
+	// in a fixed order, thus the randomness of send() is reproducible.
+	for _, m := range el.config.SortedReplicaInfos() {
+		if m == nil || m.Id == id {
+			continue
+		}
+		ri := m
+		s.send(id, m.Id, what,
+			func(tr *TRaft) wireMsg {
+				r := &VoteReq{}
+				copyMsg(req, r)
+				if preVote {
+					return tr.hdlPreVoteReq(r)
+				}
+				return tr.hdlVoteReq(r)
+			},
+			func(reply wireMsg, err error) {
+				if nd.vote != vt {
+					return
+				}
+				if err != nil {
+					vt.add(ri, nil)
+				} else {
+					r := &VoteReply{}
+					copyMsg(reply, r)
+					vt.add(ri, r)
+				}
+				if vt.done() {
+					done(nil)
+				}
+			})
+	}
+
+	if vt.done() {
+		done(nil)
+		return
+	}
+
+	s.after(voteTimeout, id, what+"-timeout", func(id int64) {
+		done(errors.Wrapf(ErrTimeout, "voting"))
+	})
+}
+
+// fetchLogs does what TRaft.fetchLogs() does.
+func (s *simulator) fetchLogs(id, from int64) {
+	req := s.trs[id].fetchLogsReq()
+
+	s.send(id, from, "fetch-logs",
+		func(tr *TRaft) wireMsg {
+			r := &FetchLogsReq{}
+			copyMsg(req, r)
+			return tr.hdlFetchLogsReq(r)
+		},
+		func(reply wireMsg, err error) {
+			if err != nil {
+				s.log(id, "fetch-logs from %d: %v", from, err)
+				return
+			}
+			r := &FetchLogsReply{}
+			copyMsg(reply, r)
+			ok := s.trs[id].hdlFetchLogsReply(from, r)
+			s.log(id, "fetch-logs from %d: %v", from, ok)
+		})
+}
+
+// heartbeat does what TRaft.heartbeat() does.
+func (s *simulator) heartbeat(id int64, committer *LeaderId) {
+	tr := s.trs[id]
+	nd := s.nodes[id]
+
+	req, config, err := tr.heartbeatReq(committer)
+	if err != nil {
+		return
+	}
+
+	// the lease starts when heartbeat is sent.
+	sentAt := s.clock.Now()
+
+	// a heartbeat not acknowledged in time is replaced by the next one.
+	ht := newHeartbeatTally(id, config)
+	nd.hb = ht
+
+	for _, m := range config.SortedReplicaInfos() {
+		if m == nil || m.Id == id {
+			continue
+		}
+		ri := m
+		s.send(id, m.Id, "heartbeat",
+			func(tr *TRaft) wireMsg {
+				r := &LogForwardReq{}
+				copyMsg(req, r)
+				return tr.hdlLogForward(r)
+			},
+			func(reply wireMsg, err error) {
+				ok := false
+				if err == nil {
+					r := &LogForwardReply{}
+					copyMsg(reply, r)
+					ok = tr.hdlHeartbeatReply(committer, ri.Id, r)
+				}
+
+				if nd.hb != ht || ht.done() {
+					return
+				}
+				ht.add(ri, ok)
+				if ht.granted() {
+					tr.extendLease(committer, sentAt)
+				}
+			})
+	}
+
+	if ht.granted() {
+		tr.extendLease(committer, sentAt)
+	}
+}
+
+// replicate does what every ReplicateLoop() of a replica does when it is
+// woken up: forward logs a follower lacks, until the leadership is lost.
+func (s *simulator) replicate(id int64) {
+	nd := s.nodes[id]
+
+	running := nd.replicators[:0]
+	for _, sr := range nd.replicators {
+		s.forward(id, sr)
+		if !sr.quit {
+			running = append(running, sr)
+		}
+	}
+	nd.replicators = running
+}
+
+// forward sends logs with a replicator, as ReplicateLoop() does.
+func (s *simulator) forward(id int64, sr *simReplicator) {
+	tr := s.trs[id]
+	rp := sr.rp
+
+	if s.clock.Now() < sr.pausedUntil {
+		return
+	}
+
+	for {
+		req, err := tr.nextForward(rp)
+		if err != nil {
+			s.log(id, "replicate to %d quit: %v", rp.to.Id, err)
+			sr.quit = true
+			return
+		}
+		if req == nil {
+			return
+		}
+
+		s.log(id, "forward to %d: %s", rp.to.Id, RecordsShortStr(req.Logs, ""))
+
+		s.send(id, rp.to.Id, "forward",
+			func(tr *TRaft) wireMsg {
+				r := &LogForwardReq{}
+				copyMsg(req, r)
+				return tr.hdlLogForward(r)
+			},
+			func(reply wireMsg, err error) {
+				res := &forwardRst{req: req, err: err}
+				if err == nil {
+					res.reply = &LogForwardReply{}
+					copyMsg(reply, res.reply)
+				}
+
+				backoff, err := tr.hdlForwardRst(rp, res)
+				if err != nil {
+					sr.quit = true
+					return
+				}
+				if backoff > 0 {
+					sr.pausedUntil = s.clock.Now() + int64(backoff)
+					s.after(backoff, id, "replicate-retry", func(id int64) {})
+				}
+			})
+	}
+}
+
+// leader returns the replica that believes it is the leader with a valid lease
+// and is voted by all others, or nil.
+func (s *simulator) leader() *TRaft {
+	now := s.clock.Now()
+	for _, id := range s.ids {
+		me := s.trs[id].Status[id]
+		if me.VotedFor.Id != id || me.VoteExpireAt < now {
+			continue
+		}
+
+		all := true
+		for _, other := range s.ids {
+			if !s.trs[other].Status[other].VotedFor.Equal(me.VotedFor) {
+				all = false
+			}
+		}
+		if all {
+			return s.trs[id]
+		}
+	}
+	return nil
+}
+
+// kvsOf returns the applied key-values of a replica.
+func kvsOf(tr *TRaft) string {
+	kvs := tr.sm.(*kvState)
+	kvs.mu.Lock()
+	defer kvs.mu.Unlock()
+
+	all := kvs.all()
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ss = append(ss, all[k].ShortStr())
+	}
+	return strings.Join(ss, ",")
+}
+
+func TestSimulator_election(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := newSimulator(1, []int64{1, 2, 3}, "")
+	defer s.stop()
+
+	s.run(time.Second * 3)
+
+	leader := s.leader()
+	ta.NotNil(leader, "trace: %s", strings.Join(s.trace, "\n"))
+
+	// the leader keeps its leadership
+	s.run(time.Second * 10)
+	ta.Equal(leader, s.leader())
+}
+
+func TestSimulator_replay(t *testing.T) {
+
+	ta := require.New(t)
+
+	seed := seedForTest(t)
+
+	sim := func(seed int64) *simulator {
+		s := newSimulator(seed, []int64{1, 2, 3}, "")
+		s.fault = LinkFault{
+			DropRate: 0.1,
+			DupRate:  0.1,
+			MinDelay: time.Millisecond,
+			MaxDelay: time.Millisecond * 20,
+		}
+
+		for i := 0; i < 20; i++ {
+			id := s.ids[s.rnd.Intn(len(s.ids))]
+			cmd := fmt.Sprintf("%c=%d", 'x'+s.rnd.Intn(3), i)
+			s.propose(time.Duration(s.rnd.Int63n(int64(time.Second*3))), id, cmd)
+		}
+
+		s.run(time.Second * 5)
+		s.stop()
+		return s
+	}
+
+	s1 := sim(seed)
+	s2 := sim(seed)
+
+	ta.Equal(s1.trace, s2.trace)
+	for _, id := range s1.ids {
+		ta.Equal(kvsOf(s1.trs[id]), kvsOf(s2.trs[id]))
+	}
+
+	// a proposal is replied only when it is committed.
+	nOK := 0
+	for _, id := range s1.ids {
+		for _, p := range s1.nodes[id].proposals {
+			if p.reply != nil && p.reply.OK {
+				nOK++
+			}
+		}
+	}
+	ta.True(nOK > 0, "trace: %s", strings.Join(s1.trace, "\n"))
+
+	s3 := sim(seed + 1)
+	ta.NotEqual(s1.trace, s3.trace)
+}
@@ -0,0 +1,170 @@
+package traft
+
+import (
+	"github.com/pkg/errors"
+)
+
+const snapshotFn = "snapshot"
+
+// snapshotThreshold is the number of applied logs kept in LogStorage before
+// a snapshot is made and these logs are reclaimed.
+var snapshotThreshold = int64(10000)
+
+func saveSnapshot(dir string, snap *Snapshot) error {
+	b, err := snap.Marshal()
+	if err != nil {
+		return errors.Wrapf(err, "marshal snapshot")
+	}
+
+	return writeFileAtomic(dir, snapshotFn, b)
+}
+
+// loadSnapshot reads the snapshot from dir.
+// It returns nil Snapshot if there is none.
+func loadSnapshot(dir string) (*Snapshot, error) {
+	b, err := readFileChecked(dir, snapshotFn)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+
+	snap := &Snapshot{}
+	err = snap.Unmarshal(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal snapshot")
+	}
+	return snap, nil
+}
+
+// loadSnapshotToMe restores the state machine from the snapshot in tr.dir
+// and marks all logs in it as committed and applied.
+func (tr *TRaft) loadSnapshotToMe() error {
+	snap, err := loadSnapshot(tr.dir)
+	if err != nil {
+		return err
+	}
+	if snap == nil {
+		return nil
+	}
+
+	err = tr.sm.Restore(snap.Data)
+	if err != nil {
+		return errors.Wrapf(err, "restore state machine")
+	}
+
+	tr.installSnapshot(snap)
+
+	lg.Infow("load-snapshot", "Id", tr.Id, "Offset", snap.Offset)
+	return nil
+}
+
+// installSnapshot makes snap the base state of this replica.
+// Logs before snap.Offset are reclaimed.
+func (tr *TRaft) installSnapshot(snap *Snapshot) {
+	tr.snapshot = snap
+
+	if tr.logs.FirstIndex() < snap.Offset {
+		err := tr.logs.Reclaim(snap.Offset)
+		if err != nil {
+			lg.Panicw("fail to reclaim logs", "lsn", snap.Offset, "err", err)
+		}
+	}
+
+	applied := snap.Applied
+	if applied == nil {
+		applied = NewTailBitmap(snap.Offset)
+	}
+
+	me := tr.Status[tr.Id]
+	me.Accepted.Union(applied)
+	me.Committed.Union(applied)
+	me.Applied.Union(applied)
+
+	if snap.Config != nil {
+		tr.baseConfig = snap.Config
+	}
+	tr.loadConfig()
+}
+
+// maybeSnapshot makes a snapshot if there are too many applied logs in
+// LogStorage.
+// It must be called from ApplyLoop().
+func (tr *TRaft) maybeSnapshot() {
+
+	var applied *TailBitmap
+	var first, gen int64
+
+	rst := tr.queryOrStop("func", func() error {
+		applied = tr.Status[tr.Id].Applied.Clone()
+		first = tr.logs.FirstIndex()
+		gen = tr.applyGen
+		return nil
+	})
+	if rst == nil {
+		return
+	}
+
+	if applied.Offset-first < snapshotThreshold {
+		return
+	}
+
+	err := tr.takeSnapshot(applied, gen)
+	if err != nil {
+		lg.Infow("fail to take snapshot", "err", err)
+	}
+}
+
+// takeSnapshot snapshots the state machine, in which logs in `applied` are
+// applied, persists it and reclaims logs before applied.Offset.
+// gen is the generation of the state machine `applied` is read from.
+// It must be called from ApplyLoop(), thus the state machine does not change.
+func (tr *TRaft) takeSnapshot(applied *TailBitmap, gen int64) error {
+
+	data, err := tr.sm.Snapshot()
+	if err != nil {
+		return errors.Wrapf(err, "snapshot state machine")
+	}
+
+	snap := &Snapshot{
+		Offset:  applied.Offset,
+		Applied: applied,
+		Data:    data,
+	}
+
+	rst := tr.queryOrStop("func", func() error {
+		if gen != tr.applyGen {
+			return errors.Errorf("state machine is replaced")
+		}
+
+		if tr.snapshot != nil && snap.Offset <= tr.snapshot.Offset {
+			return nil
+		}
+
+		// config logs before Offset are reclaimed.
+		snap.Config, _ = tr.lastConfig(snap.Offset)
+
+		// snapshot must be durable before logs are removed.
+		if tr.dir != "" {
+			err := saveSnapshot(tr.dir, snap)
+			if err != nil {
+				return err
+			}
+		}
+
+		tr.installSnapshot(snap)
+
+		lg.Infow("take-snapshot",
+			"Id", tr.Id,
+			"Offset", snap.Offset,
+			"size", len(snap.Data))
+
+		return nil
+	})
+	if rst == nil {
+		return errors.Errorf("TRaft is stopped")
+	}
+
+	return rst.err
+}
@@ -0,0 +1,181 @@
+package traft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVState_Snapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []map[string]int64{
+		{},
+		{"x": 1},
+		{"x": 1, "y": 2, "xy": 3, "abc": 4},
+	}
+
+	for i, c := range cases {
+		st := newKVState()
+		for k, v := range c {
+			st.kvs[k] = NewCmdI64("set", k, v)
+		}
+
+		b, err := st.Snapshot()
+		ta.Nil(err)
+
+		got := newKVState()
+		ta.Nil(got.Restore(b))
+		ta.Equal(kvsIn(st), kvsIn(got), "%d-th: case: %+v", i+1, c)
+
+		// values are looked up in the snapshot
+		ta.Empty(got.kvs, "%d-th: case: %+v", i+1, c)
+		for k, v := range c {
+			ta.Equal(NewCmdI64("set", k, v), got.get(k), "%d-th: case: %+v", i+1, c)
+		}
+		ta.Nil(got.get("not-exist"), "%d-th: case: %+v", i+1, c)
+
+		// a key set after the snapshot overrides the one in it.
+		for k, v := range c {
+			rst := got.Apply(NewRecord(NewLeaderId(1, 1), 1, NewCmdI64("set", k, v+1)))
+			prev := &Cmd{}
+			ta.Nil(prev.Unmarshal(rst))
+			ta.Equal(NewCmdI64("set", k, v), prev, "%d-th: case: %+v", i+1, c)
+			ta.Equal(NewCmdI64("set", k, v+1), got.get(k), "%d-th: case: %+v", i+1, c)
+		}
+	}
+}
+
+// kvsIn returns all key-values in s.
+func kvsIn(s *kvState) map[string]*Cmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.all()
+}
+
+func TestKVState_Apply(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	st := newKVState()
+
+	ta.Nil(st.Apply(&Record{Seq: 0}))
+	ta.Nil(st.Apply(NewRecord(lid(1, 1), 1, NewCmdI64("set", "x", 1))))
+	ta.Nil(st.Apply(NewRecord(lid(1, 1), 2, NewCmdI64("foo", "x", 2))))
+
+	// returns the previous value
+	rst := st.Apply(NewRecord(lid(1, 1), 3, NewCmdI64("set", "x", 3)))
+	prev := &Cmd{}
+	ta.Nil(prev.Unmarshal(rst))
+	ta.Equal(NewCmdI64("set", "x", 1), prev)
+
+	ta.Equal(map[string]*Cmd{
+		"x": NewCmdI64("set", "x", 3),
+	}, st.kvs)
+}
+
+func TestTRaft_takeSnapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(n int64) { snapshotThreshold = n }(snapshotThreshold)
+	snapshotThreshold = 64
+
+	dir := tmpDir(t)
+	id := int64(1)
+	cluster := map[int64]string{1: ":5501"}
+
+	tr := NewTRaft(id, cluster, WithDir(dir))
+	me := tr.Status[id]
+
+	for i := 0; i < 70; i++ {
+		tr.addlogs(fmt.Sprintf("k%d=%d", i%3, i))
+	}
+	tr.leaderUpdateCommitted(me.VotedFor, []int64{0, 70})
+	ta.Equal(int64(64), me.Committed.Offset)
+
+	tr.StartMainLoop()
+
+	waitFor(ta, tr, func() bool {
+		return tr.snapshot != nil
+	})
+
+	query(tr.actionCh, "func", func() error {
+		ta.Equal(int64(64), tr.snapshot.Offset)
+		ta.Equal(int64(70), tr.snapshot.Applied.Len())
+		ta.Equal(int64(64), tr.logs.FirstIndex())
+		ta.Equal(int64(69), tr.logs.LastIndex())
+		ta.Equal(int64(70), me.Applied.Len())
+		return nil
+	})
+
+	tr.Stop()
+
+	tr = NewTRaft(id, cluster, WithDir(dir))
+	defer tr.Stop()
+
+	me = tr.Status[id]
+	ta.Equal(int64(64), tr.snapshot.Offset)
+	ta.Equal(int64(64), tr.logs.FirstIndex())
+	ta.Equal(int64(70), me.Accepted.Len())
+	ta.Equal(int64(70), me.Committed.Len())
+	ta.Equal(int64(70), me.Applied.Len())
+
+	ta.Equal(map[string]*Cmd{
+		"k0": NewCmdI64("set", "k0", 69),
+		"k1": NewCmdI64("set", "k1", 67),
+		"k2": NewCmdI64("set", "k2", 68),
+	}, kvsIn(tr.sm.(*kvState)))
+
+	// new logs depend on the snapshot
+	r := tr.AddLog(NewCmdI64("set", "x", 1))
+	ta.Equal(int64(70), r.Seq)
+	ta.Equal(int64(64), r.Depends.Offset)
+}
+
+func TestTRaft_maybeSnapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(n int64) { snapshotThreshold = n }(snapshotThreshold)
+	snapshotThreshold = 128
+
+	id := int64(1)
+	tr := NewTRaft(id, map[int64]string{1: ":5501"})
+	defer tr.Stop()
+
+	me := tr.Status[id]
+
+	for i := 0; i < 150; i++ {
+		tr.addlogs(fmt.Sprintf("k=%d", i))
+	}
+	tr.leaderUpdateCommitted(me.VotedFor, []int64{0, 100})
+
+	tr.StartMainLoop()
+
+	waitFor(ta, tr, func() bool {
+		return me.Applied.Len() == 100
+	})
+
+	query(tr.actionCh, "func", func() error {
+		ta.Nil(tr.snapshot)
+		return tr.leaderUpdateCommitted(me.VotedFor, []int64{100, 150})
+	})
+
+	waitFor(ta, tr, func() bool {
+		return tr.snapshot != nil
+	})
+
+	query(tr.actionCh, "func", func() error {
+		ta.Equal(int64(128), tr.snapshot.Offset)
+		ta.Equal(int64(128), tr.logs.FirstIndex())
+		ta.Equal(map[string]*Cmd{
+			"k": NewCmdI64("set", "k", 149),
+		}, kvsIn(tr.sm.(*kvState)))
+		return nil
+	})
+}
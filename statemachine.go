@@ -0,0 +1,197 @@
+package traft
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/openacid/slim/encode"
+	"github.com/openacid/slim/trie"
+	"github.com/pkg/errors"
+)
+
+// StateMachine executes committed commands.
+//
+// A record is applied only after all records in its Depends and Overrides are
+// applied. Records that do not interfere with each other(see
+// Cmd.Interfering()) may be applied in any order or concurrently.
+// Snapshot and Restore are never called concurrently with Apply.
+type StateMachine interface {
+	// Apply executes the Cmd in a committed record and returns the result to
+	// the proposer.
+	// It must be deterministic: every replica gets the same state by applying
+	// the same records.
+	Apply(r *Record) []byte
+
+	// Snapshot serializes the current state.
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the current state with a serialized one.
+	Restore(data []byte) error
+}
+
+// kvState is the default StateMachine: a key-value map built by applying `set`
+// commands.
+//
+// The state restored from a snapshot is not loaded into the map: a key not set
+// since then is looked up in the snapshot with its slim trie index.
+type kvState struct {
+	mu   sync.Mutex
+	base *kvSnapshot
+	kvs  map[string]*Cmd
+}
+
+func newKVState() *kvState {
+	return &kvState{
+		kvs: map[string]*Cmd{},
+	}
+}
+
+// kvSnapshot is an unmarshaled KVSnapshot with its KeyIndex loaded.
+type kvSnapshot struct {
+	index  *trie.SlimTrie
+	values []*Cmd
+}
+
+func loadKVSnapshot(data []byte) (*kvSnapshot, error) {
+
+	kvsnap := &KVSnapshot{}
+	err := kvsnap.Unmarshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal KVSnapshot")
+	}
+
+	st, err := trie.NewSlimTrie(encode.U32{}, nil, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create slim trie")
+	}
+
+	err = st.Unmarshal(kvsnap.KeyIndex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal slim trie")
+	}
+
+	return &kvSnapshot{
+		index:  st,
+		values: kvsnap.Values,
+	}, nil
+}
+
+// get returns the value of key, or nil if key is not in the snapshot.
+func (ks *kvSnapshot) get(key string) *Cmd {
+	idx, found := ks.index.Get(key)
+	if !found {
+		return nil
+	}
+	return ks.values[idx.(uint32)]
+}
+
+// get returns the value of key, or nil if key is never set.
+// It must be called with mu held.
+func (s *kvState) get(key string) *Cmd {
+	if v, ok := s.kvs[key]; ok {
+		return v
+	}
+	if s.base != nil {
+		return s.base.get(key)
+	}
+	return nil
+}
+
+// all returns all key-values, including those in the restored snapshot.
+// It must be called with mu held.
+func (s *kvState) all() map[string]*Cmd {
+	kvs := map[string]*Cmd{}
+	if s.base != nil {
+		// a value is a `set` Cmd and has the key in it.
+		for _, v := range s.base.values {
+			kvs[v.Key] = v
+		}
+	}
+	for k, v := range s.kvs {
+		kvs[k] = v
+	}
+	return kvs
+}
+
+// Apply a log record to the state.
+// Only `set` is supported for now. It returns the previous `set` Cmd of the key.
+func (s *kvState) Apply(r *Record) []byte {
+	if r.Empty() {
+		return nil
+	}
+
+	if r.Cmd.Op != "set" {
+		return nil
+	}
+
+	s.mu.Lock()
+	prev := s.get(r.Cmd.Key)
+	s.kvs[r.Cmd.Key] = r.Cmd
+	s.mu.Unlock()
+
+	if prev == nil {
+		return nil
+	}
+
+	b, err := prev.Marshal()
+	if err != nil {
+		lg.Panicw("fail to marshal Cmd", "cmd", prev, "err", err)
+	}
+	return b
+}
+
+// Snapshot builds a KVSnapshot with a slim trie as key index.
+func (s *kvState) Snapshot() ([]byte, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.all()
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	idxs := make([]uint32, len(keys))
+	vals := make([]*Cmd, len(keys))
+	for i, k := range keys {
+		idxs[i] = uint32(i)
+		vals[i] = all[k]
+	}
+
+	st, err := trie.NewSlimTrie(encode.U32{}, keys, idxs,
+		trie.Opt{Complete: trie.Bool(true)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "build slim trie")
+	}
+
+	index, err := st.Marshal()
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal slim trie")
+	}
+
+	kvsnap := &KVSnapshot{
+		KeyIndex: index,
+		Values:   vals,
+	}
+	return kvsnap.Marshal()
+}
+
+// Restore replaces the state with a snapshot. Values are read from the
+// snapshot when they are looked up.
+func (s *kvState) Restore(data []byte) error {
+
+	base, err := loadKVSnapshot(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.base = base
+	s.kvs = map[string]*Cmd{}
+	s.mu.Unlock()
+
+	return nil
+}
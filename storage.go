@@ -0,0 +1,213 @@
+package traft
+
+// LogStorage stores log records of a replica.
+//
+// A storage holds a continuous range of lsn: [FirstIndex(), LastIndex()].
+// An absent record in this range is an empty Record, see Record.Empty().
+type LogStorage interface {
+	// Append writes records at their Seq.
+	// An existent record with the same Seq is overridden.
+	// The storage is extended with empty records if a Seq is beyond
+	// LastIndex()+1.
+	// Records with Seq less than FirstIndex() are ignored.
+	Append(recs ...*Record) error
+
+	// Get returns the record at lsn.
+	// It returns nil if lsn is not in [FirstIndex(), LastIndex()].
+	Get(lsn int64) *Record
+
+	// Range returns records in [start, end) that are in the storage.
+	Range(start, end int64) []*Record
+
+	// Truncate removes records at and after lsn.
+	Truncate(lsn int64) error
+
+	// Reclaim removes records before lsn, e.g., they are no longer needed
+	// because a snapshot has been made.
+	Reclaim(lsn int64) error
+
+	// FirstIndex returns the lsn of the first record.
+	FirstIndex() int64
+
+	// LastIndex returns the lsn of the last record.
+	// It returns FirstIndex()-1 if the storage is empty.
+	LastIndex() int64
+
+	// SaveCommitted stores the Committed bitmap of this replica.
+	SaveCommitted(committed *TailBitmap) error
+
+	// Committed returns the last saved Committed bitmap.
+	Committed() *TailBitmap
+
+	// Sync makes all writes durable.
+	Sync() error
+
+	Close() error
+}
+
+// MemStorage is a LogStorage in memory. Nothing survives a restart.
+type MemStorage struct {
+	offset    int64
+	logs      []*Record
+	committed *TailBitmap
+}
+
+// NewMemStorage creates an empty MemStorage whose first lsn is offset.
+func NewMemStorage(offset int64) *MemStorage {
+	return &MemStorage{
+		offset:    offset,
+		logs:      make([]*Record, 0),
+		committed: NewTailBitmap(0),
+	}
+}
+
+func (s *MemStorage) Append(recs ...*Record) error {
+	for _, r := range recs {
+		if r.Seq < s.offset {
+			continue
+		}
+
+		idx := r.Seq - s.offset
+		for int(idx) >= len(s.logs) {
+			s.logs = append(s.logs, &Record{Seq: s.offset + int64(len(s.logs))})
+		}
+		s.logs[idx] = r
+	}
+	return nil
+}
+
+func (s *MemStorage) Get(lsn int64) *Record {
+	idx := lsn - s.offset
+	if idx < 0 || int(idx) >= len(s.logs) {
+		return nil
+	}
+	return s.logs[idx]
+}
+
+func (s *MemStorage) Range(start, end int64) []*Record {
+	if start < s.offset {
+		start = s.offset
+	}
+	if end > s.offset+int64(len(s.logs)) {
+		end = s.offset + int64(len(s.logs))
+	}
+
+	rst := make([]*Record, 0)
+	for i := start; i < end; i++ {
+		rst = append(rst, s.logs[i-s.offset])
+	}
+	return rst
+}
+
+func (s *MemStorage) Truncate(lsn int64) error {
+	if lsn < s.offset {
+		lsn = s.offset
+	}
+	idx := lsn - s.offset
+	if int(idx) < len(s.logs) {
+		s.logs = s.logs[:idx]
+	}
+	return nil
+}
+
+func (s *MemStorage) Reclaim(lsn int64) error {
+	if lsn <= s.offset {
+		return nil
+	}
+
+	idx := lsn - s.offset
+	if int(idx) >= len(s.logs) {
+		s.logs = make([]*Record, 0)
+	} else {
+		logs := make([]*Record, len(s.logs)-int(idx))
+		copy(logs, s.logs[idx:])
+		s.logs = logs
+	}
+	s.offset = lsn
+	return nil
+}
+
+func (s *MemStorage) FirstIndex() int64 {
+	return s.offset
+}
+
+func (s *MemStorage) LastIndex() int64 {
+	return s.offset + int64(len(s.logs)) - 1
+}
+
+func (s *MemStorage) SaveCommitted(committed *TailBitmap) error {
+	s.committed = committed.Clone()
+	return nil
+}
+
+func (s *MemStorage) Committed() *TailBitmap {
+	return s.committed.Clone()
+}
+
+func (s *MemStorage) Sync() error  { return nil }
+func (s *MemStorage) Close() error { return nil }
+
+// allLogs returns all records in log storage.
+func (tr *TRaft) allLogs() []*Record {
+	return tr.logs.Range(tr.logs.FirstIndex(), tr.logs.LastIndex()+1)
+}
+
+// loadLogStatus rebuilds the local Accepted and Committed from log storage.
+func (tr *TRaft) loadLogStatus() {
+	me := tr.Status[tr.Id]
+
+	for _, r := range tr.allLogs() {
+		if r.Empty() {
+			continue
+		}
+		me.Accepted.Union(r.Overrides)
+		me.Accepted.Set(r.Seq)
+	}
+
+	committed := tr.logs.Committed()
+	me.Committed.Union(committed)
+	me.Accepted.Union(committed)
+
+	lg.Infow("load-log-status",
+		"Id", tr.Id,
+		"FirstIndex", tr.logs.FirstIndex(),
+		"LastIndex", tr.logs.LastIndex(),
+		"Accepted", me.Accepted.ShortStr(),
+		"Committed", me.Committed.ShortStr(),
+	)
+}
+
+// appendLogs writes records to log storage.
+// A replica must not go on if it fails to persist its state.
+func (tr *TRaft) appendLogs(recs ...*Record) {
+	err := tr.logs.Append(recs...)
+	if err != nil {
+		lg.Panicw("fail to append logs", "err", err)
+	}
+	tr.mayChangeConfig(recs)
+}
+
+func (tr *TRaft) truncateLogs(lsn int64) {
+	err := tr.logs.Truncate(lsn)
+	if err != nil {
+		lg.Panicw("fail to truncate logs", "lsn", lsn, "err", err)
+	}
+	if tr.configLsn >= lsn {
+		tr.loadConfig()
+	}
+}
+
+func (tr *TRaft) saveCommitted() {
+	err := tr.logs.SaveCommitted(tr.Status[tr.Id].Committed)
+	if err != nil {
+		lg.Panicw("fail to save committed", "err", err)
+	}
+}
+
+// syncLogs must be called before telling others what has been written.
+func (tr *TRaft) syncLogs() {
+	err := tr.logs.Sync()
+	if err != nil {
+		lg.Panicw("fail to sync logs", "err", err)
+	}
+}
@@ -0,0 +1,78 @@
+package traft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testLogStorage checks the behavior every LogStorage must have.
+func testLogStorage(t *testing.T, s LogStorage) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	rec := func(lsn int64) *Record {
+		return NewRecord(lid(1, 1), lsn, NewCmdI64("set", "x", lsn))
+	}
+
+	ta.Equal(int64(0), s.FirstIndex())
+	ta.Equal(int64(-1), s.LastIndex())
+	ta.Nil(s.Get(0))
+	ta.Equal([]*Record{}, s.Range(0, 10))
+
+	ta.Nil(s.Append(rec(0), rec(1)))
+	ta.Equal(int64(1), s.LastIndex())
+	ta.Equal("<001#001:000{set(x, 0)}-0→0>", s.Get(0).ShortStr())
+
+	// extend with empty records
+	ta.Nil(s.Append(rec(4)))
+	ta.Equal(int64(4), s.LastIndex())
+	ta.True(s.Get(2).Empty())
+	ta.Equal(int64(3), s.Get(3).Seq)
+	ta.Equal("[<001#001:001{set(x, 1)}-0→0>, <>, <>]", RecordsShortStr(s.Range(1, 4)))
+
+	// override
+	ta.Nil(s.Append(&Record{Seq: 1}))
+	ta.True(s.Get(1).Empty())
+
+	ta.Nil(s.Truncate(3))
+	ta.Equal(int64(2), s.LastIndex())
+	ta.Nil(s.Get(3))
+
+	ta.Nil(s.Reclaim(1))
+	ta.Equal(int64(1), s.FirstIndex())
+	ta.Equal(int64(2), s.LastIndex())
+	ta.Nil(s.Get(0))
+
+	// reclaimed record is ignored
+	ta.Nil(s.Append(rec(0)))
+	ta.Nil(s.Get(0))
+
+	// reclaim beyond the last
+	ta.Nil(s.Reclaim(5))
+	ta.Equal(int64(5), s.FirstIndex())
+	ta.Equal(int64(4), s.LastIndex())
+
+	ta.Nil(s.SaveCommitted(NewTailBitmap(0, 1, 2)))
+	ta.Equal(NewTailBitmap(0, 1, 2), s.Committed())
+
+	ta.Nil(s.Sync())
+}
+
+func TestMemStorage(t *testing.T) {
+	testLogStorage(t, NewMemStorage(0))
+}
+
+func TestMemStorage_offset(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := NewMemStorage(10)
+	ta.Equal(int64(10), s.FirstIndex())
+	ta.Equal(int64(9), s.LastIndex())
+
+	ta.Nil(s.Append(NewRecord(NewLeaderId(1, 1), 11, NewCmdI64("set", "x", 1))))
+	ta.Equal(int64(11), s.LastIndex())
+	ta.True(s.Get(10).Empty())
+}
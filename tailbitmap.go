@@ -232,6 +232,65 @@ func (tb *TailBitmap) Diff(tc *TailBitmap) {
 	}
 }
 
+// Includes returns true if every bit set in tc is also set in tb.
+func (tb *TailBitmap) Includes(tc *TailBitmap) bool {
+
+	if tc == nil {
+		return true
+	}
+
+	for i := tb.Offset; i < tc.Len(); i++ {
+		if tc.Get(i) != 0 && tb.Get(i) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersection sets tb to tb ∩ tc. A nil tc is an empty set.
+func (tb *TailBitmap) Intersection(tc *TailBitmap) {
+
+	if tc == nil {
+		tc = NewTailBitmap(0)
+	}
+
+	// bits before the less Offset are all set in both.
+	offset := tb.Offset
+	if tc.Offset < offset {
+		offset = tc.Offset
+	}
+
+	lb := tb.Offset + int64(len(tb.Words)*64)
+	lc := tc.Offset + int64(len(tc.Words)*64)
+	end := lb
+	if lc < end {
+		end = lc
+	}
+
+	words := make([]uint64, 0, (end-offset)>>6)
+	for p := offset; p < end; p += 64 {
+		words = append(words, tb.wordAt(p)&tc.wordAt(p))
+	}
+
+	tb.Offset = offset
+	tb.Words = words
+	tb.Reclamed = offset
+	tb.Compact()
+}
+
+// wordAt returns the word starting at 64-aligned bit position p.
+func (tb *TailBitmap) wordAt(p int64) uint64 {
+	if p < tb.Offset {
+		return 0xffffffffffffffff
+	}
+
+	i := (p - tb.Offset) >> 6
+	if int(i) >= len(tb.Words) {
+		return 0
+	}
+	return tb.Words[i]
+}
+
 // Last returns last set bit index + 1.
 func (tb *TailBitmap) Len() int64 {
 
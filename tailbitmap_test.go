@@ -539,3 +539,64 @@ func TestTailBitmap_Len(t *testing.T) {
 		ta.Equal(c.want, got, "%d-th: Get case: %+v", i+1, c)
 	}
 }
+
+func TestTailBitmap_Includes(t *testing.T) {
+
+	ta := require.New(t)
+
+	bm := NewTailBitmap
+
+	cases := []struct {
+		a, b *TailBitmap
+		want bool
+	}{
+		{bm(0), nil, true},
+		{bm(0), bm(0), true},
+		{bm(0), bm(0, 1), false},
+		{bm(0, 1), bm(0, 1), true},
+		{bm(0, 1, 2), bm(0, 1), true},
+		{bm(64), bm(0, 1, 63), true},
+		{bm(64), bm(64), true},
+		{bm(64), bm(65), false},
+		{bm(0, 1, 2), bm(3), false},
+		{bm(128, 130), bm(64, 65, 130), true},
+		{bm(128, 130), bm(64, 65, 131), false},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, c.a.Includes(c.b), "%d-th: case: %+v", i+1, c)
+	}
+}
+
+func TestTailBitmap_Intersection(t *testing.T) {
+
+	ta := require.New(t)
+
+	bm := NewTailBitmap
+
+	cases := []struct {
+		a, b *TailBitmap
+		want *TailBitmap
+	}{
+		{bm(0), nil, bm(0)},
+		{bm(64), nil, bm(0)},
+		{bm(0), bm(0), bm(0)},
+		{bm(0, 1), bm(0, 1), bm(0, 1)},
+		{bm(0, 1, 2), bm(0, 2, 3), bm(0, 2)},
+		{bm(64), bm(0, 1, 63), bm(0, 1, 63)},
+		{bm(0, 1, 63), bm(64), bm(0, 1, 63)},
+		{bm(64, 65, 130), bm(128, 129, 130), bm(64, 65, 130)},
+		{bm(128), bm(64, 100, 130), bm(64, 100)},
+		{bm(128, 200), bm(192, 200), bm(128, 200)},
+		{bm(0, 1, 200), bm(0, 200), bm(0, 200)},
+		{bm(0, 65), bm(0, 1), bm(0)},
+	}
+
+	for i, c := range cases {
+		got := c.a.Clone()
+		got.Intersection(c.b)
+		// trailing zero words do not matter
+		ta.True(got.Includes(c.want) && c.want.Includes(got),
+			"%d-th: case: %+v, got: %s", i+1, c, got.DebugStr())
+	}
+}
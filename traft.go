@@ -4,6 +4,7 @@ package traft
 
 import (
 	"fmt"
+	"math/rand"
 	"net"
 	"sort"
 	"strings"
@@ -30,6 +31,77 @@ type TRaft struct {
 	MsgCh chan string
 
 	grpcServer *grpc.Server
+	listener   net.Listener
+
+	// sends requests to other replicas.
+	transport Transport
+
+	// where time comes from.
+	clock Clock
+
+	// randomness source. Only Loop() uses it.
+	rnd *rand.Rand
+
+	// dir to store persistent data. Empty dir means in-memory only.
+	dir string
+
+	// where log records are stored.
+	logs LogStorage
+
+	// the latest snapshot. Logs before snapshot.Offset are reclaimed.
+	snapshot *Snapshot
+
+	// the last persisted hard state.
+	hardState *HardState
+
+	// snapshot being received from leader.
+	snapshotRecv *snapshotRecv
+
+	// followers a snapshot is being sent to.
+	sendingSnapshot map[int64]bool
+
+	// where committed logs are applied.
+	// Only ApplyLoop() calls it.
+	sm StateMachine
+
+	// notifies ApplyLoop() there may be logs to apply.
+	applyCh chan struct{}
+
+	// generation of the state machine.
+	// It increments when the state machine is replaced by a snapshot from
+	// leader. Logs applied to a former generation are discarded.
+	applyGen int64
+
+	// a snapshot from leader the state machine has not yet restored from.
+	snapshotToRestore *Snapshot
+
+	// replicators of the current leadership, indexed by follower id.
+	replicators map[int64]*replicator
+
+	// runs ReplicateLoop() of a replicator in a goroutine.
+	// The simulator in test replaces it to drive replicators by itself.
+	runReplicator func(rp *replicator)
+
+	// proposers waiting for their logs to be committed or applied, indexed by
+	// lsn.
+	proposing map[int64]*proposal
+
+	// leadership transfer in progress, proposals are refused until it
+	// finishes.
+	transferring *leadershipTransfer
+
+	// the leader asks VoteLoop() to start an election at once.
+	electCh chan struct{}
+
+	// the config before all logs: the one in snapshot or the initial one.
+	baseConfig *ClusterConfig
+
+	// lsn of the log the current config comes from, or -1 if it is
+	// baseConfig.
+	configLsn int64
+
+	// set quorums of the initial config, specified by WithQuorums etc.
+	quorumOpts []func(*ClusterConfig) error
 
 	wg sync.WaitGroup
 
@@ -40,7 +112,113 @@ func init() {
 	initLogging()
 }
 
-func NewTRaft(id int64, idAddrs map[int64]string) *TRaft {
+// Option configures a TRaft when it is created.
+type Option func(*TRaft)
+
+// WithDir specifies the dir to store WAL, hard state and snapshot.
+// Data written in dir are loaded when TRaft is created.
+// If no LogStorage is specified, a FileStorage in dir is used.
+func WithDir(dir string) Option {
+	return func(tr *TRaft) {
+		tr.dir = dir
+	}
+}
+
+// WithStorage specifies the LogStorage to store log records.
+// By default it is a MemStorage, or a FileStorage if WithDir is specified.
+func WithStorage(s LogStorage) Option {
+	return func(tr *TRaft) {
+		tr.logs = s
+	}
+}
+
+// WithStateMachine specifies where committed logs are applied.
+// By default it is a key-value map.
+func WithStateMachine(sm StateMachine) Option {
+	return func(tr *TRaft) {
+		tr.sm = sm
+	}
+}
+
+// WithTransport specifies how requests are sent to other replicas.
+// By default it is gRPC.
+func WithTransport(t Transport) Option {
+	return func(tr *TRaft) {
+		tr.transport = t
+	}
+}
+
+// WithClock specifies where time comes from.
+// By default it is the wall clock.
+func WithClock(c Clock) Option {
+	return func(tr *TRaft) {
+		tr.clock = c
+	}
+}
+
+// WithQuorums specifies how to build the quorums of the initial cluster config,
+// e.g., with ClusterConfig.HierarchicalQuorums.
+// Every replica must be started with the same quorums.
+// By default a quorum is a majority of members.
+func WithQuorums(build func(*ClusterConfig) ([]uint64, error)) Option {
+	return func(tr *TRaft) {
+		tr.quorumOpts = append(tr.quorumOpts, func(conf *ClusterConfig) error {
+			qs, err := build(conf)
+			conf.Quorums, conf.QuorumRule = qs, nil
+			return err
+		})
+	}
+}
+
+// WithVoteQuorums specifies how to build the quorums to elect a leader in the
+// initial cluster config. Every one of them must intersect every quorum
+// specified by WithQuorums.
+// By default they are the same as the quorums to commit a log.
+func WithVoteQuorums(build func(*ClusterConfig) ([]uint64, error)) Option {
+	return func(tr *TRaft) {
+		tr.quorumOpts = append(tr.quorumOpts, func(conf *ClusterConfig) error {
+			qs, err := build(conf)
+			conf.VoteQuorums, conf.VoteQuorumRule = qs, nil
+			return err
+		})
+	}
+}
+
+// WithQuorumRule is the same as WithQuorums except that quorums are described
+// by a QuorumRule, e.g., for a cluster of more than 64 members.
+// By default a cluster of more than 9 members uses ClusterConfig.MajorityRule.
+func WithQuorumRule(build func(*ClusterConfig) (*QuorumRule, error)) Option {
+	return func(tr *TRaft) {
+		tr.quorumOpts = append(tr.quorumOpts, func(conf *ClusterConfig) error {
+			r, err := build(conf)
+			conf.Quorums, conf.QuorumRule = nil, r
+			return err
+		})
+	}
+}
+
+// WithVoteQuorumRule is the same as WithVoteQuorums except that quorums are
+// described by a QuorumRule.
+func WithVoteQuorumRule(build func(*ClusterConfig) (*QuorumRule, error)) Option {
+	return func(tr *TRaft) {
+		tr.quorumOpts = append(tr.quorumOpts, func(conf *ClusterConfig) error {
+			r, err := build(conf)
+			conf.VoteQuorums, conf.VoteQuorumRule = nil, r
+			return err
+		})
+	}
+}
+
+// WithRand specifies the randomness source, e.g., to replay a test with the
+// same seed.
+// By default it is seeded with the current time.
+func WithRand(r *rand.Rand) Option {
+	return func(tr *TRaft) {
+		tr.rnd = r
+	}
+}
+
+func NewTRaft(id int64, idAddrs map[int64]string, opts ...Option) *TRaft {
 	_, ok := idAddrs[id]
 	if !ok {
 		panic("my id is not in cluster")
@@ -68,19 +246,11 @@ func NewTRaft(id int64, idAddrs map[int64]string) *TRaft {
 	conf := &ClusterConfig{
 		Members: members,
 	}
-	maxPos := conf.MaxPosition()
-	conf.Quorums = buildMajorityQuorums(1<<uint(maxPos+1) - 1)
-
-	progs := make(map[int64]*ReplicaStatus, 0)
-	for _, m := range members {
-		progs[m.Id] = emptyProgress(m.Id)
-	}
+	conf.setMajorityQuorums()
 
 	node := &Node{
-		Config: conf,
-		Logs:   make([]*Record, 0),
 		Id:     id,
-		Status: progs,
+		Status: map[int64]*ReplicaStatus{},
 	}
 
 	// TODO buffer size
@@ -95,6 +265,72 @@ func NewTRaft(id int64, idAddrs map[int64]string) *TRaft {
 		grpcServer: nil,
 		wg:         sync.WaitGroup{},
 		Node:       *node,
+
+		sendingSnapshot: map[int64]bool{},
+		sm:              newKVState(),
+		applyCh:         make(chan struct{}, 1),
+		proposing:       map[int64]*proposal{},
+		electCh:         make(chan struct{}, 1),
+		baseConfig:      conf,
+		configLsn:       -1,
+	}
+
+	for _, o := range opts {
+		o(tr)
+	}
+
+	if tr.transport == nil {
+		tr.transport = newGRPCTransport()
+	}
+
+	if tr.clock == nil {
+		tr.clock = realClock{}
+	}
+
+	if tr.rnd == nil {
+		tr.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	tr.runReplicator = func(rp *replicator) {
+		tr.goit(func() { tr.ReplicateLoop(rp) })
+	}
+
+	if len(tr.quorumOpts) > 0 {
+		err := tr.buildInitialQuorums(conf)
+		if err != nil {
+			lg.Fatalw("Fail to build quorums", "err", err)
+		}
+	}
+
+	tr.setConfig(conf.Clone())
+
+	if tr.logs == nil {
+		if tr.dir != "" {
+			s, err := OpenFileStorage(tr.dir)
+			if err != nil {
+				lg.Fatalw("Fail to open FileStorage", "dir", tr.dir, "err", err)
+			}
+			tr.logs = s
+		} else {
+			tr.logs = NewMemStorage(0)
+		}
+	}
+
+	if tr.dir != "" {
+		err := tr.loadSnapshotToMe()
+		if err != nil {
+			lg.Fatalw("Fail to load snapshot", "dir", tr.dir, "err", err)
+		}
+	}
+
+	tr.loadLogStatus()
+	tr.loadConfig()
+
+	if tr.dir != "" {
+		err := tr.loadHardStateToMe()
+		if err != nil {
+			lg.Fatalw("Fail to load hard state", "dir", tr.dir, "err", err)
+		}
 	}
 
 	{
@@ -124,6 +360,8 @@ func (tr *TRaft) StartServer() {
 		lg.Fatalw("Fail to listen:", "addr", addr, "err", err)
 	}
 
+	tr.listener = lis
+
 	go tr.grpcServer.Serve(lis)
 	lg.Infow("grpc started", "addr", addr)
 }
@@ -138,6 +376,7 @@ func (tr *TRaft) goit(f func()) {
 
 func (tr *TRaft) StartMainLoop() {
 	tr.goit(tr.Loop)
+	tr.goit(tr.ApplyLoop)
 	lg.Infow("Started Loop")
 }
 
@@ -148,11 +387,18 @@ func (tr *TRaft) StartVoteLoop() {
 
 func (tr *TRaft) Stop() {
 	id := tr.Id
-	addr := tr.Config.Members[id].Addr
+	// this replica may have been removed from the cluster.
+	addr := tr.Config.Members[id].GetAddr()
 	lg.Infow("Stopping grpc: ", "addr:", addr)
 	// tr.grpcServer.Stop() does not wait.
 	tr.grpcServer.GracefulStop()
 
+	// GracefulStop does not close a listener that Serve() has not yet picked
+	// up. Close it explicitly so that the port is released at once.
+	if tr.listener != nil {
+		tr.listener.Close()
+	}
+
 	if !tr.running {
 		lg.Infow("TRaft already stopped")
 		return
@@ -164,13 +410,20 @@ func (tr *TRaft) Stop() {
 
 	tr.wg.Wait()
 
+	tr.transport.Close()
+
+	err := tr.logs.Close()
+	if err != nil {
+		lg.Infow("fail to close log storage", "err", err)
+	}
+
 	lg.Infow("TRaft stopped")
 }
 
 // stoppable sleep, if tr.Stop() has been called, it returns at once
 func (tr *TRaft) sleep(t time.Duration) {
 	select {
-	case <-time.After(t):
+	case <-tr.clock.After(t):
 	case <-tr.shutdown:
 	}
 }
@@ -218,6 +471,36 @@ func (tr *TRaft) sendMsg(msg ...interface{}) {
 	}
 }
 
+// setConfig replaces the cluster config.
+// It creates status for new members and closes connections to members that are
+// removed or have a new address.
+// It must be called from Loop(), or before Loop() starts.
+func (tr *TRaft) setConfig(conf *ClusterConfig) {
+	tr.Config = conf
+
+	for id := range conf.Members {
+		if _, ok := tr.Status[id]; !ok {
+			tr.Status[id] = emptyProgress(id)
+		}
+	}
+
+	tr.transport.UpdateConfig(conf)
+	tr.updateReplicators()
+}
+
+// buildInitialQuorums sets quorums of the initial config with what WithQuorums
+// etc. specify.
+func (tr *TRaft) buildInitialQuorums(conf *ClusterConfig) error {
+	for _, set := range tr.quorumOpts {
+		err := set(conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return conf.checkQuorums()
+}
+
 func emptyProgress(id int64) *ReplicaStatus {
 	return &ReplicaStatus{
 		// initially it votes for itself with term 0
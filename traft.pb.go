@@ -4,6 +4,7 @@
 package traft
 
 import (
+	bytes "bytes"
 	context "context"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
@@ -150,16 +151,15 @@ func (*Cmd) XXX_OneofWrappers() []interface{} {
 //
 // The data structure is as the following described:
 //
-//                      reclaimed
-//                      |
-//                      |     Offset
-//                      |     |
-//                      v     v
-//                ..... X ... 01010...00111  00...
-//   bitIndex:    0123...     ^              ^
-//                            |              |
-//                            Words[0]       Words[1]
-//
+//	                   reclaimed
+//	                   |
+//	                   |     Offset
+//	                   |     |
+//	                   v     v
+//	             ..... X ... 01010...00111  00...
+//	bitIndex:    0123...     ^              ^
+//	                         |              |
+//	                         Words[0]       Words[1]
 type TailBitmap struct {
 	Offset   int64    `protobuf:"varint,1,opt,name=Offset,proto3" json:"Offset,omitempty"`
 	Words    []uint64 `protobuf:"varint,2,rep,packed,name=Words,proto3" json:"Words,omitempty"`
@@ -368,9 +368,6 @@ type Node struct {
 	// replica id of this replica.
 	Id     int64          `protobuf:"varint,3,opt,name=Id,proto3" json:"Id,omitempty"`
 	Config *ClusterConfig `protobuf:"bytes,1,opt,name=Config,proto3" json:"Config,omitempty"`
-	// From which log seq number we keeps here.
-	LogOffset int64     `protobuf:"varint,4,opt,name=LogOffset,proto3" json:"LogOffset,omitempty"`
-	Logs      []*Record `protobuf:"bytes,2,rep,name=Logs,proto3" json:"Logs,omitempty"`
 	// local view of every replica, including this node too.
 	Status map[int64]*ReplicaStatus `protobuf:"bytes,6,rep,name=Status,proto3" json:"Status,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
@@ -422,20 +419,6 @@ func (m *Node) GetConfig() *ClusterConfig {
 	return nil
 }
 
-func (m *Node) GetLogOffset() int64 {
-	if m != nil {
-		return m.LogOffset
-	}
-	return 0
-}
-
-func (m *Node) GetLogs() []*Record {
-	if m != nil {
-		return m.Logs
-	}
-	return nil
-}
-
 func (m *Node) GetStatus() map[int64]*ReplicaStatus {
 	if m != nil {
 		return m.Status
@@ -657,6 +640,200 @@ func (m *ReplicaStatus) GetApplied() *TailBitmap {
 	return nil
 }
 
+// HardState is the part of ReplicaStatus that must survive a restart.
+// A replica persists it before responding a vote or accepting logs from a new
+// Committer, so that it never votes twice for different leaders with the same
+// LeaderId.
+type HardState struct {
+	VotedFor     *LeaderId `protobuf:"bytes,10,opt,name=VotedFor,proto3" json:"VotedFor,omitempty"`
+	VoteExpireAt int64     `protobuf:"varint,11,opt,name=VoteExpireAt,proto3" json:"VoteExpireAt,omitempty"`
+	Committer    *LeaderId `protobuf:"bytes,4,opt,name=Committer,proto3" json:"Committer,omitempty"`
+}
+
+func (m *HardState) Reset()         { *m = HardState{} }
+func (m *HardState) String() string { return proto.CompactTextString(m) }
+func (*HardState) ProtoMessage()    {}
+func (*HardState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{8}
+}
+func (m *HardState) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HardState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HardState.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HardState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HardState.Merge(m, src)
+}
+func (m *HardState) XXX_Size() int {
+	return m.Size()
+}
+func (m *HardState) XXX_DiscardUnknown() {
+	xxx_messageInfo_HardState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HardState proto.InternalMessageInfo
+
+func (m *HardState) GetVotedFor() *LeaderId {
+	if m != nil {
+		return m.VotedFor
+	}
+	return nil
+}
+
+func (m *HardState) GetVoteExpireAt() int64 {
+	if m != nil {
+		return m.VoteExpireAt
+	}
+	return 0
+}
+
+func (m *HardState) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
+	}
+	return nil
+}
+
+// Snapshot is the state built by applying all logs before Offset.
+type Snapshot struct {
+	// All logs before Offset are applied to build this snapshot.
+	Offset int64 `protobuf:"varint,1,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	// serialized state.
+	Data []byte `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	// Logs applied to build this snapshot, including all before Offset and
+	// some after it.
+	Applied *TailBitmap `protobuf:"bytes,3,opt,name=Applied,proto3" json:"Applied,omitempty"`
+	// the cluster config in the last config log before Offset.
+	Config *ClusterConfig `protobuf:"bytes,4,opt,name=Config,proto3" json:"Config,omitempty"`
+}
+
+func (m *Snapshot) Reset()         { *m = Snapshot{} }
+func (m *Snapshot) String() string { return proto.CompactTextString(m) }
+func (*Snapshot) ProtoMessage()    {}
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{9}
+}
+func (m *Snapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Snapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Snapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Snapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Snapshot.Merge(m, src)
+}
+func (m *Snapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *Snapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_Snapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Snapshot proto.InternalMessageInfo
+
+func (m *Snapshot) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *Snapshot) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Snapshot) GetApplied() *TailBitmap {
+	if m != nil {
+		return m.Applied
+	}
+	return nil
+}
+
+func (m *Snapshot) GetConfig() *ClusterConfig {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+// KVSnapshot is the serialized state of a key-value map built from `set`
+// commands.
+// Keys are indexed by a slim trie(https://github.com/openacid/slim),
+// and the value of a key is the Cmd at the index the trie gives.
+type KVSnapshot struct {
+	KeyIndex []byte `protobuf:"bytes,1,opt,name=KeyIndex,proto3" json:"KeyIndex,omitempty"`
+	Values   []*Cmd `protobuf:"bytes,2,rep,name=Values,proto3" json:"Values,omitempty"`
+}
+
+func (m *KVSnapshot) Reset()         { *m = KVSnapshot{} }
+func (m *KVSnapshot) String() string { return proto.CompactTextString(m) }
+func (*KVSnapshot) ProtoMessage()    {}
+func (*KVSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{10}
+}
+func (m *KVSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *KVSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_KVSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *KVSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVSnapshot.Merge(m, src)
+}
+func (m *KVSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *KVSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KVSnapshot proto.InternalMessageInfo
+
+func (m *KVSnapshot) GetKeyIndex() []byte {
+	if m != nil {
+		return m.KeyIndex
+	}
+	return nil
+}
+
+func (m *KVSnapshot) GetValues() []*Cmd {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 type ReplicaInfo struct {
 	Id   int64  `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
 	Addr string `protobuf:"bytes,2,opt,name=Addr,proto3" json:"Addr,omitempty"`
@@ -668,7 +845,7 @@ func (m *ReplicaInfo) Reset()         { *m = ReplicaInfo{} }
 func (m *ReplicaInfo) String() string { return proto.CompactTextString(m) }
 func (*ReplicaInfo) ProtoMessage()    {}
 func (*ReplicaInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{8}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{11}
 }
 func (m *ReplicaInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -718,16 +895,101 @@ func (m *ReplicaInfo) GetPosition() int64 {
 	return 0
 }
 
+// QuorumRule describes quorums by counting votes, instead of listing them.
+// A set of members is a quorum if the votes of its members, plus one vote for
+// each of Groups it is a quorum of, are at least Threshold.
+//
+// E.g., a majority of 5 members is {Votes: {0:1, 1:1, 2:1, 3:1, 4:1},
+// Threshold: 3}, and a majority in each of 2 groups is {Groups: [g1, g2],
+// Threshold: 2}.
+type QuorumRule struct {
+	// Votes of members, indexed by Position. A member without vote, e.g., a
+	// learner, is not in it.
+	Votes     map[int64]int64 `protobuf:"bytes,1,rep,name=Votes,proto3" json:"Votes,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Groups    []*QuorumRule   `protobuf:"bytes,2,rep,name=Groups,proto3" json:"Groups,omitempty"`
+	Threshold int64           `protobuf:"varint,3,opt,name=Threshold,proto3" json:"Threshold,omitempty"`
+}
+
+func (m *QuorumRule) Reset()         { *m = QuorumRule{} }
+func (m *QuorumRule) String() string { return proto.CompactTextString(m) }
+func (*QuorumRule) ProtoMessage()    {}
+func (*QuorumRule) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{12}
+}
+func (m *QuorumRule) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuorumRule) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuorumRule.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuorumRule) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuorumRule.Merge(m, src)
+}
+func (m *QuorumRule) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuorumRule) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuorumRule.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuorumRule proto.InternalMessageInfo
+
+func (m *QuorumRule) GetVotes() map[int64]int64 {
+	if m != nil {
+		return m.Votes
+	}
+	return nil
+}
+
+func (m *QuorumRule) GetGroups() []*QuorumRule {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+func (m *QuorumRule) GetThreshold() int64 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
 type ClusterConfig struct {
 	Members map[int64]*ReplicaInfo `protobuf:"bytes,11,rep,name=Members,proto3" json:"Members,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	Quorums []uint64               `protobuf:"varint,21,rep,packed,name=Quorums,proto3" json:"Quorums,omitempty"`
+	// Quorums lists every quorum as a bitmap of positions. Thus only members
+	// at position 0 to 63 can be in a quorum.
+	Quorums []uint64 `protobuf:"varint,21,rep,packed,name=Quorums,proto3" json:"Quorums,omitempty"`
+	// VoteQuorums are quorums to elect a leader, while Quorums are quorums to
+	// commit a log, as in Flexible Paxos. Every vote quorum must intersect
+	// every quorum.
+	// If it is empty, Quorums are used to elect too.
+	VoteQuorums []uint64 `protobuf:"varint,22,rep,packed,name=VoteQuorums,proto3" json:"VoteQuorums,omitempty"`
+	// QuorumRule and VoteQuorumRule are used instead of Quorums and
+	// VoteQuorums, e.g., in a cluster of more than 64 members, where listing
+	// every quorum is impossible.
+	QuorumRule     *QuorumRule `protobuf:"bytes,23,opt,name=QuorumRule,proto3" json:"QuorumRule,omitempty"`
+	VoteQuorumRule *QuorumRule `protobuf:"bytes,24,opt,name=VoteQuorumRule,proto3" json:"VoteQuorumRule,omitempty"`
+	// Next is the config to change to, if this is a joint config.
+	// A joint config has members of both the former config and Next, and
+	// each of its quorums is a quorum of both.
+	Next *ClusterConfig `protobuf:"bytes,31,opt,name=Next,proto3" json:"Next,omitempty"`
 }
 
 func (m *ClusterConfig) Reset()         { *m = ClusterConfig{} }
 func (m *ClusterConfig) String() string { return proto.CompactTextString(m) }
 func (*ClusterConfig) ProtoMessage()    {}
 func (*ClusterConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{9}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{13}
 }
 func (m *ClusterConfig) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -770,6 +1032,34 @@ func (m *ClusterConfig) GetQuorums() []uint64 {
 	return nil
 }
 
+func (m *ClusterConfig) GetVoteQuorums() []uint64 {
+	if m != nil {
+		return m.VoteQuorums
+	}
+	return nil
+}
+
+func (m *ClusterConfig) GetQuorumRule() *QuorumRule {
+	if m != nil {
+		return m.QuorumRule
+	}
+	return nil
+}
+
+func (m *ClusterConfig) GetVoteQuorumRule() *QuorumRule {
+	if m != nil {
+		return m.VoteQuorumRule
+	}
+	return nil
+}
+
+func (m *ClusterConfig) GetNext() *ClusterConfig {
+	if m != nil {
+		return m.Next
+	}
+	return nil
+}
+
 type VoteReq struct {
 	// who initiates the election
 	Candidate *LeaderId `protobuf:"bytes,1,opt,name=Candidate,proto3" json:"Candidate,omitempty"`
@@ -783,7 +1073,7 @@ func (m *VoteReq) Reset()         { *m = VoteReq{} }
 func (m *VoteReq) String() string { return proto.CompactTextString(m) }
 func (*VoteReq) ProtoMessage()    {}
 func (*VoteReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{10}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{14}
 }
 func (m *VoteReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -852,7 +1142,7 @@ func (m *VoteReply) Reset()         { *m = VoteReply{} }
 func (m *VoteReply) String() string { return proto.CompactTextString(m) }
 func (*VoteReply) ProtoMessage()    {}
 func (*VoteReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{11}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{15}
 }
 func (m *VoteReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -926,13 +1216,19 @@ func (m *VoteReply) GetLogs() []*Record {
 type LogForwardReq struct {
 	Committer *LeaderId `protobuf:"bytes,1,opt,name=Committer,proto3" json:"Committer,omitempty"`
 	Logs      []*Record `protobuf:"bytes,2,rep,name=Logs,proto3" json:"Logs,omitempty"`
+	// What logs the leader has committed.
+	// A follower commits those it has accepted from the same Committer.
+	Committed *TailBitmap `protobuf:"bytes,3,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	// Heartbeat is set if it is sent to extend the lease of the leader. It
+	// has no Logs.
+	Heartbeat bool `protobuf:"varint,4,opt,name=Heartbeat,proto3" json:"Heartbeat,omitempty"`
 }
 
 func (m *LogForwardReq) Reset()         { *m = LogForwardReq{} }
 func (m *LogForwardReq) String() string { return proto.CompactTextString(m) }
 func (*LogForwardReq) ProtoMessage()    {}
 func (*LogForwardReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{12}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{16}
 }
 func (m *LogForwardReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -975,6 +1271,20 @@ func (m *LogForwardReq) GetLogs() []*Record {
 	return nil
 }
 
+func (m *LogForwardReq) GetCommitted() *TailBitmap {
+	if m != nil {
+		return m.Committed
+	}
+	return nil
+}
+
+func (m *LogForwardReq) GetHeartbeat() bool {
+	if m != nil {
+		return m.Heartbeat
+	}
+	return false
+}
+
 type LogForwardReply struct {
 	OK bool `protobuf:"varint,10,opt,name=OK,proto3" json:"OK,omitempty"`
 	// A replica responding a VotedFor with the same value with
@@ -983,13 +1293,16 @@ type LogForwardReply struct {
 	VotedFor  *LeaderId   `protobuf:"bytes,1,opt,name=VotedFor,proto3" json:"VotedFor,omitempty"`
 	Accepted  *TailBitmap `protobuf:"bytes,2,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
 	Committed *TailBitmap `protobuf:"bytes,3,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	// The leader the replica accepted its logs from.
+	// Logs accepted from another committer may differ from the leader's.
+	Committer *LeaderId `protobuf:"bytes,4,opt,name=Committer,proto3" json:"Committer,omitempty"`
 }
 
 func (m *LogForwardReply) Reset()         { *m = LogForwardReply{} }
 func (m *LogForwardReply) String() string { return proto.CompactTextString(m) }
 func (*LogForwardReply) ProtoMessage()    {}
 func (*LogForwardReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{13}
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{17}
 }
 func (m *LogForwardReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1046,25 +1359,40 @@ func (m *LogForwardReply) GetCommitted() *TailBitmap {
 	return nil
 }
 
-type ProposeReply struct {
-	OK  bool   `protobuf:"varint,2,opt,name=OK,proto3" json:"OK,omitempty"`
-	Err string `protobuf:"bytes,3,opt,name=Err,proto3" json:"Err,omitempty"`
-	// I am not leader, please redirect to `OtherLeader` to write to TRaft.
-	OtherLeader *LeaderId `protobuf:"bytes,1,opt,name=OtherLeader,proto3" json:"OtherLeader,omitempty"`
+func (m *LogForwardReply) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
+	}
+	return nil
 }
 
-func (m *ProposeReply) Reset()         { *m = ProposeReply{} }
-func (m *ProposeReply) String() string { return proto.CompactTextString(m) }
-func (*ProposeReply) ProtoMessage()    {}
-func (*ProposeReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_39aa4f94ef5dbc0b, []int{14}
-}
-func (m *ProposeReply) XXX_Unmarshal(b []byte) error {
+// SnapshotChunk is a piece of a Snapshot a leader sends to a follower that
+// lacks logs the leader has reclaimed.
+type SnapshotChunk struct {
+	Committer *LeaderId `protobuf:"bytes,1,opt,name=Committer,proto3" json:"Committer,omitempty"`
+	// Snapshot.Offset of the snapshot being sent.
+	SnapshotOffset int64 `protobuf:"varint,2,opt,name=SnapshotOffset,proto3" json:"SnapshotOffset,omitempty"`
+	// total size of the marshaled Snapshot.
+	SnapshotSize int64 `protobuf:"varint,3,opt,name=SnapshotSize,proto3" json:"SnapshotSize,omitempty"`
+	// the position of Data in the marshaled Snapshot.
+	ChunkOffset int64  `protobuf:"varint,4,opt,name=ChunkOffset,proto3" json:"ChunkOffset,omitempty"`
+	Data        []byte `protobuf:"bytes,5,opt,name=Data,proto3" json:"Data,omitempty"`
+	// the last chunk.
+	Done bool `protobuf:"varint,6,opt,name=Done,proto3" json:"Done,omitempty"`
+}
+
+func (m *SnapshotChunk) Reset()         { *m = SnapshotChunk{} }
+func (m *SnapshotChunk) String() string { return proto.CompactTextString(m) }
+func (*SnapshotChunk) ProtoMessage()    {}
+func (*SnapshotChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{18}
+}
+func (m *SnapshotChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *ProposeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *SnapshotChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_ProposeReply.Marshal(b, m, deterministic)
+		return xxx_messageInfo_SnapshotChunk.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1074,439 +1402,940 @@ func (m *ProposeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error)
 		return b[:n], nil
 	}
 }
-func (m *ProposeReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ProposeReply.Merge(m, src)
+func (m *SnapshotChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotChunk.Merge(m, src)
 }
-func (m *ProposeReply) XXX_Size() int {
+func (m *SnapshotChunk) XXX_Size() int {
 	return m.Size()
 }
-func (m *ProposeReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_ProposeReply.DiscardUnknown(m)
+func (m *SnapshotChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotChunk.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ProposeReply proto.InternalMessageInfo
+var xxx_messageInfo_SnapshotChunk proto.InternalMessageInfo
 
-func (m *ProposeReply) GetOK() bool {
+func (m *SnapshotChunk) GetCommitter() *LeaderId {
 	if m != nil {
-		return m.OK
+		return m.Committer
 	}
-	return false
+	return nil
 }
 
-func (m *ProposeReply) GetErr() string {
+func (m *SnapshotChunk) GetSnapshotOffset() int64 {
 	if m != nil {
-		return m.Err
+		return m.SnapshotOffset
 	}
-	return ""
+	return 0
 }
 
-func (m *ProposeReply) GetOtherLeader() *LeaderId {
+func (m *SnapshotChunk) GetSnapshotSize() int64 {
 	if m != nil {
-		return m.OtherLeader
+		return m.SnapshotSize
+	}
+	return 0
+}
+
+func (m *SnapshotChunk) GetChunkOffset() int64 {
+	if m != nil {
+		return m.ChunkOffset
+	}
+	return 0
+}
+
+func (m *SnapshotChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Cmd)(nil), "Cmd")
-	proto.RegisterType((*TailBitmap)(nil), "TailBitmap")
-	proto.RegisterType((*Record)(nil), "Record")
-	proto.RegisterType((*LeaderId)(nil), "LeaderId")
-	proto.RegisterType((*Node)(nil), "Node")
-	proto.RegisterMapType((map[int64]*ReplicaStatus)(nil), "Node.StatusEntry")
-	proto.RegisterType((*LogStatus)(nil), "LogStatus")
-	proto.RegisterType((*LeaderStatus)(nil), "LeaderStatus")
-	proto.RegisterType((*ReplicaStatus)(nil), "ReplicaStatus")
-	proto.RegisterType((*ReplicaInfo)(nil), "ReplicaInfo")
-	proto.RegisterType((*ClusterConfig)(nil), "ClusterConfig")
-	proto.RegisterMapType((map[int64]*ReplicaInfo)(nil), "ClusterConfig.MembersEntry")
-	proto.RegisterType((*VoteReq)(nil), "VoteReq")
-	proto.RegisterType((*VoteReply)(nil), "VoteReply")
-	proto.RegisterType((*LogForwardReq)(nil), "LogForwardReq")
-	proto.RegisterType((*LogForwardReply)(nil), "LogForwardReply")
-	proto.RegisterType((*ProposeReply)(nil), "ProposeReply")
+func (m *SnapshotChunk) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
 }
 
-func init() { proto.RegisterFile("traft.proto", fileDescriptor_39aa4f94ef5dbc0b) }
-
-var fileDescriptor_39aa4f94ef5dbc0b = []byte{
-	// 951 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4f, 0x6f, 0xe3, 0x44,
-	0x14, 0xcf, 0xd8, 0x6e, 0xfe, 0x3c, 0xa7, 0xa5, 0x8c, 0xba, 0x95, 0x95, 0x45, 0x26, 0x6b, 0xb1,
-	0x6c, 0x2a, 0x84, 0x17, 0x85, 0x65, 0xb5, 0x82, 0x53, 0x1a, 0xba, 0x6a, 0xd4, 0x2e, 0x59, 0xa6,
-	0x25, 0x08, 0xa4, 0x3d, 0xb8, 0x99, 0x49, 0x6a, 0x11, 0x67, 0xbc, 0xe3, 0xc9, 0x42, 0xc5, 0x95,
-	0x0f, 0xc0, 0x89, 0x13, 0x77, 0x10, 0x9f, 0x84, 0x63, 0x8f, 0x1c, 0x38, 0x40, 0xfb, 0x01, 0xf8,
-	0x06, 0x08, 0x79, 0x3c, 0x4e, 0xe2, 0xb6, 0x8a, 0x2a, 0xb4, 0xb7, 0xf7, 0xde, 0x6f, 0xc6, 0xf3,
-	0x7b, 0xbf, 0xf7, 0x9b, 0x49, 0xc0, 0x96, 0x22, 0x18, 0x49, 0x3f, 0x16, 0x5c, 0xf2, 0xc6, 0xfb,
-	0xe3, 0x50, 0x9e, 0xce, 0x4e, 0xfc, 0x21, 0x8f, 0x1e, 0x8e, 0xf9, 0x98, 0x3f, 0x54, 0xe5, 0x93,
-	0xd9, 0x48, 0x65, 0x2a, 0x51, 0x51, 0xb6, 0xdc, 0xfb, 0x09, 0x81, 0xd9, 0x8d, 0x28, 0xde, 0x00,
-	0xa3, 0x1f, 0x3b, 0xd0, 0x44, 0xad, 0x1a, 0x31, 0xfa, 0x31, 0xde, 0x04, 0xf3, 0x80, 0x9d, 0x39,
-	0x5b, 0xaa, 0x90, 0x86, 0x78, 0x0b, 0xac, 0xc1, 0x91, 0x14, 0xce, 0xdb, 0x69, 0x69, 0xbf, 0x44,
-	0x54, 0xa6, 0xaa, 0xbd, 0xc7, 0x8f, 0x9c, 0x66, 0x13, 0xb5, 0x4c, 0x55, 0xed, 0x3d, 0x7e, 0x84,
-	0x9f, 0xc0, 0xc6, 0xa0, 0x3b, 0x99, 0x25, 0x92, 0x89, 0x2e, 0x9f, 0x8e, 0xc2, 0xb1, 0x73, 0xaf,
-	0x89, 0x5a, 0x76, 0x7b, 0xc3, 0x2f, 0x54, 0xf7, 0x4b, 0xe4, 0xca, 0xba, 0xdd, 0x0a, 0xac, 0x0d,
-	0x82, 0xc9, 0x8c, 0x79, 0x03, 0x80, 0xe3, 0x20, 0x9c, 0xec, 0x86, 0x32, 0x0a, 0x62, 0xbc, 0x0d,
-	0xe5, 0xfe, 0x68, 0x94, 0x30, 0xe9, 0xa0, 0xf4, 0x20, 0xa2, 0x33, 0xbc, 0x05, 0x6b, 0x5f, 0x72,
-	0x41, 0x13, 0xc7, 0x68, 0x9a, 0x2d, 0x8b, 0x64, 0x09, 0x6e, 0x40, 0x95, 0xb0, 0xe1, 0x24, 0x88,
-	0x18, 0x75, 0x4c, 0xb5, 0x7e, 0x9e, 0x7b, 0xbf, 0x20, 0x28, 0x13, 0x36, 0xe4, 0x82, 0xe2, 0x7b,
-	0x50, 0xee, 0xcc, 0xe4, 0x29, 0x17, 0xea, 0xa3, 0x76, 0xbb, 0xe6, 0x1f, 0xb2, 0x80, 0x32, 0xd1,
-	0xa3, 0x44, 0x03, 0xa9, 0x0c, 0x47, 0xec, 0xa5, 0xd2, 0xc5, 0x24, 0x69, 0x88, 0xb7, 0x95, 0x5e,
-	0x8e, 0xab, 0x76, 0x58, 0x7e, 0x37, 0xa2, 0x44, 0x09, 0x78, 0x1f, 0x2a, 0x9f, 0xb2, 0x98, 0x4d,
-	0x69, 0xa2, 0xb4, 0xb0, 0xdb, 0xb6, 0xbf, 0xe0, 0x4f, 0x72, 0x0c, 0xef, 0x40, 0xad, 0xff, 0x8a,
-	0x09, 0x11, 0x52, 0x96, 0x38, 0xad, 0xeb, 0x0b, 0x17, 0xa8, 0xe7, 0x43, 0x35, 0xe7, 0x83, 0x31,
-	0x58, 0xc7, 0x4c, 0x44, 0xba, 0x7b, 0x15, 0xa7, 0x23, 0xeb, 0x51, 0xc7, 0x50, 0x15, 0xa3, 0x47,
-	0xbd, 0x7f, 0x10, 0x58, 0x9f, 0x71, 0xca, 0x34, 0x60, 0xe6, 0x00, 0x7e, 0x17, 0xca, 0x7a, 0x0a,
-	0xe8, 0xa6, 0x29, 0x10, 0x8d, 0xe2, 0xb7, 0xa0, 0x76, 0xc8, 0xc7, 0x5a, 0x67, 0x4b, 0x6d, 0x5f,
-	0x14, 0xf0, 0x5d, 0xb0, 0x0e, 0xf9, 0x38, 0x53, 0xda, 0x6e, 0x57, 0xfc, 0x4c, 0x44, 0xa2, 0x8a,
-	0x78, 0x07, 0xca, 0x47, 0x32, 0x90, 0xb3, 0xc4, 0x29, 0x2b, 0xf8, 0x4d, 0x3f, 0x65, 0xe2, 0x67,
-	0xb5, 0xbd, 0xa9, 0x14, 0x67, 0x44, 0x2f, 0x68, 0xf4, 0xc0, 0x5e, 0x2a, 0xa7, 0x0a, 0x7f, 0xc3,
-	0xce, 0x74, 0x63, 0x69, 0x88, 0xdf, 0x81, 0xb5, 0x57, 0xa9, 0x05, 0x54, 0x6b, 0x29, 0x5b, 0xc2,
-	0xe2, 0x49, 0x38, 0x0c, 0xb2, 0x5d, 0x24, 0x03, 0x3f, 0x36, 0x9e, 0x20, 0xef, 0x85, 0x22, 0x9c,
-	0xd5, 0xf1, 0x03, 0xa8, 0x75, 0x79, 0x14, 0x85, 0x52, 0x32, 0xa1, 0xd8, 0x17, 0x06, 0xba, 0xc0,
-	0xf0, 0x03, 0xa8, 0x76, 0x86, 0x43, 0x16, 0x4b, 0x46, 0xb5, 0x20, 0x85, 0x09, 0xcc, 0x41, 0xef,
-	0x2b, 0xa8, 0x67, 0xfb, 0xf5, 0x09, 0xf7, 0xa1, 0x3a, 0xe0, 0x92, 0xd1, 0xa7, 0x5c, 0x28, 0x47,
-	0x14, 0x0e, 0x98, 0x43, 0xd8, 0x83, 0x7a, 0x1a, 0xef, 0x7d, 0x17, 0x87, 0x82, 0x75, 0xa4, 0x63,
-	0xab, 0xd6, 0x0a, 0x35, 0xef, 0x5f, 0x04, 0xeb, 0x85, 0xb6, 0x5e, 0xe3, 0xc7, 0x5f, 0xbf, 0x12,
-	0xa9, 0x6b, 0xf3, 0x5d, 0x54, 0x8f, 0xa5, 0xe8, 0xda, 0x39, 0x9a, 0xde, 0x83, 0x4e, 0x1c, 0x4f,
-	0x42, 0x7d, 0xf5, 0xae, 0xde, 0x03, 0x8d, 0x79, 0xcf, 0xc0, 0xd6, 0xfd, 0xf7, 0xa6, 0x23, 0xae,
-	0x2d, 0x8b, 0xe6, 0x96, 0xc5, 0x60, 0x75, 0x28, 0x15, 0xea, 0xac, 0x1a, 0x51, 0x71, 0x7a, 0xab,
-	0x9f, 0xf3, 0x24, 0x94, 0x21, 0x9f, 0xe6, 0xb7, 0x3a, 0xcf, 0xbd, 0xdf, 0x10, 0xac, 0x17, 0x4c,
-	0x8d, 0x3f, 0x82, 0xca, 0x33, 0x16, 0x9d, 0x30, 0x91, 0x38, 0xb6, 0xb2, 0xe4, 0xdd, 0xa2, 0xeb,
-	0x7d, 0x8d, 0x66, 0xe6, 0xcc, 0xd7, 0x62, 0x07, 0x2a, 0x9f, 0xcf, 0xb8, 0x98, 0x45, 0x89, 0x73,
-	0x47, 0x3d, 0x29, 0x79, 0xda, 0xd8, 0x87, 0xfa, 0xf2, 0x96, 0x1b, 0x8c, 0xeb, 0x15, 0x8d, 0x5b,
-	0xf7, 0x97, 0x3a, 0x5c, 0xb6, 0xed, 0x0f, 0x08, 0x2a, 0xe9, 0xc0, 0x08, 0x7b, 0xa9, 0x66, 0x15,
-	0x4c, 0x69, 0x48, 0x03, 0xc9, 0xae, 0x3f, 0x43, 0x0b, 0xac, 0x38, 0x54, 0xe3, 0x96, 0x43, 0x35,
-	0x57, 0xd9, 0xfb, 0x4f, 0x04, 0xb5, 0x8c, 0x46, 0x3c, 0x39, 0xbb, 0x36, 0x81, 0x5b, 0xfa, 0xf1,
-	0x7f, 0x79, 0xed, 0xce, 0xad, 0xbd, 0xb6, 0xbd, 0xd2, 0x6b, 0xf9, 0x93, 0xe4, 0xde, 0xf0, 0x24,
-	0x79, 0x5f, 0xc0, 0xfa, 0x21, 0x1f, 0x3f, 0xe5, 0xe2, 0xdb, 0x40, 0xd0, 0x5c, 0xea, 0x39, 0x55,
-	0xb4, 0x82, 0xea, 0xaa, 0x97, 0xce, 0xfb, 0x19, 0xc1, 0x1b, 0xcb, 0xdf, 0xd5, 0xda, 0xf5, 0x0f,
-	0x94, 0x4a, 0x55, 0x62, 0xf4, 0x0f, 0x0a, 0xda, 0xa1, 0x55, 0xda, 0x2d, 0x24, 0x31, 0x6e, 0x2d,
-	0x89, 0xb9, 0x4a, 0x12, 0xef, 0x05, 0xd4, 0x9f, 0x0b, 0x1e, 0xf3, 0x84, 0x2d, 0x53, 0x33, 0xe6,
-	0xd4, 0x36, 0xc1, 0xdc, 0x13, 0x42, 0x7d, 0xa4, 0x46, 0xd2, 0x10, 0xbf, 0x07, 0x76, 0x5f, 0x9e,
-	0x32, 0x91, 0x11, 0xbc, 0xce, 0x77, 0x19, 0x6d, 0x7f, 0x0f, 0x6b, 0xc7, 0x24, 0x18, 0x49, 0xec,
-	0x82, 0x95, 0xf6, 0x81, 0xab, 0xbe, 0x76, 0x72, 0x03, 0xfc, 0xb9, 0x99, 0xbc, 0x12, 0xfe, 0x00,
-	0x60, 0xa1, 0x12, 0xde, 0xf0, 0x0b, 0xa3, 0x68, 0x6c, 0xfa, 0x57, 0x24, 0xf4, 0x4a, 0xb8, 0x09,
-	0x15, 0xcd, 0x1c, 0xab, 0x9f, 0xd5, 0xc6, 0xba, 0xbf, 0xdc, 0x89, 0x57, 0xda, 0xdd, 0x39, 0xff,
-	0xdb, 0x2d, 0xfd, 0x7a, 0xe1, 0xa2, 0xdf, 0x2f, 0x5c, 0x74, 0x7e, 0xe1, 0xa2, 0xbf, 0x2e, 0x5c,
-	0xf4, 0xe3, 0xa5, 0x5b, 0x3a, 0xbf, 0x74, 0x4b, 0x7f, 0x5c, 0xba, 0xa5, 0xaf, 0x2b, 0xfe, 0x27,
-	0xea, 0xbf, 0xd0, 0x49, 0x59, 0xfd, 0xbb, 0xf9, 0xf0, 0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x75,
-	0x26, 0xbe, 0xbd, 0x1b, 0x09, 0x00, 0x00,
+type InstallSnapshotReply struct {
+	// the snapshot is installed.
+	OK       bool      `protobuf:"varint,1,opt,name=OK,proto3" json:"OK,omitempty"`
+	VotedFor *LeaderId `protobuf:"bytes,2,opt,name=VotedFor,proto3" json:"VotedFor,omitempty"`
+	// The position in the marshaled Snapshot the follower expects next.
+	// A sender resumes an interrupted transfer from here.
+	NextChunkOffset int64       `protobuf:"varint,3,opt,name=NextChunkOffset,proto3" json:"NextChunkOffset,omitempty"`
+	Accepted        *TailBitmap `protobuf:"bytes,4,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
+	Committed       *TailBitmap `protobuf:"bytes,5,opt,name=Committed,proto3" json:"Committed,omitempty"`
 }
 
-func (this *Cmd) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
-	}
-
-	that1, ok := that.(*Cmd)
-	if !ok {
-		that2, ok := that.(Cmd)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
-	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.Op != that1.Op {
-		return false
-	}
-	if this.Key != that1.Key {
-		return false
-	}
-	if that1.Value == nil {
-		if this.Value != nil {
-			return false
+func (m *InstallSnapshotReply) Reset()         { *m = InstallSnapshotReply{} }
+func (m *InstallSnapshotReply) String() string { return proto.CompactTextString(m) }
+func (*InstallSnapshotReply) ProtoMessage()    {}
+func (*InstallSnapshotReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{19}
+}
+func (m *InstallSnapshotReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InstallSnapshotReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InstallSnapshotReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-	} else if this.Value == nil {
-		return false
-	} else if !this.Value.Equal(that1.Value) {
-		return false
+		return b[:n], nil
 	}
-	return true
 }
-func (this *Cmd_VStr) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+func (m *InstallSnapshotReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InstallSnapshotReply.Merge(m, src)
+}
+func (m *InstallSnapshotReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *InstallSnapshotReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_InstallSnapshotReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InstallSnapshotReply proto.InternalMessageInfo
+
+func (m *InstallSnapshotReply) GetOK() bool {
+	if m != nil {
+		return m.OK
 	}
+	return false
+}
 
-	that1, ok := that.(*Cmd_VStr)
-	if !ok {
-		that2, ok := that.(Cmd_VStr)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
+func (m *InstallSnapshotReply) GetVotedFor() *LeaderId {
+	if m != nil {
+		return m.VotedFor
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+	return nil
+}
+
+func (m *InstallSnapshotReply) GetNextChunkOffset() int64 {
+	if m != nil {
+		return m.NextChunkOffset
 	}
-	if this.VStr != that1.VStr {
-		return false
+	return 0
+}
+
+func (m *InstallSnapshotReply) GetAccepted() *TailBitmap {
+	if m != nil {
+		return m.Accepted
 	}
-	return true
+	return nil
 }
-func (this *Cmd_VI64) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *InstallSnapshotReply) GetCommitted() *TailBitmap {
+	if m != nil {
+		return m.Committed
 	}
+	return nil
+}
 
-	that1, ok := that.(*Cmd_VI64)
-	if !ok {
-		that2, ok := that.(Cmd_VI64)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// ProposeReq is a proposal the leader handles. Propose and ProposeApplied
+// build it from a Cmd.
+type ProposeReq struct {
+	Cmd *Cmd `protobuf:"bytes,1,opt,name=Cmd,proto3" json:"Cmd,omitempty"`
+	// Reply after the log is applied, with the result of applying it.
+	// By default it replies once the log is committed.
+	WaitApplied bool `protobuf:"varint,2,opt,name=WaitApplied,proto3" json:"WaitApplied,omitempty"`
+}
+
+func (m *ProposeReq) Reset()         { *m = ProposeReq{} }
+func (m *ProposeReq) String() string { return proto.CompactTextString(m) }
+func (*ProposeReq) ProtoMessage()    {}
+func (*ProposeReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{20}
+}
+func (m *ProposeReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ProposeReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ProposeReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.VI64 != that1.VI64 {
-		return false
+}
+func (m *ProposeReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposeReq.Merge(m, src)
+}
+func (m *ProposeReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *ProposeReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposeReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposeReq proto.InternalMessageInfo
+
+func (m *ProposeReq) GetCmd() *Cmd {
+	if m != nil {
+		return m.Cmd
 	}
-	return true
+	return nil
 }
-func (this *Cmd_VClusterConfig) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *ProposeReq) GetWaitApplied() bool {
+	if m != nil {
+		return m.WaitApplied
 	}
+	return false
+}
 
-	that1, ok := that.(*Cmd_VClusterConfig)
-	if !ok {
-		that2, ok := that.(Cmd_VClusterConfig)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type ProposeReply struct {
+	OK  bool   `protobuf:"varint,2,opt,name=OK,proto3" json:"OK,omitempty"`
+	Err string `protobuf:"bytes,3,opt,name=Err,proto3" json:"Err,omitempty"`
+	// I am not leader, please redirect to `OtherLeader` to write to TRaft.
+	OtherLeader *LeaderId `protobuf:"bytes,1,opt,name=OtherLeader,proto3" json:"OtherLeader,omitempty"`
+	// What StateMachine.Apply() returns, if proposed with ProposeApplied.
+	// It is empty if the log is overridden by another one before being
+	// applied.
+	Result []byte `protobuf:"bytes,4,opt,name=Result,proto3" json:"Result,omitempty"`
+	// lsn of the log.
+	Seq int64 `protobuf:"varint,5,opt,name=Seq,proto3" json:"Seq,omitempty"`
+	// The leader that committed the log.
+	Committer *LeaderId `protobuf:"bytes,6,opt,name=Committer,proto3" json:"Committer,omitempty"`
+}
+
+func (m *ProposeReply) Reset()         { *m = ProposeReply{} }
+func (m *ProposeReply) String() string { return proto.CompactTextString(m) }
+func (*ProposeReply) ProtoMessage()    {}
+func (*ProposeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{21}
+}
+func (m *ProposeReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ProposeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ProposeReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *ProposeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposeReply.Merge(m, src)
+}
+func (m *ProposeReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *ProposeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposeReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposeReply proto.InternalMessageInfo
+
+func (m *ProposeReply) GetOK() bool {
+	if m != nil {
+		return m.OK
 	}
-	if !this.VClusterConfig.Equal(that1.VClusterConfig) {
-		return false
+	return false
+}
+
+func (m *ProposeReply) GetErr() string {
+	if m != nil {
+		return m.Err
 	}
-	return true
+	return ""
 }
-func (this *TailBitmap) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *ProposeReply) GetOtherLeader() *LeaderId {
+	if m != nil {
+		return m.OtherLeader
 	}
+	return nil
+}
 
-	that1, ok := that.(*TailBitmap)
-	if !ok {
-		that2, ok := that.(TailBitmap)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
+func (m *ProposeReply) GetResult() []byte {
+	if m != nil {
+		return m.Result
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+	return nil
+}
+
+func (m *ProposeReply) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
 	}
-	if this.Offset != that1.Offset {
-		return false
+	return 0
+}
+
+func (m *ProposeReply) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
 	}
-	if len(this.Words) != len(that1.Words) {
-		return false
-	}
-	for i := range this.Words {
-		if this.Words[i] != that1.Words[i] {
-			return false
+	return nil
+}
+
+type TransferLeadershipReq struct {
+	// The replica to be the next leader.
+	TargetId int64 `protobuf:"varint,1,opt,name=TargetId,proto3" json:"TargetId,omitempty"`
+}
+
+func (m *TransferLeadershipReq) Reset()         { *m = TransferLeadershipReq{} }
+func (m *TransferLeadershipReq) String() string { return proto.CompactTextString(m) }
+func (*TransferLeadershipReq) ProtoMessage()    {}
+func (*TransferLeadershipReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{22}
+}
+func (m *TransferLeadershipReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TransferLeadershipReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TransferLeadershipReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if this.Reclamed != that1.Reclamed {
-		return false
-	}
-	return true
 }
-func (this *Record) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+func (m *TransferLeadershipReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferLeadershipReq.Merge(m, src)
+}
+func (m *TransferLeadershipReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *TransferLeadershipReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferLeadershipReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferLeadershipReq proto.InternalMessageInfo
+
+func (m *TransferLeadershipReq) GetTargetId() int64 {
+	if m != nil {
+		return m.TargetId
 	}
+	return 0
+}
 
-	that1, ok := that.(*Record)
-	if !ok {
-		that2, ok := that.(Record)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TransferLeadershipReply struct {
+	OK  bool   `protobuf:"varint,1,opt,name=OK,proto3" json:"OK,omitempty"`
+	Err string `protobuf:"bytes,2,opt,name=Err,proto3" json:"Err,omitempty"`
+	// The leader after the transfer. If this replica is not the leader, it
+	// is the one to send the request to.
+	OtherLeader *LeaderId `protobuf:"bytes,3,opt,name=OtherLeader,proto3" json:"OtherLeader,omitempty"`
+}
+
+func (m *TransferLeadershipReply) Reset()         { *m = TransferLeadershipReply{} }
+func (m *TransferLeadershipReply) String() string { return proto.CompactTextString(m) }
+func (*TransferLeadershipReply) ProtoMessage()    {}
+func (*TransferLeadershipReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{23}
+}
+func (m *TransferLeadershipReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TransferLeadershipReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TransferLeadershipReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if !this.Author.Equal(that1.Author) {
-		return false
-	}
-	if this.Seq != that1.Seq {
-		return false
-	}
-	if !this.Cmd.Equal(that1.Cmd) {
-		return false
-	}
-	if !this.Depends.Equal(that1.Depends) {
-		return false
+}
+func (m *TransferLeadershipReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferLeadershipReply.Merge(m, src)
+}
+func (m *TransferLeadershipReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *TransferLeadershipReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferLeadershipReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferLeadershipReply proto.InternalMessageInfo
+
+func (m *TransferLeadershipReply) GetOK() bool {
+	if m != nil {
+		return m.OK
 	}
-	if !this.Overrides.Equal(that1.Overrides) {
-		return false
+	return false
+}
+
+func (m *TransferLeadershipReply) GetErr() string {
+	if m != nil {
+		return m.Err
 	}
-	return true
+	return ""
 }
-func (this *LeaderId) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TransferLeadershipReply) GetOtherLeader() *LeaderId {
+	if m != nil {
+		return m.OtherLeader
 	}
+	return nil
+}
 
-	that1, ok := that.(*LeaderId)
-	if !ok {
-		that2, ok := that.(LeaderId)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// ChangeMembersReq adds and removes members.
+// To replace a member, add the new one and remove the former one in one
+// request.
+type ChangeMembersReq struct {
+	// Replicas to add. The leader assigns their Position.
+	Add []*ReplicaInfo `protobuf:"bytes,1,rep,name=Add,proto3" json:"Add,omitempty"`
+	// Ids of members to remove.
+	Remove []int64 `protobuf:"varint,2,rep,packed,name=Remove,proto3" json:"Remove,omitempty"`
+}
+
+func (m *ChangeMembersReq) Reset()         { *m = ChangeMembersReq{} }
+func (m *ChangeMembersReq) String() string { return proto.CompactTextString(m) }
+func (*ChangeMembersReq) ProtoMessage()    {}
+func (*ChangeMembersReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{24}
+}
+func (m *ChangeMembersReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ChangeMembersReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ChangeMembersReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.Term != that1.Term {
-		return false
-	}
-	if this.Id != that1.Id {
-		return false
+}
+func (m *ChangeMembersReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChangeMembersReq.Merge(m, src)
+}
+func (m *ChangeMembersReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *ChangeMembersReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChangeMembersReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChangeMembersReq proto.InternalMessageInfo
+
+func (m *ChangeMembersReq) GetAdd() []*ReplicaInfo {
+	if m != nil {
+		return m.Add
 	}
-	return true
+	return nil
 }
-func (this *Node) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *ChangeMembersReq) GetRemove() []int64 {
+	if m != nil {
+		return m.Remove
 	}
+	return nil
+}
 
-	that1, ok := that.(*Node)
-	if !ok {
-		that2, ok := that.(Node)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// ChangeMembersProgress is sent each time a member change reaches a stage:
+// "proposed", "joint-committed", "committed", and "stepped-down" if the leader
+// removes itself.
+type ChangeMembersProgress struct {
+	Stage string `protobuf:"bytes,1,opt,name=Stage,proto3" json:"Stage,omitempty"`
+	// Set if the change failed. It is the last progress sent.
+	Err string `protobuf:"bytes,2,opt,name=Err,proto3" json:"Err,omitempty"`
+	// The leader to send the request to, if this replica is not the leader.
+	OtherLeader *LeaderId `protobuf:"bytes,3,opt,name=OtherLeader,proto3" json:"OtherLeader,omitempty"`
+	// The config of the config log written at this stage, and its lsn.
+	Config *ClusterConfig `protobuf:"bytes,4,opt,name=Config,proto3" json:"Config,omitempty"`
+	Seq    int64          `protobuf:"varint,5,opt,name=Seq,proto3" json:"Seq,omitempty"`
+}
+
+func (m *ChangeMembersProgress) Reset()         { *m = ChangeMembersProgress{} }
+func (m *ChangeMembersProgress) String() string { return proto.CompactTextString(m) }
+func (*ChangeMembersProgress) ProtoMessage()    {}
+func (*ChangeMembersProgress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{25}
+}
+func (m *ChangeMembersProgress) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ChangeMembersProgress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ChangeMembersProgress.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.Id != that1.Id {
-		return false
-	}
-	if !this.Config.Equal(that1.Config) {
-		return false
+}
+func (m *ChangeMembersProgress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChangeMembersProgress.Merge(m, src)
+}
+func (m *ChangeMembersProgress) XXX_Size() int {
+	return m.Size()
+}
+func (m *ChangeMembersProgress) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChangeMembersProgress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChangeMembersProgress proto.InternalMessageInfo
+
+func (m *ChangeMembersProgress) GetStage() string {
+	if m != nil {
+		return m.Stage
 	}
-	if this.LogOffset != that1.LogOffset {
-		return false
+	return ""
+}
+
+func (m *ChangeMembersProgress) GetErr() string {
+	if m != nil {
+		return m.Err
 	}
-	if len(this.Logs) != len(that1.Logs) {
-		return false
+	return ""
+}
+
+func (m *ChangeMembersProgress) GetOtherLeader() *LeaderId {
+	if m != nil {
+		return m.OtherLeader
 	}
-	for i := range this.Logs {
-		if !this.Logs[i].Equal(that1.Logs[i]) {
-			return false
-		}
+	return nil
+}
+
+func (m *ChangeMembersProgress) GetConfig() *ClusterConfig {
+	if m != nil {
+		return m.Config
 	}
-	if len(this.Status) != len(that1.Status) {
-		return false
+	return nil
+}
+
+func (m *ChangeMembersProgress) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
 	}
-	for i := range this.Status {
-		if !this.Status[i].Equal(that1.Status[i]) {
-			return false
+	return 0
+}
+
+// TimeoutNowReq is sent by a leader transferring its leadership, to ask a
+// follower to start an election at once.
+type TimeoutNowReq struct {
+	Committer *LeaderId `protobuf:"bytes,1,opt,name=Committer,proto3" json:"Committer,omitempty"`
+	// What the leader has. The follower refuses if it has not got all of
+	// them.
+	Accepted *TailBitmap `protobuf:"bytes,2,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
+}
+
+func (m *TimeoutNowReq) Reset()         { *m = TimeoutNowReq{} }
+func (m *TimeoutNowReq) String() string { return proto.CompactTextString(m) }
+func (*TimeoutNowReq) ProtoMessage()    {}
+func (*TimeoutNowReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{26}
+}
+func (m *TimeoutNowReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TimeoutNowReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TimeoutNowReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return true
 }
-func (this *LogStatus) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
-	}
+func (m *TimeoutNowReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeoutNowReq.Merge(m, src)
+}
+func (m *TimeoutNowReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *TimeoutNowReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeoutNowReq.DiscardUnknown(m)
+}
 
-	that1, ok := that.(*LogStatus)
-	if !ok {
-		that2, ok := that.(LogStatus)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
+var xxx_messageInfo_TimeoutNowReq proto.InternalMessageInfo
+
+func (m *TimeoutNowReq) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+	return nil
+}
+
+func (m *TimeoutNowReq) GetAccepted() *TailBitmap {
+	if m != nil {
+		return m.Accepted
 	}
-	if !this.Committer.Equal(that1.Committer) {
-		return false
+	return nil
+}
+
+type TimeoutNowReply struct {
+	OK        bool        `protobuf:"varint,1,opt,name=OK,proto3" json:"OK,omitempty"`
+	VotedFor  *LeaderId   `protobuf:"bytes,2,opt,name=VotedFor,proto3" json:"VotedFor,omitempty"`
+	Committer *LeaderId   `protobuf:"bytes,3,opt,name=Committer,proto3" json:"Committer,omitempty"`
+	Accepted  *TailBitmap `protobuf:"bytes,4,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
+}
+
+func (m *TimeoutNowReply) Reset()         { *m = TimeoutNowReply{} }
+func (m *TimeoutNowReply) String() string { return proto.CompactTextString(m) }
+func (*TimeoutNowReply) ProtoMessage()    {}
+func (*TimeoutNowReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{27}
+}
+func (m *TimeoutNowReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TimeoutNowReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TimeoutNowReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if !this.Accepted.Equal(that1.Accepted) {
-		return false
+}
+func (m *TimeoutNowReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeoutNowReply.Merge(m, src)
+}
+func (m *TimeoutNowReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *TimeoutNowReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeoutNowReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeoutNowReply proto.InternalMessageInfo
+
+func (m *TimeoutNowReply) GetOK() bool {
+	if m != nil {
+		return m.OK
 	}
-	return true
+	return false
 }
-func (this *LeaderStatus) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TimeoutNowReply) GetVotedFor() *LeaderId {
+	if m != nil {
+		return m.VotedFor
 	}
+	return nil
+}
 
-	that1, ok := that.(*LeaderStatus)
-	if !ok {
-		that2, ok := that.(LeaderStatus)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+func (m *TimeoutNowReply) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
+	}
+	return nil
+}
+
+func (m *TimeoutNowReply) GetAccepted() *TailBitmap {
+	if m != nil {
+		return m.Accepted
+	}
+	return nil
+}
+
+// FetchLogsReq is sent by a replica that can not be elected for stale logs,
+// to get logs from a replica with a greater log status.
+type FetchLogsReq struct {
+	// What the sender has.
+	Committer *LeaderId   `protobuf:"bytes,1,opt,name=Committer,proto3" json:"Committer,omitempty"`
+	Accepted  *TailBitmap `protobuf:"bytes,2,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
+	Committed *TailBitmap `protobuf:"bytes,3,opt,name=Committed,proto3" json:"Committed,omitempty"`
+}
+
+func (m *FetchLogsReq) Reset()         { *m = FetchLogsReq{} }
+func (m *FetchLogsReq) String() string { return proto.CompactTextString(m) }
+func (*FetchLogsReq) ProtoMessage()    {}
+func (*FetchLogsReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{28}
+}
+func (m *FetchLogsReq) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FetchLogsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FetchLogsReq.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *FetchLogsReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchLogsReq.Merge(m, src)
+}
+func (m *FetchLogsReq) XXX_Size() int {
+	return m.Size()
+}
+func (m *FetchLogsReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchLogsReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchLogsReq proto.InternalMessageInfo
+
+func (m *FetchLogsReq) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
 	}
-	if !this.VotedFor.Equal(that1.VotedFor) {
-		return false
+	return nil
+}
+
+func (m *FetchLogsReq) GetAccepted() *TailBitmap {
+	if m != nil {
+		return m.Accepted
 	}
-	if this.VoteExpireAt != that1.VoteExpireAt {
-		return false
+	return nil
+}
+
+func (m *FetchLogsReq) GetCommitted() *TailBitmap {
+	if m != nil {
+		return m.Committed
 	}
-	return true
+	return nil
 }
-func (this *ReplicaStatus) Equal(that interface{}) bool {
+
+type FetchLogsReply struct {
+	Committer *LeaderId   `protobuf:"bytes,1,opt,name=Committer,proto3" json:"Committer,omitempty"`
+	Accepted  *TailBitmap `protobuf:"bytes,2,opt,name=Accepted,proto3" json:"Accepted,omitempty"`
+	Committed *TailBitmap `protobuf:"bytes,3,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	// Logs in Accepted the sender does not have, if the replier has a greater
+	// log status.
+	Logs []*Record `protobuf:"bytes,4,rep,name=Logs,proto3" json:"Logs,omitempty"`
+}
+
+func (m *FetchLogsReply) Reset()         { *m = FetchLogsReply{} }
+func (m *FetchLogsReply) String() string { return proto.CompactTextString(m) }
+func (*FetchLogsReply) ProtoMessage()    {}
+func (*FetchLogsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_39aa4f94ef5dbc0b, []int{29}
+}
+func (m *FetchLogsReply) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FetchLogsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FetchLogsReply.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FetchLogsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchLogsReply.Merge(m, src)
+}
+func (m *FetchLogsReply) XXX_Size() int {
+	return m.Size()
+}
+func (m *FetchLogsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchLogsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchLogsReply proto.InternalMessageInfo
+
+func (m *FetchLogsReply) GetCommitter() *LeaderId {
+	if m != nil {
+		return m.Committer
+	}
+	return nil
+}
+
+func (m *FetchLogsReply) GetAccepted() *TailBitmap {
+	if m != nil {
+		return m.Accepted
+	}
+	return nil
+}
+
+func (m *FetchLogsReply) GetCommitted() *TailBitmap {
+	if m != nil {
+		return m.Committed
+	}
+	return nil
+}
+
+func (m *FetchLogsReply) GetLogs() []*Record {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Cmd)(nil), "Cmd")
+	proto.RegisterType((*TailBitmap)(nil), "TailBitmap")
+	proto.RegisterType((*Record)(nil), "Record")
+	proto.RegisterType((*LeaderId)(nil), "LeaderId")
+	proto.RegisterType((*Node)(nil), "Node")
+	proto.RegisterMapType((map[int64]*ReplicaStatus)(nil), "Node.StatusEntry")
+	proto.RegisterType((*LogStatus)(nil), "LogStatus")
+	proto.RegisterType((*LeaderStatus)(nil), "LeaderStatus")
+	proto.RegisterType((*ReplicaStatus)(nil), "ReplicaStatus")
+	proto.RegisterType((*HardState)(nil), "HardState")
+	proto.RegisterType((*Snapshot)(nil), "Snapshot")
+	proto.RegisterType((*KVSnapshot)(nil), "KVSnapshot")
+	proto.RegisterType((*ReplicaInfo)(nil), "ReplicaInfo")
+	proto.RegisterType((*QuorumRule)(nil), "QuorumRule")
+	proto.RegisterMapType((map[int64]int64)(nil), "QuorumRule.VotesEntry")
+	proto.RegisterType((*ClusterConfig)(nil), "ClusterConfig")
+	proto.RegisterMapType((map[int64]*ReplicaInfo)(nil), "ClusterConfig.MembersEntry")
+	proto.RegisterType((*VoteReq)(nil), "VoteReq")
+	proto.RegisterType((*VoteReply)(nil), "VoteReply")
+	proto.RegisterType((*LogForwardReq)(nil), "LogForwardReq")
+	proto.RegisterType((*LogForwardReply)(nil), "LogForwardReply")
+	proto.RegisterType((*SnapshotChunk)(nil), "SnapshotChunk")
+	proto.RegisterType((*InstallSnapshotReply)(nil), "InstallSnapshotReply")
+	proto.RegisterType((*ProposeReq)(nil), "ProposeReq")
+	proto.RegisterType((*ProposeReply)(nil), "ProposeReply")
+	proto.RegisterType((*TransferLeadershipReq)(nil), "TransferLeadershipReq")
+	proto.RegisterType((*TransferLeadershipReply)(nil), "TransferLeadershipReply")
+	proto.RegisterType((*ChangeMembersReq)(nil), "ChangeMembersReq")
+	proto.RegisterType((*ChangeMembersProgress)(nil), "ChangeMembersProgress")
+	proto.RegisterType((*TimeoutNowReq)(nil), "TimeoutNowReq")
+	proto.RegisterType((*TimeoutNowReply)(nil), "TimeoutNowReply")
+	proto.RegisterType((*FetchLogsReq)(nil), "FetchLogsReq")
+	proto.RegisterType((*FetchLogsReply)(nil), "FetchLogsReply")
+}
+
+func init() { proto.RegisterFile("traft.proto", fileDescriptor_39aa4f94ef5dbc0b) }
+
+var fileDescriptor_39aa4f94ef5dbc0b = []byte{
+	// 1621 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x58, 0x3f, 0x6f, 0x1b, 0xcb,
+	0x11, 0xe7, 0xde, 0x1d, 0xff, 0x0d, 0xff, 0x48, 0x5e, 0x48, 0xf2, 0x81, 0x36, 0x68, 0x7a, 0x13,
+	0xdb, 0x34, 0x1c, 0x9f, 0x0d, 0xd9, 0x31, 0x8c, 0x24, 0x08, 0x20, 0xcb, 0x56, 0x44, 0x4b, 0xb6,
+	0x94, 0x95, 0x20, 0x23, 0x01, 0x52, 0x9c, 0x74, 0x4b, 0xf2, 0x60, 0x92, 0x47, 0xef, 0x2d, 0x6d,
+	0x2b, 0x4d, 0x9a, 0xa4, 0x4a, 0x93, 0x2a, 0x45, 0x8a, 0x94, 0x49, 0x90, 0x2a, 0x5d, 0xda, 0x34,
+	0x01, 0x02, 0x04, 0x08, 0x8c, 0x54, 0x29, 0x52, 0x24, 0xf2, 0x17, 0x78, 0x9f, 0xe0, 0xe1, 0x61,
+	0xf7, 0xee, 0x78, 0x77, 0x24, 0x45, 0xd0, 0xcf, 0x7e, 0x70, 0xb7, 0x3b, 0x33, 0xb7, 0x3b, 0xf3,
+	0x9b, 0x99, 0xdf, 0x2c, 0x09, 0x25, 0xc1, 0xed, 0xb6, 0xb0, 0x86, 0xdc, 0x13, 0x5e, 0xed, 0x76,
+	0xc7, 0x15, 0xdd, 0xd1, 0xb1, 0x75, 0xe2, 0xf5, 0xef, 0x74, 0xbc, 0x8e, 0x77, 0x47, 0x89, 0x8f,
+	0x47, 0x6d, 0xb5, 0x53, 0x1b, 0xb5, 0x0a, 0xcc, 0xc9, 0x6f, 0x11, 0xe8, 0x9b, 0x7d, 0x07, 0x57,
+	0x41, 0xdb, 0x1b, 0x9a, 0xd0, 0x40, 0xcd, 0x22, 0xd5, 0xf6, 0x86, 0x78, 0x19, 0xf4, 0x1d, 0x76,
+	0x6a, 0xae, 0x28, 0x81, 0x5c, 0xe2, 0x15, 0x30, 0x8e, 0x0e, 0x04, 0x37, 0xaf, 0x48, 0xd1, 0x76,
+	0x86, 0xaa, 0x9d, 0x92, 0xb6, 0x1e, 0xdc, 0x37, 0x1b, 0x0d, 0xd4, 0xd4, 0x95, 0xb4, 0xf5, 0xe0,
+	0x3e, 0x7e, 0x08, 0xd5, 0xa3, 0xcd, 0xde, 0xc8, 0x17, 0x8c, 0x6f, 0x7a, 0x83, 0xb6, 0xdb, 0x31,
+	0xaf, 0x36, 0x50, 0xb3, 0xb4, 0x5e, 0xb5, 0x52, 0xd2, 0xed, 0x0c, 0x9d, 0xb0, 0x7b, 0x94, 0x87,
+	0xec, 0x91, 0xdd, 0x1b, 0x31, 0x72, 0x04, 0x70, 0x68, 0xbb, 0xbd, 0x47, 0xae, 0xe8, 0xdb, 0x43,
+	0xbc, 0x06, 0xb9, 0xbd, 0x76, 0xdb, 0x67, 0xc2, 0x44, 0xf2, 0x22, 0x1a, 0xee, 0xf0, 0x0a, 0x64,
+	0x5f, 0x78, 0xdc, 0xf1, 0x4d, 0xad, 0xa1, 0x37, 0x0d, 0x1a, 0x6c, 0x70, 0x0d, 0x0a, 0x94, 0x9d,
+	0xf4, 0xec, 0x3e, 0x73, 0x4c, 0x5d, 0xd9, 0x8f, 0xf7, 0xe4, 0x8f, 0x08, 0x72, 0x94, 0x9d, 0x78,
+	0xdc, 0xc1, 0x57, 0x21, 0xb7, 0x31, 0x12, 0x5d, 0x8f, 0xab, 0x43, 0x4b, 0xeb, 0x45, 0x6b, 0x97,
+	0xd9, 0x0e, 0xe3, 0x2d, 0x87, 0x86, 0x0a, 0x09, 0xc3, 0x01, 0x7b, 0xa5, 0x70, 0xd1, 0xa9, 0x5c,
+	0xe2, 0x35, 0x85, 0x97, 0x59, 0x57, 0x5f, 0x18, 0xd6, 0x66, 0xdf, 0xa1, 0x0a, 0xc0, 0x6b, 0x90,
+	0x7f, 0xcc, 0x86, 0x6c, 0xe0, 0xf8, 0x0a, 0x8b, 0xd2, 0x7a, 0xc9, 0x8a, 0xfd, 0xa7, 0x91, 0x0e,
+	0xdf, 0x84, 0xe2, 0xde, 0x6b, 0xc6, 0xb9, 0xeb, 0x30, 0xdf, 0x6c, 0x4e, 0x1b, 0xc6, 0x5a, 0x62,
+	0x41, 0x21, 0xf2, 0x07, 0x63, 0x30, 0x0e, 0x19, 0xef, 0x87, 0xd1, 0xab, 0xb5, 0x4c, 0x59, 0xcb,
+	0x31, 0x35, 0x25, 0xd1, 0x5a, 0x0e, 0xf9, 0x1b, 0x02, 0xe3, 0xb9, 0xe7, 0xb0, 0x50, 0xa1, 0x47,
+	0x0a, 0x7c, 0x1d, 0x72, 0x61, 0x16, 0xd0, 0xac, 0x2c, 0xd0, 0x50, 0x8b, 0x6f, 0x42, 0xee, 0x40,
+	0xd8, 0x62, 0xe4, 0x9b, 0xb9, 0x86, 0xde, 0x2c, 0xad, 0x5f, 0xb0, 0xe4, 0x71, 0x56, 0x20, 0x7b,
+	0x32, 0x10, 0xfc, 0x94, 0x86, 0x06, 0xb5, 0x16, 0x94, 0x12, 0x62, 0x09, 0xd3, 0x4b, 0x76, 0x1a,
+	0x7a, 0x27, 0x97, 0xf8, 0xdb, 0x90, 0x7d, 0x2d, 0xf3, 0x68, 0x6a, 0xe1, 0x95, 0x94, 0x0d, 0x7b,
+	0xee, 0x89, 0x1d, 0x7c, 0x45, 0x03, 0xe5, 0xf7, 0xb4, 0x87, 0xe8, 0xa9, 0x51, 0xd0, 0x96, 0xf5,
+	0xa7, 0x46, 0xc1, 0x58, 0xce, 0x92, 0x9f, 0x41, 0x71, 0xd7, 0xeb, 0x04, 0x36, 0xf8, 0x06, 0x14,
+	0x37, 0xbd, 0x7e, 0xdf, 0x15, 0x82, 0x71, 0xd3, 0x98, 0xcc, 0x50, 0xac, 0xc3, 0x37, 0xa0, 0xb0,
+	0x71, 0x72, 0xc2, 0x86, 0x82, 0x39, 0x26, 0x9a, 0x86, 0x74, 0xac, 0x24, 0x3f, 0x81, 0x72, 0xf0,
+	0x7d, 0x78, 0xc3, 0x35, 0x28, 0x1c, 0x79, 0x82, 0x39, 0x5b, 0x1e, 0x37, 0x61, 0xf2, 0x82, 0xb1,
+	0x0a, 0x13, 0x28, 0xcb, 0xf5, 0x93, 0xb7, 0x43, 0x97, 0xb3, 0x0d, 0x61, 0x96, 0x54, 0x98, 0x29,
+	0x19, 0xf9, 0x12, 0x41, 0x25, 0x15, 0xe2, 0x27, 0x3c, 0xfc, 0xd3, 0x23, 0x21, 0xcb, 0x30, 0xfa,
+	0xca, 0x31, 0xb5, 0x69, 0xcb, 0x58, 0x2b, 0x0b, 0x7b, 0x63, 0x38, 0xec, 0xb9, 0x61, 0x2f, 0x4d,
+	0x16, 0x76, 0xa8, 0x23, 0xbf, 0x80, 0xe2, 0xb6, 0xcd, 0x1d, 0x19, 0x3c, 0xfb, 0x1c, 0xb1, 0x93,
+	0x5f, 0x21, 0x28, 0x1c, 0x0c, 0xec, 0xa1, 0xdf, 0xf5, 0xc4, 0xb9, 0x7c, 0x81, 0xc1, 0x78, 0x6c,
+	0x0b, 0x5b, 0x85, 0x5c, 0xa6, 0x6a, 0xbd, 0x60, 0x80, 0x89, 0x2e, 0x32, 0xe6, 0x75, 0x11, 0xd9,
+	0x02, 0xd8, 0x39, 0x1a, 0x3b, 0x52, 0x83, 0xc2, 0x0e, 0x3b, 0x6d, 0x0d, 0x1c, 0xf6, 0x56, 0xb9,
+	0x52, 0xa6, 0xe3, 0x3d, 0xbe, 0x0c, 0x39, 0xc5, 0x75, 0x01, 0x7b, 0x45, 0x6c, 0x12, 0xca, 0xc8,
+	0x33, 0x28, 0x85, 0x05, 0xd5, 0x1a, 0xb4, 0xbd, 0xb0, 0xa9, 0xd1, 0xb8, 0xa9, 0x31, 0x18, 0x1b,
+	0x8e, 0xc3, 0x55, 0x24, 0x45, 0xaa, 0xd6, 0xf2, 0xb2, 0x7d, 0xcf, 0x77, 0x85, 0xeb, 0x0d, 0x22,
+	0xde, 0x8b, 0xf6, 0xe4, 0xaf, 0x08, 0xe0, 0xc7, 0x23, 0x8f, 0x8f, 0xfa, 0x74, 0xd4, 0x63, 0xf8,
+	0x3b, 0x90, 0x95, 0x30, 0xfb, 0x26, 0x52, 0x57, 0xaf, 0x59, 0xb1, 0xce, 0x52, 0x8a, 0xa0, 0xdf,
+	0x03, 0x23, 0xfc, 0x2d, 0xc8, 0xfd, 0x88, 0x7b, 0xa3, 0x61, 0xe4, 0x69, 0x29, 0x61, 0x4e, 0x43,
+	0x15, 0xbe, 0x0c, 0xc5, 0xc3, 0x2e, 0x67, 0x7e, 0xd7, 0xeb, 0x45, 0xec, 0x13, 0x0b, 0x6a, 0x0f,
+	0x01, 0xe2, 0x73, 0x67, 0x10, 0xc6, 0x4a, 0x92, 0x30, 0xf4, 0x04, 0x41, 0x90, 0x7f, 0x6b, 0x50,
+	0x49, 0x41, 0x8d, 0xbf, 0x0b, 0xf9, 0x67, 0xac, 0x7f, 0xcc, 0xb8, 0x6f, 0x96, 0x94, 0x3f, 0x97,
+	0xd2, 0xb9, 0xb0, 0x42, 0x6d, 0x10, 0x43, 0x64, 0x8b, 0x4d, 0xc8, 0x07, 0x6e, 0xfb, 0xe6, 0xaa,
+	0x1a, 0x17, 0xd1, 0x16, 0x37, 0xa0, 0x24, 0x9d, 0x8b, 0xb4, 0x6b, 0x4a, 0x9b, 0x14, 0xe1, 0x5b,
+	0x49, 0xf4, 0xcc, 0x8b, 0x61, 0x9d, 0xc4, 0x22, 0x9a, 0x04, 0xf7, 0x1e, 0x54, 0xe3, 0x6f, 0xd5,
+	0x07, 0xe6, 0xf4, 0x07, 0x13, 0x26, 0x98, 0x80, 0xf1, 0x9c, 0xbd, 0x15, 0xe6, 0x95, 0x99, 0xd5,
+	0xa5, 0x74, 0xb5, 0x6d, 0x28, 0x27, 0x43, 0x9b, 0x01, 0x23, 0x49, 0xf3, 0x6e, 0xd9, 0x4a, 0xd4,
+	0x50, 0x12, 0xd4, 0x5f, 0x22, 0xc8, 0x4b, 0x07, 0x28, 0x7b, 0xa5, 0x5a, 0xcc, 0x1e, 0x38, 0xae,
+	0x63, 0x0b, 0x36, 0x3d, 0x0a, 0x63, 0x5d, 0xba, 0x17, 0xb5, 0x05, 0x79, 0x48, 0x9f, 0xc7, 0xc8,
+	0xff, 0x45, 0x50, 0x0c, 0xdc, 0x18, 0xf6, 0x4e, 0xa7, 0x6a, 0x7c, 0x41, 0x1a, 0xf9, 0x5a, 0xf4,
+	0xb8, 0xba, 0x30, 0x3d, 0xae, 0xcd, 0xa5, 0xc7, 0x4b, 0x60, 0xec, 0x7a, 0x1d, 0xdf, 0xac, 0xab,
+	0x42, 0xcc, 0x5b, 0xc1, 0xdb, 0x82, 0x2a, 0x21, 0xf9, 0x3d, 0x82, 0xca, 0xae, 0xd7, 0xd9, 0xf2,
+	0xf8, 0x1b, 0x9b, 0x3b, 0x11, 0xd6, 0x63, 0x5f, 0xd1, 0x1c, 0x5f, 0xa3, 0x73, 0xb5, 0x19, 0xe7,
+	0xa6, 0xfd, 0xd3, 0xe7, 0xfa, 0x77, 0x19, 0x8a, 0xdb, 0xcc, 0xe6, 0xe2, 0x98, 0xd9, 0x42, 0x81,
+	0x53, 0xa0, 0xb1, 0x80, 0xfc, 0x1d, 0xc1, 0x52, 0xd2, 0xc1, 0x30, 0x0b, 0x7b, 0x3b, 0x0a, 0xef,
+	0x02, 0xd5, 0xf6, 0x76, 0x52, 0x59, 0x40, 0xf3, 0xb2, 0x10, 0x83, 0xab, 0x2d, 0x0c, 0xee, 0x7c,
+	0xe7, 0x17, 0x26, 0xff, 0x7f, 0x21, 0xa8, 0x44, 0x9c, 0xbb, 0xd9, 0x1d, 0x0d, 0x5e, 0x2e, 0x0e,
+	0xf4, 0x75, 0xa8, 0x46, 0x5f, 0x86, 0x23, 0x23, 0x60, 0xa0, 0x09, 0xa9, 0x1c, 0x56, 0x91, 0xe4,
+	0xc0, 0xfd, 0x39, 0x0b, 0x19, 0x2e, 0x25, 0x93, 0x3c, 0xa2, 0x6e, 0x0f, 0x0f, 0x32, 0x94, 0x49,
+	0x52, 0x34, 0x1e, 0x40, 0xd9, 0xc4, 0x00, 0x92, 0x32, 0x6f, 0xc0, 0xcc, 0x9c, 0x82, 0x5c, 0xad,
+	0xc9, 0x3f, 0x11, 0xac, 0xb4, 0x06, 0xbe, 0xb0, 0x7b, 0xbd, 0xe8, 0x86, 0x64, 0x76, 0xd0, 0xcc,
+	0xec, 0x68, 0xe7, 0x67, 0xa7, 0x09, 0x4b, 0x92, 0x41, 0x92, 0xde, 0x05, 0x01, 0x4c, 0x8a, 0x53,
+	0x79, 0x34, 0x16, 0xce, 0x63, 0x76, 0x5e, 0x1e, 0xe5, 0x4c, 0xdc, 0xe7, 0xde, 0xd0, 0xf3, 0x19,
+	0x8d, 0x9f, 0xd0, 0x68, 0xf2, 0x09, 0xdd, 0x80, 0xd2, 0x0b, 0xdb, 0x15, 0xd1, 0x30, 0xd6, 0x54,
+	0x8c, 0x49, 0x11, 0xf9, 0x0b, 0x82, 0xf2, 0xf8, 0xa0, 0x18, 0x0d, 0x6d, 0x8c, 0xc6, 0x32, 0xe8,
+	0x4f, 0x38, 0x57, 0xa1, 0x15, 0xa9, 0x5c, 0xe2, 0x5b, 0x50, 0xda, 0x13, 0x5d, 0xc6, 0x03, 0x4c,
+	0xa6, 0x2b, 0x21, 0xa9, 0x95, 0xcf, 0x06, 0xca, 0xfc, 0x51, 0x2f, 0x48, 0x5d, 0x99, 0x86, 0xbb,
+	0xe8, 0x67, 0x40, 0x36, 0xfe, 0x19, 0x90, 0x2a, 0xaf, 0xdc, 0x9c, 0xca, 0xbc, 0x07, 0xab, 0x87,
+	0xdc, 0x1e, 0xf8, 0xed, 0xe8, 0x12, 0xbf, 0xeb, 0x0e, 0x25, 0x0a, 0x35, 0x28, 0x1c, 0xda, 0xbc,
+	0xc3, 0xc4, 0x98, 0xf2, 0xc6, 0x7b, 0xd2, 0x85, 0x8b, 0xb3, 0x3e, 0x9a, 0x95, 0xff, 0x30, 0x62,
+	0xed, 0xdc, 0x88, 0xf5, 0x79, 0x11, 0x93, 0xa7, 0xb0, 0xbc, 0xd9, 0xb5, 0x07, 0x1d, 0x16, 0xce,
+	0x15, 0xe9, 0x59, 0x1d, 0xf4, 0x0d, 0xc7, 0x09, 0x5f, 0x06, 0xe9, 0x09, 0x22, 0x15, 0x01, 0x4a,
+	0x7d, 0xef, 0x35, 0x53, 0xe4, 0xa4, 0xd3, 0x70, 0x47, 0xfe, 0x80, 0x60, 0x35, 0x75, 0xd8, 0x3e,
+	0xf7, 0x3a, 0x9c, 0xf9, 0xbe, 0x1c, 0xee, 0x07, 0xc2, 0xee, 0x04, 0xd3, 0xa5, 0x48, 0x83, 0xcd,
+	0x47, 0xba, 0xbe, 0xe8, 0x83, 0x6c, 0x3a, 0x79, 0xc4, 0x86, 0xca, 0xa1, 0xdb, 0x67, 0xde, 0x48,
+	0x3c, 0xf7, 0xde, 0x7c, 0x10, 0x2b, 0x2f, 0x4a, 0x72, 0xe4, 0x77, 0x08, 0x96, 0x92, 0x77, 0x7c,
+	0x44, 0xeb, 0xa6, 0x9c, 0xd3, 0x17, 0x74, 0x6e, 0x5e, 0xe7, 0x92, 0x5f, 0x23, 0x28, 0x6f, 0x31,
+	0x71, 0xd2, 0x95, 0xc3, 0xe4, 0x1b, 0x89, 0xff, 0x03, 0x48, 0x9e, 0xfc, 0x19, 0x41, 0x35, 0xe1,
+	0x8d, 0x44, 0xea, 0x73, 0xfa, 0x33, 0x9e, 0xbc, 0xc6, 0x8c, 0xc9, 0xbb, 0xfe, 0x85, 0x0e, 0xd9,
+	0x43, 0x6a, 0xb7, 0x05, 0xae, 0x83, 0x21, 0x53, 0x84, 0x0b, 0x56, 0xf8, 0x8e, 0xaa, 0x81, 0x35,
+	0x7e, 0xca, 0x90, 0x0c, 0xbe, 0x0a, 0xf9, 0x7d, 0xce, 0xe6, 0x9a, 0xdc, 0x05, 0x88, 0x87, 0x2f,
+	0xae, 0x5a, 0xa9, 0xa7, 0x42, 0x6d, 0xd9, 0x9a, 0x98, 0xcc, 0x24, 0x83, 0x1b, 0x90, 0x0f, 0xf9,
+	0x0f, 0x2b, 0xe2, 0xac, 0x55, 0xac, 0x24, 0x1f, 0x92, 0x0c, 0xbe, 0x01, 0xd5, 0x50, 0x12, 0xfd,
+	0x70, 0x39, 0xc7, 0xf0, 0x07, 0xb0, 0x34, 0x31, 0x60, 0x70, 0xd5, 0x4a, 0xcd, 0xd0, 0xda, 0xaa,
+	0x35, 0x6b, 0x04, 0x91, 0x4c, 0x13, 0xe1, 0x6d, 0xc0, 0xd3, 0x0c, 0x85, 0xd7, 0xac, 0x99, 0x5c,
+	0x57, 0x33, 0xad, 0x73, 0xe8, 0x8c, 0x64, 0xf0, 0x0f, 0xa1, 0x92, 0x22, 0x0d, 0x7c, 0xc1, 0x9a,
+	0x64, 0xa4, 0xda, 0x9a, 0x35, 0x93, 0x57, 0x48, 0xe6, 0x2e, 0x92, 0x20, 0xc6, 0x8d, 0x86, 0xab,
+	0x56, 0xaa, 0xb3, 0x6b, 0xcb, 0xd6, 0x44, 0x17, 0x92, 0x0c, 0xbe, 0x0d, 0xc5, 0x71, 0xbd, 0xe1,
+	0x8a, 0x95, 0xec, 0x84, 0xda, 0x92, 0x95, 0x2e, 0x45, 0x92, 0x79, 0x74, 0xf3, 0xdd, 0xff, 0xeb,
+	0x99, 0x3f, 0x9d, 0xd5, 0xd1, 0x3f, 0xce, 0xea, 0xe8, 0xdd, 0x59, 0x1d, 0xfd, 0xef, 0xac, 0x8e,
+	0x7e, 0xf3, 0xbe, 0x9e, 0x79, 0xf7, 0xbe, 0x9e, 0xf9, 0xcf, 0xfb, 0x7a, 0xe6, 0xa7, 0x79, 0xeb,
+	0xfb, 0xea, 0x2f, 0xb8, 0xe3, 0x9c, 0xfa, 0x53, 0xed, 0xde, 0x57, 0x03, 0x00, 0x35, 0xca, 0x59,
+	0x8b, 0x92, 0x13, 0x00, 0x00,
+}
+
+func (this *Cmd) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*ReplicaStatus)
+	that1, ok := that.(*Cmd)
 	if !ok {
-		that2, ok := that.(ReplicaStatus)
+		that2, ok := that.(Cmd)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1518,34 +2347,31 @@ func (this *ReplicaStatus) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if !this.VotedFor.Equal(that1.VotedFor) {
-		return false
-	}
-	if this.VoteExpireAt != that1.VoteExpireAt {
-		return false
-	}
-	if !this.Committer.Equal(that1.Committer) {
+	if this.Op != that1.Op {
 		return false
 	}
-	if !this.Accepted.Equal(that1.Accepted) {
+	if this.Key != that1.Key {
 		return false
 	}
-	if !this.Committed.Equal(that1.Committed) {
+	if that1.Value == nil {
+		if this.Value != nil {
+			return false
+		}
+	} else if this.Value == nil {
 		return false
-	}
-	if !this.Applied.Equal(that1.Applied) {
+	} else if !this.Value.Equal(that1.Value) {
 		return false
 	}
 	return true
 }
-func (this *ReplicaInfo) Equal(that interface{}) bool {
+func (this *Cmd_VStr) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*ReplicaInfo)
+	that1, ok := that.(*Cmd_VStr)
 	if !ok {
-		that2, ok := that.(ReplicaInfo)
+		that2, ok := that.(Cmd_VStr)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1557,25 +2383,19 @@ func (this *ReplicaInfo) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.Id != that1.Id {
-		return false
-	}
-	if this.Addr != that1.Addr {
-		return false
-	}
-	if this.Position != that1.Position {
+	if this.VStr != that1.VStr {
 		return false
 	}
 	return true
 }
-func (this *ClusterConfig) Equal(that interface{}) bool {
+func (this *Cmd_VI64) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*ClusterConfig)
+	that1, ok := that.(*Cmd_VI64)
 	if !ok {
-		that2, ok := that.(ClusterConfig)
+		that2, ok := that.(Cmd_VI64)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1587,32 +2407,19 @@ func (this *ClusterConfig) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Members) != len(that1.Members) {
-		return false
-	}
-	for i := range this.Members {
-		if !this.Members[i].Equal(that1.Members[i]) {
-			return false
-		}
-	}
-	if len(this.Quorums) != len(that1.Quorums) {
+	if this.VI64 != that1.VI64 {
 		return false
 	}
-	for i := range this.Quorums {
-		if this.Quorums[i] != that1.Quorums[i] {
-			return false
-		}
-	}
 	return true
 }
-func (this *VoteReq) Equal(that interface{}) bool {
+func (this *Cmd_VClusterConfig) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*VoteReq)
+	that1, ok := that.(*Cmd_VClusterConfig)
 	if !ok {
-		that2, ok := that.(VoteReq)
+		that2, ok := that.(Cmd_VClusterConfig)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1624,25 +2431,19 @@ func (this *VoteReq) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if !this.Candidate.Equal(that1.Candidate) {
-		return false
-	}
-	if !this.Committer.Equal(that1.Committer) {
-		return false
-	}
-	if !this.Accepted.Equal(that1.Accepted) {
+	if !this.VClusterConfig.Equal(that1.VClusterConfig) {
 		return false
 	}
 	return true
 }
-func (this *VoteReply) Equal(that interface{}) bool {
+func (this *TailBitmap) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*VoteReply)
+	that1, ok := that.(*TailBitmap)
 	if !ok {
-		that2, ok := that.(VoteReply)
+		that2, ok := that.(TailBitmap)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1654,39 +2455,93 @@ func (this *VoteReply) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.Id != that1.Id {
+	if this.Offset != that1.Offset {
 		return false
 	}
-	if !this.VotedFor.Equal(that1.VotedFor) {
+	if len(this.Words) != len(that1.Words) {
 		return false
 	}
-	if !this.Committer.Equal(that1.Committer) {
+	for i := range this.Words {
+		if this.Words[i] != that1.Words[i] {
+			return false
+		}
+	}
+	if this.Reclamed != that1.Reclamed {
 		return false
 	}
-	if !this.Accepted.Equal(that1.Accepted) {
+	return true
+}
+func (this *Record) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Record)
+	if !ok {
+		that2, ok := that.(Record)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
-	if !this.Committed.Equal(that1.Committed) {
+	if !this.Author.Equal(that1.Author) {
 		return false
 	}
-	if len(this.Logs) != len(that1.Logs) {
+	if this.Seq != that1.Seq {
 		return false
 	}
-	for i := range this.Logs {
-		if !this.Logs[i].Equal(that1.Logs[i]) {
+	if !this.Cmd.Equal(that1.Cmd) {
+		return false
+	}
+	if !this.Depends.Equal(that1.Depends) {
+		return false
+	}
+	if !this.Overrides.Equal(that1.Overrides) {
+		return false
+	}
+	return true
+}
+func (this *LeaderId) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LeaderId)
+	if !ok {
+		that2, ok := that.(LeaderId)
+		if ok {
+			that1 = &that2
+		} else {
 			return false
 		}
 	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Term != that1.Term {
+		return false
+	}
+	if this.Id != that1.Id {
+		return false
+	}
 	return true
 }
-func (this *LogForwardReq) Equal(that interface{}) bool {
+func (this *Node) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LogForwardReq)
+	that1, ok := that.(*Node)
 	if !ok {
-		that2, ok := that.(LogForwardReq)
+		that2, ok := that.(Node)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1698,27 +2553,30 @@ func (this *LogForwardReq) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if !this.Committer.Equal(that1.Committer) {
+	if this.Id != that1.Id {
 		return false
 	}
-	if len(this.Logs) != len(that1.Logs) {
+	if !this.Config.Equal(that1.Config) {
 		return false
 	}
-	for i := range this.Logs {
-		if !this.Logs[i].Equal(that1.Logs[i]) {
+	if len(this.Status) != len(that1.Status) {
+		return false
+	}
+	for i := range this.Status {
+		if !this.Status[i].Equal(that1.Status[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LogForwardReply) Equal(that interface{}) bool {
+func (this *LogStatus) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LogForwardReply)
+	that1, ok := that.(*LogStatus)
 	if !ok {
-		that2, ok := that.(LogForwardReply)
+		that2, ok := that.(LogStatus)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1730,28 +2588,88 @@ func (this *LogForwardReply) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.OK != that1.OK {
+	if !this.Committer.Equal(that1.Committer) {
+		return false
+	}
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
+	}
+	return true
+}
+func (this *LeaderStatus) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LeaderStatus)
+	if !ok {
+		that2, ok := that.(LeaderStatus)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.VotedFor.Equal(that1.VotedFor) {
+		return false
+	}
+	if this.VoteExpireAt != that1.VoteExpireAt {
+		return false
+	}
+	return true
+}
+func (this *ReplicaStatus) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ReplicaStatus)
+	if !ok {
+		that2, ok := that.(ReplicaStatus)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
 	if !this.VotedFor.Equal(that1.VotedFor) {
 		return false
 	}
+	if this.VoteExpireAt != that1.VoteExpireAt {
+		return false
+	}
+	if !this.Committer.Equal(that1.Committer) {
+		return false
+	}
 	if !this.Accepted.Equal(that1.Accepted) {
 		return false
 	}
 	if !this.Committed.Equal(that1.Committed) {
 		return false
 	}
+	if !this.Applied.Equal(that1.Applied) {
+		return false
+	}
 	return true
 }
-func (this *ProposeReply) Equal(that interface{}) bool {
+func (this *HardState) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*ProposeReply)
+	that1, ok := that.(*HardState)
 	if !ok {
-		that2, ok := that.(ProposeReply)
+		that2, ok := that.(HardState)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1763,1530 +2681,6030 @@ func (this *ProposeReply) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.OK != that1.OK {
+	if !this.VotedFor.Equal(that1.VotedFor) {
 		return false
 	}
-	if this.Err != that1.Err {
+	if this.VoteExpireAt != that1.VoteExpireAt {
 		return false
 	}
-	if !this.OtherLeader.Equal(that1.OtherLeader) {
+	if !this.Committer.Equal(that1.Committer) {
 		return false
 	}
 	return true
 }
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// TRaftClient is the client API for TRaft service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type TRaftClient interface {
-	Vote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error)
-	LogForward(ctx context.Context, in *LogForwardReq, opts ...grpc.CallOption) (*LogForwardReply, error)
-	Propose(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error)
-}
-
-type tRaftClient struct {
-	cc *grpc.ClientConn
-}
-
-func NewTRaftClient(cc *grpc.ClientConn) TRaftClient {
-	return &tRaftClient{cc}
-}
-
-func (c *tRaftClient) Vote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error) {
-	out := new(VoteReply)
-	err := c.cc.Invoke(ctx, "/TRaft/Vote", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (this *Snapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return out, nil
-}
 
-func (c *tRaftClient) LogForward(ctx context.Context, in *LogForwardReq, opts ...grpc.CallOption) (*LogForwardReply, error) {
-	out := new(LogForwardReply)
-	err := c.cc.Invoke(ctx, "/TRaft/LogForward", in, out, opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*Snapshot)
+	if !ok {
+		that2, ok := that.(Snapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	return out, nil
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Offset != that1.Offset {
+		return false
+	}
+	if !bytes.Equal(this.Data, that1.Data) {
+		return false
+	}
+	if !this.Applied.Equal(that1.Applied) {
+		return false
+	}
+	if !this.Config.Equal(that1.Config) {
+		return false
+	}
+	return true
 }
+func (this *KVSnapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (c *tRaftClient) Propose(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error) {
-	out := new(ProposeReply)
-	err := c.cc.Invoke(ctx, "/TRaft/Propose", in, out, opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*KVSnapshot)
+	if !ok {
+		that2, ok := that.(KVSnapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	return out, nil
-}
-
-// TRaftServer is the server API for TRaft service.
-type TRaftServer interface {
-	Vote(context.Context, *VoteReq) (*VoteReply, error)
-	LogForward(context.Context, *LogForwardReq) (*LogForwardReply, error)
-	Propose(context.Context, *Cmd) (*ProposeReply, error)
-}
-
-// UnimplementedTRaftServer can be embedded to have forward compatible implementations.
-type UnimplementedTRaftServer struct {
-}
-
-func (*UnimplementedTRaftServer) Vote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Vote not implemented")
-}
-func (*UnimplementedTRaftServer) LogForward(ctx context.Context, req *LogForwardReq) (*LogForwardReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method LogForward not implemented")
-}
-func (*UnimplementedTRaftServer) Propose(ctx context.Context, req *Cmd) (*ProposeReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Propose not implemented")
-}
-
-func RegisterTRaftServer(s *grpc.Server, srv TRaftServer) {
-	s.RegisterService(&_TRaft_serviceDesc, srv)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.KeyIndex, that1.KeyIndex) {
+		return false
+	}
+	if len(this.Values) != len(that1.Values) {
+		return false
+	}
+	for i := range this.Values {
+		if !this.Values[i].Equal(that1.Values[i]) {
+			return false
+		}
+	}
+	return true
 }
+func (this *ReplicaInfo) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _TRaft_Vote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VoteReq)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*ReplicaInfo)
+	if !ok {
+		that2, ok := that.(ReplicaInfo)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(TRaftServer).Vote(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/TRaft/Vote",
+	if this.Id != that1.Id {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(TRaftServer).Vote(ctx, req.(*VoteReq))
+	if this.Addr != that1.Addr {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
+	if this.Position != that1.Position {
+		return false
+	}
+	return true
 }
+func (this *QuorumRule) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _TRaft_LogForward_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LogForwardReq)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*QuorumRule)
+	if !ok {
+		that2, ok := that.(QuorumRule)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(TRaftServer).LogForward(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/TRaft/LogForward",
+	if len(this.Votes) != len(that1.Votes) {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(TRaftServer).LogForward(ctx, req.(*LogForwardReq))
+	for i := range this.Votes {
+		if this.Votes[i] != that1.Votes[i] {
+			return false
+		}
 	}
-	return interceptor(ctx, in, info, handler)
+	if len(this.Groups) != len(that1.Groups) {
+		return false
+	}
+	for i := range this.Groups {
+		if !this.Groups[i].Equal(that1.Groups[i]) {
+			return false
+		}
+	}
+	if this.Threshold != that1.Threshold {
+		return false
+	}
+	return true
 }
+func (this *ClusterConfig) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _TRaft_Propose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Cmd)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*ClusterConfig)
+	if !ok {
+		that2, ok := that.(ClusterConfig)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(TRaftServer).Propose(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/TRaft/Propose",
+	if len(this.Members) != len(that1.Members) {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(TRaftServer).Propose(ctx, req.(*Cmd))
+	for i := range this.Members {
+		if !this.Members[i].Equal(that1.Members[i]) {
+			return false
+		}
 	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _TRaft_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "TRaft",
-	HandlerType: (*TRaftServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Vote",
-			Handler:    _TRaft_Vote_Handler,
-		},
-		{
-			MethodName: "LogForward",
-			Handler:    _TRaft_LogForward_Handler,
-		},
-		{
-			MethodName: "Propose",
-			Handler:    _TRaft_Propose_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "traft.proto",
-}
-
-func (m *Cmd) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if len(this.Quorums) != len(that1.Quorums) {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *Cmd) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *Cmd) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Value != nil {
-		{
-			size := m.Value.Size()
-			i -= size
-			if _, err := m.Value.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
+	for i := range this.Quorums {
+		if this.Quorums[i] != that1.Quorums[i] {
+			return false
 		}
 	}
-	if len(m.Key) > 0 {
-		i -= len(m.Key)
-		copy(dAtA[i:], m.Key)
-		i = encodeVarintTraft(dAtA, i, uint64(len(m.Key)))
-		i--
-		dAtA[i] = 0x1
-		i--
-		dAtA[i] = 0xa2
+	if len(this.VoteQuorums) != len(that1.VoteQuorums) {
+		return false
 	}
-	if len(m.Op) > 0 {
-		i -= len(m.Op)
-		copy(dAtA[i:], m.Op)
-		i = encodeVarintTraft(dAtA, i, uint64(len(m.Op)))
-		i--
-		dAtA[i] = 0x52
+	for i := range this.VoteQuorums {
+		if this.VoteQuorums[i] != that1.VoteQuorums[i] {
+			return false
+		}
 	}
-	return len(dAtA) - i, nil
+	if !this.QuorumRule.Equal(that1.QuorumRule) {
+		return false
+	}
+	if !this.VoteQuorumRule.Equal(that1.VoteQuorumRule) {
+		return false
+	}
+	if !this.Next.Equal(that1.Next) {
+		return false
+	}
+	return true
 }
+func (this *VoteReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *Cmd_VStr) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	that1, ok := that.(*VoteReq)
+	if !ok {
+		that2, ok := that.(VoteReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Candidate.Equal(that1.Candidate) {
+		return false
+	}
+	if !this.Committer.Equal(that1.Committer) {
+		return false
+	}
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
+	}
+	return true
 }
+func (this *VoteReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *Cmd_VStr) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	i -= len(m.VStr)
-	copy(dAtA[i:], m.VStr)
-	i = encodeVarintTraft(dAtA, i, uint64(len(m.VStr)))
-	i--
-	dAtA[i] = 0x1
-	i--
-	dAtA[i] = 0xfa
-	return len(dAtA) - i, nil
-}
-func (m *Cmd_VI64) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *Cmd_VI64) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	i = encodeVarintTraft(dAtA, i, uint64(m.VI64))
-	i--
-	dAtA[i] = 0x2
-	i--
-	dAtA[i] = 0x80
-	return len(dAtA) - i, nil
-}
-func (m *Cmd_VClusterConfig) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *Cmd_VClusterConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.VClusterConfig != nil {
-		{
-			size, err := m.VClusterConfig.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	that1, ok := that.(*VoteReply)
+	if !ok {
+		that2, ok := that.(VoteReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x2
-		i--
-		dAtA[i] = 0x8a
 	}
-	return len(dAtA) - i, nil
-}
-func (m *TailBitmap) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *TailBitmap) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *TailBitmap) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Reclamed != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Reclamed))
-		i--
-		dAtA[i] = 0x18
+	if this.Id != that1.Id {
+		return false
 	}
-	if len(m.Words) > 0 {
-		dAtA3 := make([]byte, len(m.Words)*10)
-		var j2 int
-		for _, num := range m.Words {
-			for num >= 1<<7 {
-				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
-				num >>= 7
-				j2++
-			}
-			dAtA3[j2] = uint8(num)
-			j2++
-		}
-		i -= j2
-		copy(dAtA[i:], dAtA3[:j2])
-		i = encodeVarintTraft(dAtA, i, uint64(j2))
-		i--
-		dAtA[i] = 0x12
+	if !this.VotedFor.Equal(that1.VotedFor) {
+		return false
 	}
-	if m.Offset != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Offset))
-		i--
-		dAtA[i] = 0x8
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *Record) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *Record) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if !this.Committed.Equal(that1.Committed) {
+		return false
+	}
+	if len(this.Logs) != len(that1.Logs) {
+		return false
+	}
+	for i := range this.Logs {
+		if !this.Logs[i].Equal(that1.Logs[i]) {
+			return false
+		}
+	}
+	return true
 }
+func (this *LogForwardReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *Record) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Overrides != nil {
-		{
-			size, err := m.Overrides.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	that1, ok := that.(*LogForwardReq)
+	if !ok {
+		that2, ok := that.(LogForwardReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x2
-		i--
-		dAtA[i] = 0xc2
 	}
-	if m.Depends != nil {
-		{
-			size, err := m.Depends.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x2
-		i--
-		dAtA[i] = 0x82
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Cmd != nil {
-		{
-			size, err := m.Cmd.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1
-		i--
-		dAtA[i] = 0xf2
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	if m.Seq != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Seq))
-		i--
-		dAtA[i] = 0x50
+	if len(this.Logs) != len(that1.Logs) {
+		return false
 	}
-	if m.Author != nil {
-		{
-			size, err := m.Author.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	for i := range this.Logs {
+		if !this.Logs[i].Equal(that1.Logs[i]) {
+			return false
 		}
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
-}
-
-func (m *LeaderId) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LeaderId) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *LeaderId) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Id != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
-		i--
-		dAtA[i] = 0x10
+	if !this.Committed.Equal(that1.Committed) {
+		return false
 	}
-	if m.Term != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Term))
-		i--
-		dAtA[i] = 0x8
+	if this.Heartbeat != that1.Heartbeat {
+		return false
 	}
-	return len(dAtA) - i, nil
+	return true
 }
-
-func (m *Node) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LogForwardReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
-
-func (m *Node) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
 
-func (m *Node) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Status) > 0 {
-		for k := range m.Status {
-			v := m.Status[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintTraft(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i = encodeVarintTraft(dAtA, i, uint64(k))
-			i--
-			dAtA[i] = 0x8
-			i = encodeVarintTraft(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x32
+	that1, ok := that.(*LogForwardReply)
+	if !ok {
+		that2, ok := that.(LogForwardReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
 	}
-	if m.LogOffset != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.LogOffset))
-		i--
-		dAtA[i] = 0x20
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Id != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
-		i--
-		dAtA[i] = 0x18
+	if this.OK != that1.OK {
+		return false
 	}
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintTraft(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
-		}
+	if !this.VotedFor.Equal(that1.VotedFor) {
+		return false
 	}
-	if m.Config != nil {
-		{
-			size, err := m.Config.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *LogStatus) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if !this.Committed.Equal(that1.Committed) {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LogStatus) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if !this.Committer.Equal(that1.Committer) {
+		return false
+	}
+	return true
 }
+func (this *SnapshotChunk) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *LogStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Committer != nil {
-		{
-			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	that1, ok := that.(*SnapshotChunk)
+	if !ok {
+		that2, ok := that.(SnapshotChunk)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x22
 	}
-	if m.Accepted != nil {
-		{
-			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *LeaderStatus) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LeaderStatus) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *LeaderStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.VoteExpireAt != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.VoteExpireAt))
-		i--
-		dAtA[i] = 0x58
+	if this.SnapshotOffset != that1.SnapshotOffset {
+		return false
 	}
-	if m.VotedFor != nil {
-		{
-			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x52
+	if this.SnapshotSize != that1.SnapshotSize {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *ReplicaStatus) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if this.ChunkOffset != that1.ChunkOffset {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ReplicaStatus) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if !bytes.Equal(this.Data, that1.Data) {
+		return false
+	}
+	if this.Done != that1.Done {
+		return false
+	}
+	return true
 }
-
-func (m *ReplicaStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.VoteExpireAt != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.VoteExpireAt))
-		i--
-		dAtA[i] = 0x58
+func (this *InstallSnapshotReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	if m.VotedFor != nil {
-		{
-			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+
+	that1, ok := that.(*InstallSnapshotReply)
+	if !ok {
+		that2, ok := that.(InstallSnapshotReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x52
 	}
-	if m.Committer != nil {
-		{
-			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x22
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Applied != nil {
-		{
-			size, err := m.Applied.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
+	if this.OK != that1.OK {
+		return false
 	}
-	if m.Committed != nil {
-		{
-			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+	if !this.VotedFor.Equal(that1.VotedFor) {
+		return false
 	}
-	if m.Accepted != nil {
-		{
-			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if this.NextChunkOffset != that1.NextChunkOffset {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *ReplicaInfo) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ReplicaInfo) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if !this.Committed.Equal(that1.Committed) {
+		return false
+	}
+	return true
 }
+func (this *ProposeReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *ReplicaInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Position != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Position))
-		i--
-		dAtA[i] = 0x18
+	that1, ok := that.(*ProposeReq)
+	if !ok {
+		that2, ok := that.(ProposeReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if len(m.Addr) > 0 {
-		i -= len(m.Addr)
-		copy(dAtA[i:], m.Addr)
-		i = encodeVarintTraft(dAtA, i, uint64(len(m.Addr)))
-		i--
-		dAtA[i] = 0x12
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Id != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
-		i--
-		dAtA[i] = 0x8
+	if !this.Cmd.Equal(that1.Cmd) {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *ClusterConfig) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if this.WaitApplied != that1.WaitApplied {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ClusterConfig) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	return true
 }
+func (this *ProposeReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *ClusterConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Quorums) > 0 {
-		dAtA19 := make([]byte, len(m.Quorums)*10)
-		var j18 int
-		for _, num := range m.Quorums {
-			for num >= 1<<7 {
-				dAtA19[j18] = uint8(uint64(num)&0x7f | 0x80)
-				num >>= 7
-				j18++
-			}
-			dAtA19[j18] = uint8(num)
-			j18++
+	that1, ok := that.(*ProposeReply)
+	if !ok {
+		that2, ok := that.(ProposeReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i -= j18
-		copy(dAtA[i:], dAtA19[:j18])
-		i = encodeVarintTraft(dAtA, i, uint64(j18))
-		i--
-		dAtA[i] = 0x1
-		i--
-		dAtA[i] = 0xaa
 	}
-	if len(m.Members) > 0 {
-		for k := range m.Members {
-			v := m.Members[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintTraft(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i = encodeVarintTraft(dAtA, i, uint64(k))
-			i--
-			dAtA[i] = 0x8
-			i = encodeVarintTraft(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x5a
-		}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *VoteReq) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if this.OK != that1.OK {
+		return false
 	}
-	return dAtA[:n], nil
+	if this.Err != that1.Err {
+		return false
+	}
+	if !this.OtherLeader.Equal(that1.OtherLeader) {
+		return false
+	}
+	if !bytes.Equal(this.Result, that1.Result) {
+		return false
+	}
+	if this.Seq != that1.Seq {
+		return false
+	}
+	if !this.Committer.Equal(that1.Committer) {
+		return false
+	}
+	return true
 }
+func (this *TransferLeadershipReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *VoteReq) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	that1, ok := that.(*TransferLeadershipReq)
+	if !ok {
+		that2, ok := that.(TransferLeadershipReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TargetId != that1.TargetId {
+		return false
+	}
+	return true
 }
+func (this *TransferLeadershipReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *VoteReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Accepted != nil {
-		{
-			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	that1, ok := that.(*TransferLeadershipReply)
+	if !ok {
+		that2, ok := that.(TransferLeadershipReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x1a
 	}
-	if m.Committer != nil {
-		{
-			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Candidate != nil {
-		{
-			size, err := m.Candidate.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if this.OK != that1.OK {
+		return false
 	}
-	return len(dAtA) - i, nil
+	if this.Err != that1.Err {
+		return false
+	}
+	if !this.OtherLeader.Equal(that1.OtherLeader) {
+		return false
+	}
+	return true
 }
+func (this *ChangeMembersReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *VoteReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
-}
-
-func (m *VoteReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *VoteReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintTraft(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1
-			i--
-			dAtA[i] = 0xf2
+	that1, ok := that.(*ChangeMembersReq)
+	if !ok {
+		that2, ok := that.(ChangeMembersReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
 	}
-	if m.Committed != nil {
-		{
-			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1
-		i--
-		dAtA[i] = 0xb2
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Accepted != nil {
-		{
-			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1
-		i--
-		dAtA[i] = 0xaa
+	if len(this.Add) != len(that1.Add) {
+		return false
 	}
-	if m.VotedFor != nil {
-		{
-			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+	for i := range this.Add {
+		if !this.Add[i].Equal(that1.Add[i]) {
+			return false
 		}
-		i--
-		dAtA[i] = 0x52
 	}
-	if m.Committer != nil {
-		{
-			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x22
+	if len(this.Remove) != len(that1.Remove) {
+		return false
 	}
-	if m.Id != 0 {
-		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
-		i--
-		dAtA[i] = 0x8
+	for i := range this.Remove {
+		if this.Remove[i] != that1.Remove[i] {
+			return false
+		}
 	}
-	return len(dAtA) - i, nil
+	return true
 }
-
-func (m *LogForwardReq) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *ChangeMembersProgress) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LogForwardReq) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
 
-func (m *LogForwardReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Logs) > 0 {
-		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintTraft(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
+	that1, ok := that.(*ChangeMembersProgress)
+	if !ok {
+		that2, ok := that.(ChangeMembersProgress)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
 	}
-	if m.Committer != nil {
-		{
-			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *LogForwardReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if this.Stage != that1.Stage {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LogForwardReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if this.Err != that1.Err {
+		return false
+	}
+	if !this.OtherLeader.Equal(that1.OtherLeader) {
+		return false
+	}
+	if !this.Config.Equal(that1.Config) {
+		return false
+	}
+	if this.Seq != that1.Seq {
+		return false
+	}
+	return true
 }
+func (this *TimeoutNowReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *LogForwardReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.OK {
-		i--
-		if m.OK {
-			dAtA[i] = 1
+	that1, ok := that.(*TimeoutNowReq)
+	if !ok {
+		that2, ok := that.(TimeoutNowReq)
+		if ok {
+			that1 = &that2
 		} else {
-			dAtA[i] = 0
+			return false
 		}
-		i--
-		dAtA[i] = 0x50
 	}
-	if m.Committed != nil {
-		{
-			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.Accepted != nil {
-		{
-			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	if m.VotedFor != nil {
-		{
-			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return len(dAtA) - i, nil
+	return true
 }
-
-func (m *ProposeReply) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *TimeoutNowReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
 
-func (m *ProposeReply) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *ProposeReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Err) > 0 {
-		i -= len(m.Err)
-		copy(dAtA[i:], m.Err)
-		i = encodeVarintTraft(dAtA, i, uint64(len(m.Err)))
-		i--
-		dAtA[i] = 0x1a
-	}
-	if m.OK {
-		i--
-		if m.OK {
-			dAtA[i] = 1
+	that1, ok := that.(*TimeoutNowReply)
+	if !ok {
+		that2, ok := that.(TimeoutNowReply)
+		if ok {
+			that1 = &that2
 		} else {
-			dAtA[i] = 0
-		}
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.OtherLeader != nil {
-		{
-			size, err := m.OtherLeader.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintTraft(dAtA, i, uint64(size))
+			return false
 		}
-		i--
-		dAtA[i] = 0xa
 	}
-	return len(dAtA) - i, nil
-}
-
-func encodeVarintTraft(dAtA []byte, offset int, v uint64) int {
-	offset -= sovTraft(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	dAtA[offset] = uint8(v)
-	return base
-}
-func (m *Cmd) Size() (n int) {
-	if m == nil {
-		return 0
+	if this.OK != that1.OK {
+		return false
 	}
-	var l int
-	_ = l
-	l = len(m.Op)
-	if l > 0 {
-		n += 1 + l + sovTraft(uint64(l))
+	if !this.VotedFor.Equal(that1.VotedFor) {
+		return false
 	}
-	l = len(m.Key)
-	if l > 0 {
-		n += 2 + l + sovTraft(uint64(l))
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	if m.Value != nil {
-		n += m.Value.Size()
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return n
+	return true
 }
+func (this *FetchLogsReq) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *Cmd_VStr) Size() (n int) {
-	if m == nil {
-		return 0
+	that1, ok := that.(*FetchLogsReq)
+	if !ok {
+		that2, ok := that.(FetchLogsReq)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	var l int
-	_ = l
-	l = len(m.VStr)
-	n += 2 + l + sovTraft(uint64(l))
-	return n
-}
-func (m *Cmd_VI64) Size() (n int) {
-	if m == nil {
-		return 0
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	var l int
-	_ = l
-	n += 2 + sovTraft(uint64(m.VI64))
-	return n
-}
-func (m *Cmd_VClusterConfig) Size() (n int) {
-	if m == nil {
-		return 0
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	var l int
-	_ = l
-	if m.VClusterConfig != nil {
-		l = m.VClusterConfig.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	return n
-}
-func (m *TailBitmap) Size() (n int) {
-	if m == nil {
-		return 0
+	if !this.Committed.Equal(that1.Committed) {
+		return false
 	}
-	var l int
-	_ = l
-	if m.Offset != 0 {
-		n += 1 + sovTraft(uint64(m.Offset))
+	return true
+}
+func (this *FetchLogsReply) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	if len(m.Words) > 0 {
-		l = 0
-		for _, e := range m.Words {
-			l += sovTraft(uint64(e))
+
+	that1, ok := that.(*FetchLogsReply)
+	if !ok {
+		that2, ok := that.(FetchLogsReply)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		n += 1 + sovTraft(uint64(l)) + l
 	}
-	if m.Reclamed != 0 {
-		n += 1 + sovTraft(uint64(m.Reclamed))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return n
-}
-
-func (m *Record) Size() (n int) {
-	if m == nil {
-		return 0
+	if !this.Committer.Equal(that1.Committer) {
+		return false
 	}
-	var l int
-	_ = l
-	if m.Author != nil {
-		l = m.Author.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if !this.Accepted.Equal(that1.Accepted) {
+		return false
 	}
-	if m.Seq != 0 {
-		n += 1 + sovTraft(uint64(m.Seq))
+	if !this.Committed.Equal(that1.Committed) {
+		return false
 	}
-	if m.Cmd != nil {
-		l = m.Cmd.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	if len(this.Logs) != len(that1.Logs) {
+		return false
 	}
-	if m.Depends != nil {
-		l = m.Depends.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	for i := range this.Logs {
+		if !this.Logs[i].Equal(that1.Logs[i]) {
+			return false
+		}
 	}
-	if m.Overrides != nil {
-		l = m.Overrides.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	return true
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// TRaftClient is the client API for TRaft service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type TRaftClient interface {
+	Vote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error)
+	// PreVote asks a voter whether it would grant a candidate, without
+	// changing anything on the voter.
+	// VoteReply.VotedFor is the candidate if it would.
+	PreVote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error)
+	LogForward(ctx context.Context, in *LogForwardReq, opts ...grpc.CallOption) (*LogForwardReply, error)
+	Propose(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error)
+	// ProposeApplied is the same as Propose except that it replies after the
+	// log is applied, with the result of applying it.
+	ProposeApplied(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error)
+	InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (TRaft_InstallSnapshotClient, error)
+	// TransferLeadership moves leadership from the leader to another member.
+	TransferLeadership(ctx context.Context, in *TransferLeadershipReq, opts ...grpc.CallOption) (*TransferLeadershipReply, error)
+	// ChangeMembers adds and removes members through the leader. It reports
+	// the progress until the new config is committed.
+	ChangeMembers(ctx context.Context, in *ChangeMembersReq, opts ...grpc.CallOption) (TRaft_ChangeMembersClient, error)
+	// TimeoutNow asks a follower to start an election at once, without
+	// waiting for its leader to expire.
+	TimeoutNow(ctx context.Context, in *TimeoutNowReq, opts ...grpc.CallOption) (*TimeoutNowReply, error)
+	// FetchLogs gets logs from a replica with a greater log status.
+	FetchLogs(ctx context.Context, in *FetchLogsReq, opts ...grpc.CallOption) (*FetchLogsReply, error)
+}
+
+type tRaftClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTRaftClient(cc *grpc.ClientConn) TRaftClient {
+	return &tRaftClient{cc}
+}
+
+func (c *tRaftClient) Vote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error) {
+	out := new(VoteReply)
+	err := c.cc.Invoke(ctx, "/TRaft/Vote", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *LeaderId) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *tRaftClient) PreVote(ctx context.Context, in *VoteReq, opts ...grpc.CallOption) (*VoteReply, error) {
+	out := new(VoteReply)
+	err := c.cc.Invoke(ctx, "/TRaft/PreVote", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Term != 0 {
-		n += 1 + sovTraft(uint64(m.Term))
+	return out, nil
+}
+
+func (c *tRaftClient) LogForward(ctx context.Context, in *LogForwardReq, opts ...grpc.CallOption) (*LogForwardReply, error) {
+	out := new(LogForwardReply)
+	err := c.cc.Invoke(ctx, "/TRaft/LogForward", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Id != 0 {
-		n += 1 + sovTraft(uint64(m.Id))
+	return out, nil
+}
+
+func (c *tRaftClient) Propose(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error) {
+	out := new(ProposeReply)
+	err := c.cc.Invoke(ctx, "/TRaft/Propose", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *Node) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *tRaftClient) ProposeApplied(ctx context.Context, in *Cmd, opts ...grpc.CallOption) (*ProposeReply, error) {
+	out := new(ProposeReply)
+	err := c.cc.Invoke(ctx, "/TRaft/ProposeApplied", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Config != nil {
-		l = m.Config.Size()
-		n += 1 + l + sovTraft(uint64(l))
-	}
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 1 + l + sovTraft(uint64(l))
-		}
+	return out, nil
+}
+
+func (c *tRaftClient) InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (TRaft_InstallSnapshotClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_TRaft_serviceDesc.Streams[0], "/TRaft/InstallSnapshot", opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Id != 0 {
-		n += 1 + sovTraft(uint64(m.Id))
+	x := &tRaftInstallSnapshotClient{stream}
+	return x, nil
+}
+
+type TRaft_InstallSnapshotClient interface {
+	Send(*SnapshotChunk) error
+	CloseAndRecv() (*InstallSnapshotReply, error)
+	grpc.ClientStream
+}
+
+type tRaftInstallSnapshotClient struct {
+	grpc.ClientStream
+}
+
+func (x *tRaftInstallSnapshotClient) Send(m *SnapshotChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tRaftInstallSnapshotClient) CloseAndRecv() (*InstallSnapshotReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if m.LogOffset != 0 {
-		n += 1 + sovTraft(uint64(m.LogOffset))
+	m := new(InstallSnapshotReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if len(m.Status) > 0 {
-		for k, v := range m.Status {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovTraft(uint64(l))
-			}
-			mapEntrySize := 1 + sovTraft(uint64(k)) + l
-			n += mapEntrySize + 1 + sovTraft(uint64(mapEntrySize))
-		}
+	return m, nil
+}
+
+func (c *tRaftClient) TransferLeadership(ctx context.Context, in *TransferLeadershipReq, opts ...grpc.CallOption) (*TransferLeadershipReply, error) {
+	out := new(TransferLeadershipReply)
+	err := c.cc.Invoke(ctx, "/TRaft/TransferLeadership", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *LogStatus) Size() (n int) {
-	if m == nil {
-		return 0
+func (c *tRaftClient) ChangeMembers(ctx context.Context, in *ChangeMembersReq, opts ...grpc.CallOption) (TRaft_ChangeMembersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_TRaft_serviceDesc.Streams[1], "/TRaft/ChangeMembers", opts...)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Accepted != nil {
-		l = m.Accepted.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	x := &tRaftChangeMembersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	if m.Committer != nil {
-		l = m.Committer.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	return n
+	return x, nil
 }
 
-func (m *LeaderStatus) Size() (n int) {
-	if m == nil {
-		return 0
+type TRaft_ChangeMembersClient interface {
+	Recv() (*ChangeMembersProgress, error)
+	grpc.ClientStream
+}
+
+type tRaftChangeMembersClient struct {
+	grpc.ClientStream
+}
+
+func (x *tRaftChangeMembersClient) Recv() (*ChangeMembersProgress, error) {
+	m := new(ChangeMembersProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.VotedFor != nil {
-		l = m.VotedFor.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	return m, nil
+}
+
+func (c *tRaftClient) TimeoutNow(ctx context.Context, in *TimeoutNowReq, opts ...grpc.CallOption) (*TimeoutNowReply, error) {
+	out := new(TimeoutNowReply)
+	err := c.cc.Invoke(ctx, "/TRaft/TimeoutNow", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.VoteExpireAt != 0 {
-		n += 1 + sovTraft(uint64(m.VoteExpireAt))
+	return out, nil
+}
+
+func (c *tRaftClient) FetchLogs(ctx context.Context, in *FetchLogsReq, opts ...grpc.CallOption) (*FetchLogsReply, error) {
+	out := new(FetchLogsReply)
+	err := c.cc.Invoke(ctx, "/TRaft/FetchLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return out, nil
 }
 
-func (m *ReplicaStatus) Size() (n int) {
-	if m == nil {
-		return 0
+// TRaftServer is the server API for TRaft service.
+type TRaftServer interface {
+	Vote(context.Context, *VoteReq) (*VoteReply, error)
+	// PreVote asks a voter whether it would grant a candidate, without
+	// changing anything on the voter.
+	// VoteReply.VotedFor is the candidate if it would.
+	PreVote(context.Context, *VoteReq) (*VoteReply, error)
+	LogForward(context.Context, *LogForwardReq) (*LogForwardReply, error)
+	Propose(context.Context, *Cmd) (*ProposeReply, error)
+	// ProposeApplied is the same as Propose except that it replies after the
+	// log is applied, with the result of applying it.
+	ProposeApplied(context.Context, *Cmd) (*ProposeReply, error)
+	InstallSnapshot(TRaft_InstallSnapshotServer) error
+	// TransferLeadership moves leadership from the leader to another member.
+	TransferLeadership(context.Context, *TransferLeadershipReq) (*TransferLeadershipReply, error)
+	// ChangeMembers adds and removes members through the leader. It reports
+	// the progress until the new config is committed.
+	ChangeMembers(*ChangeMembersReq, TRaft_ChangeMembersServer) error
+	// TimeoutNow asks a follower to start an election at once, without
+	// waiting for its leader to expire.
+	TimeoutNow(context.Context, *TimeoutNowReq) (*TimeoutNowReply, error)
+	// FetchLogs gets logs from a replica with a greater log status.
+	FetchLogs(context.Context, *FetchLogsReq) (*FetchLogsReply, error)
+}
+
+// UnimplementedTRaftServer can be embedded to have forward compatible implementations.
+type UnimplementedTRaftServer struct {
+}
+
+func (*UnimplementedTRaftServer) Vote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vote not implemented")
+}
+func (*UnimplementedTRaftServer) PreVote(ctx context.Context, req *VoteReq) (*VoteReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreVote not implemented")
+}
+func (*UnimplementedTRaftServer) LogForward(ctx context.Context, req *LogForwardReq) (*LogForwardReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogForward not implemented")
+}
+func (*UnimplementedTRaftServer) Propose(ctx context.Context, req *Cmd) (*ProposeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Propose not implemented")
+}
+func (*UnimplementedTRaftServer) ProposeApplied(ctx context.Context, req *Cmd) (*ProposeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProposeApplied not implemented")
+}
+func (*UnimplementedTRaftServer) InstallSnapshot(srv TRaft_InstallSnapshotServer) error {
+	return status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (*UnimplementedTRaftServer) TransferLeadership(ctx context.Context, req *TransferLeadershipReq) (*TransferLeadershipReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferLeadership not implemented")
+}
+func (*UnimplementedTRaftServer) ChangeMembers(req *ChangeMembersReq, srv TRaft_ChangeMembersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ChangeMembers not implemented")
+}
+func (*UnimplementedTRaftServer) TimeoutNow(ctx context.Context, req *TimeoutNowReq) (*TimeoutNowReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TimeoutNow not implemented")
+}
+func (*UnimplementedTRaftServer) FetchLogs(ctx context.Context, req *FetchLogsReq) (*FetchLogsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchLogs not implemented")
+}
+
+func RegisterTRaftServer(s *grpc.Server, srv TRaftServer) {
+	s.RegisterService(&_TRaft_serviceDesc, srv)
+}
+
+func _TRaft_Vote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteReq)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Accepted != nil {
-		l = m.Accepted.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if interceptor == nil {
+		return srv.(TRaftServer).Vote(ctx, in)
 	}
-	if m.Committed != nil {
-		l = m.Committed.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/Vote",
 	}
-	if m.Applied != nil {
-		l = m.Applied.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).Vote(ctx, req.(*VoteReq))
 	}
-	if m.Committer != nil {
-		l = m.Committer.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TRaft_PreVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteReq)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.VotedFor != nil {
-		l = m.VotedFor.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if interceptor == nil {
+		return srv.(TRaftServer).PreVote(ctx, in)
 	}
-	if m.VoteExpireAt != 0 {
-		n += 1 + sovTraft(uint64(m.VoteExpireAt))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/PreVote",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).PreVote(ctx, req.(*VoteReq))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ReplicaInfo) Size() (n int) {
-	if m == nil {
-		return 0
+func _TRaft_LogForward_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogForwardReq)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Id != 0 {
-		n += 1 + sovTraft(uint64(m.Id))
+	if interceptor == nil {
+		return srv.(TRaftServer).LogForward(ctx, in)
 	}
-	l = len(m.Addr)
-	if l > 0 {
-		n += 1 + l + sovTraft(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/LogForward",
 	}
-	if m.Position != 0 {
-		n += 1 + sovTraft(uint64(m.Position))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).LogForward(ctx, req.(*LogForwardReq))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ClusterConfig) Size() (n int) {
-	if m == nil {
-		return 0
+func _TRaft_Propose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Cmd)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Members) > 0 {
-		for k, v := range m.Members {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovTraft(uint64(l))
-			}
-			mapEntrySize := 1 + sovTraft(uint64(k)) + l
-			n += mapEntrySize + 1 + sovTraft(uint64(mapEntrySize))
-		}
+	if interceptor == nil {
+		return srv.(TRaftServer).Propose(ctx, in)
 	}
-	if len(m.Quorums) > 0 {
-		l = 0
-		for _, e := range m.Quorums {
-			l += sovTraft(uint64(e))
-		}
-		n += 2 + sovTraft(uint64(l)) + l
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/Propose",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).Propose(ctx, req.(*Cmd))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *VoteReq) Size() (n int) {
-	if m == nil {
-		return 0
+func _TRaft_ProposeApplied_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Cmd)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Candidate != nil {
-		l = m.Candidate.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if interceptor == nil {
+		return srv.(TRaftServer).ProposeApplied(ctx, in)
 	}
-	if m.Committer != nil {
-		l = m.Committer.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/ProposeApplied",
 	}
-	if m.Accepted != nil {
-		l = m.Accepted.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).ProposeApplied(ctx, req.(*Cmd))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *VoteReply) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Id != 0 {
-		n += 1 + sovTraft(uint64(m.Id))
+func _TRaft_InstallSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TRaftServer).InstallSnapshot(&tRaftInstallSnapshotServer{stream})
+}
+
+type TRaft_InstallSnapshotServer interface {
+	SendAndClose(*InstallSnapshotReply) error
+	Recv() (*SnapshotChunk, error)
+	grpc.ServerStream
+}
+
+type tRaftInstallSnapshotServer struct {
+	grpc.ServerStream
+}
+
+func (x *tRaftInstallSnapshotServer) SendAndClose(m *InstallSnapshotReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tRaftInstallSnapshotServer) Recv() (*SnapshotChunk, error) {
+	m := new(SnapshotChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if m.Committer != nil {
-		l = m.Committer.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	return m, nil
+}
+
+func _TRaft_TransferLeadership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferLeadershipReq)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.VotedFor != nil {
-		l = m.VotedFor.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if interceptor == nil {
+		return srv.(TRaftServer).TransferLeadership(ctx, in)
 	}
-	if m.Accepted != nil {
-		l = m.Accepted.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/TransferLeadership",
 	}
-	if m.Committed != nil {
-		l = m.Committed.Size()
-		n += 2 + l + sovTraft(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).TransferLeadership(ctx, req.(*TransferLeadershipReq))
 	}
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 2 + l + sovTraft(uint64(l))
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TRaft_ChangeMembers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChangeMembersReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TRaftServer).ChangeMembers(m, &tRaftChangeMembersServer{stream})
+}
+
+type TRaft_ChangeMembersServer interface {
+	Send(*ChangeMembersProgress) error
+	grpc.ServerStream
+}
+
+type tRaftChangeMembersServer struct {
+	grpc.ServerStream
+}
+
+func (x *tRaftChangeMembersServer) Send(m *ChangeMembersProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TRaft_TimeoutNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeoutNowReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TRaftServer).TimeoutNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/TimeoutNow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).TimeoutNow(ctx, req.(*TimeoutNowReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TRaft_FetchLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchLogsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TRaftServer).FetchLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/TRaft/FetchLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TRaftServer).FetchLogs(ctx, req.(*FetchLogsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _TRaft_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "TRaft",
+	HandlerType: (*TRaftServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Vote",
+			Handler:    _TRaft_Vote_Handler,
+		},
+		{
+			MethodName: "PreVote",
+			Handler:    _TRaft_PreVote_Handler,
+		},
+		{
+			MethodName: "LogForward",
+			Handler:    _TRaft_LogForward_Handler,
+		},
+		{
+			MethodName: "Propose",
+			Handler:    _TRaft_Propose_Handler,
+		},
+		{
+			MethodName: "ProposeApplied",
+			Handler:    _TRaft_ProposeApplied_Handler,
+		},
+		{
+			MethodName: "TransferLeadership",
+			Handler:    _TRaft_TransferLeadership_Handler,
+		},
+		{
+			MethodName: "TimeoutNow",
+			Handler:    _TRaft_TimeoutNow_Handler,
+		},
+		{
+			MethodName: "FetchLogs",
+			Handler:    _TRaft_FetchLogs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InstallSnapshot",
+			Handler:       _TRaft_InstallSnapshot_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ChangeMembers",
+			Handler:       _TRaft_ChangeMembers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "traft.proto",
+}
+
+func (m *Cmd) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Cmd) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cmd) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != nil {
+		{
+			size := m.Value.Size()
+			i -= size
+			if _, err := m.Value.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
 		}
 	}
-	return n
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa2
+	}
+	if len(m.Op) > 0 {
+		i -= len(m.Op)
+		copy(dAtA[i:], m.Op)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Op)))
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *LogForwardReq) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *Cmd_VStr) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cmd_VStr) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= len(m.VStr)
+	copy(dAtA[i:], m.VStr)
+	i = encodeVarintTraft(dAtA, i, uint64(len(m.VStr)))
+	i--
+	dAtA[i] = 0x1
+	i--
+	dAtA[i] = 0xfa
+	return len(dAtA) - i, nil
+}
+func (m *Cmd_VI64) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cmd_VI64) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintTraft(dAtA, i, uint64(m.VI64))
+	i--
+	dAtA[i] = 0x2
+	i--
+	dAtA[i] = 0x80
+	return len(dAtA) - i, nil
+}
+func (m *Cmd_VClusterConfig) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cmd_VClusterConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.VClusterConfig != nil {
+		{
+			size, err := m.VClusterConfig.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0x8a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *TailBitmap) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *TailBitmap) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TailBitmap) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Committer != nil {
-		l = m.Committer.Size()
-		n += 1 + l + sovTraft(uint64(l))
+	if m.Reclamed != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Reclamed))
+		i--
+		dAtA[i] = 0x18
 	}
-	if len(m.Logs) > 0 {
-		for _, e := range m.Logs {
-			l = e.Size()
-			n += 1 + l + sovTraft(uint64(l))
+	if len(m.Words) > 0 {
+		dAtA3 := make([]byte, len(m.Words)*10)
+		var j2 int
+		for _, num := range m.Words {
+			for num >= 1<<7 {
+				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j2++
+			}
+			dAtA3[j2] = uint8(num)
+			j2++
+		}
+		i -= j2
+		copy(dAtA[i:], dAtA3[:j2])
+		i = encodeVarintTraft(dAtA, i, uint64(j2))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Offset != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Record) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Record) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Record) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Overrides != nil {
+		{
+			size, err := m.Overrides.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0xc2
+	}
+	if m.Depends != nil {
+		{
+			size, err := m.Depends.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0x82
+	}
+	if m.Cmd != nil {
+		{
+			size, err := m.Cmd.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xf2
+	}
+	if m.Seq != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.Author != nil {
+		{
+			size, err := m.Author.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LeaderId) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LeaderId) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LeaderId) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Id != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Term != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Term))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Node) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Node) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Node) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Status) > 0 {
+		for k := range m.Status {
+			v := m.Status[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintTraft(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i = encodeVarintTraft(dAtA, i, uint64(k))
+			i--
+			dAtA[i] = 0x8
+			i = encodeVarintTraft(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.Id != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Config != nil {
+		{
+			size, err := m.Config.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LogStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LogStatus) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LogStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LeaderStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LeaderStatus) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LeaderStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.VoteExpireAt != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.VoteExpireAt))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicaStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaStatus) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicaStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.VoteExpireAt != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.VoteExpireAt))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Applied != nil {
+		{
+			size, err := m.Applied.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HardState) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HardState) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HardState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.VoteExpireAt != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.VoteExpireAt))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Snapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Snapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Snapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Config != nil {
+		{
+			size, err := m.Config.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Applied != nil {
+		{
+			size, err := m.Applied.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Offset != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *KVSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *KVSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KVSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Values[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.KeyIndex) > 0 {
+		i -= len(m.KeyIndex)
+		copy(dAtA[i:], m.KeyIndex)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.KeyIndex)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicaInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicaInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Position != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Position))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Addr) > 0 {
+		i -= len(m.Addr)
+		copy(dAtA[i:], m.Addr)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Addr)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Id != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuorumRule) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuorumRule) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuorumRule) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Threshold != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Threshold))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Groups) > 0 {
+		for iNdEx := len(m.Groups) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Groups[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Votes) > 0 {
+		for k := range m.Votes {
+			v := m.Votes[k]
+			baseI := i
+			i = encodeVarintTraft(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i = encodeVarintTraft(dAtA, i, uint64(k))
+			i--
+			dAtA[i] = 0x8
+			i = encodeVarintTraft(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ClusterConfig) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ClusterConfig) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ClusterConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Next != nil {
+		{
+			size, err := m.Next.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xfa
+	}
+	if m.VoteQuorumRule != nil {
+		{
+			size, err := m.VoteQuorumRule.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xc2
+	}
+	if m.QuorumRule != nil {
+		{
+			size, err := m.QuorumRule.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xba
+	}
+	if len(m.VoteQuorums) > 0 {
+		dAtA26 := make([]byte, len(m.VoteQuorums)*10)
+		var j25 int
+		for _, num := range m.VoteQuorums {
+			for num >= 1<<7 {
+				dAtA26[j25] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j25++
+			}
+			dAtA26[j25] = uint8(num)
+			j25++
+		}
+		i -= j25
+		copy(dAtA[i:], dAtA26[:j25])
+		i = encodeVarintTraft(dAtA, i, uint64(j25))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xb2
+	}
+	if len(m.Quorums) > 0 {
+		dAtA28 := make([]byte, len(m.Quorums)*10)
+		var j27 int
+		for _, num := range m.Quorums {
+			for num >= 1<<7 {
+				dAtA28[j27] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j27++
+			}
+			dAtA28[j27] = uint8(num)
+			j27++
+		}
+		i -= j27
+		copy(dAtA[i:], dAtA28[:j27])
+		i = encodeVarintTraft(dAtA, i, uint64(j27))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xaa
+	}
+	if len(m.Members) > 0 {
+		for k := range m.Members {
+			v := m.Members[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintTraft(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i = encodeVarintTraft(dAtA, i, uint64(k))
+			i--
+			dAtA[i] = 0x8
+			i = encodeVarintTraft(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x5a
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *VoteReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *VoteReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *VoteReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Candidate != nil {
+		{
+			size, err := m.Candidate.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *VoteReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *VoteReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *VoteReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1
+			i--
+			dAtA[i] = 0xf2
+		}
+	}
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xb2
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xaa
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Id != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LogForwardReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LogForwardReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LogForwardReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Heartbeat {
+		i--
+		if m.Heartbeat {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LogForwardReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LogForwardReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LogForwardReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.OK {
+		i--
+		if m.OK {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SnapshotChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotChunk) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SnapshotChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Done {
+		i--
+		if m.Done {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.ChunkOffset != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.ChunkOffset))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.SnapshotSize != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.SnapshotSize))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.SnapshotOffset != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.SnapshotOffset))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *InstallSnapshotReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InstallSnapshotReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InstallSnapshotReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.NextChunkOffset != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.NextChunkOffset))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.OK {
+		i--
+		if m.OK {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ProposeReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ProposeReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ProposeReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.WaitApplied {
+		i--
+		if m.WaitApplied {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Cmd != nil {
+		{
+			size, err := m.Cmd.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ProposeReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ProposeReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ProposeReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Seq != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Result) > 0 {
+		i -= len(m.Result)
+		copy(dAtA[i:], m.Result)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Result)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.OK {
+		i--
+		if m.OK {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.OtherLeader != nil {
+		{
+			size, err := m.OtherLeader.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TransferLeadershipReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TransferLeadershipReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TransferLeadershipReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TargetId != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.TargetId))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TransferLeadershipReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TransferLeadershipReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TransferLeadershipReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.OtherLeader != nil {
+		{
+			size, err := m.OtherLeader.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.OK {
+		i--
+		if m.OK {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ChangeMembersReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChangeMembersReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ChangeMembersReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Remove) > 0 {
+		dAtA52 := make([]byte, len(m.Remove)*10)
+		var j51 int
+		for _, num1 := range m.Remove {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA52[j51] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j51++
+			}
+			dAtA52[j51] = uint8(num)
+			j51++
+		}
+		i -= j51
+		copy(dAtA[i:], dAtA52[:j51])
+		i = encodeVarintTraft(dAtA, i, uint64(j51))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Add) > 0 {
+		for iNdEx := len(m.Add) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Add[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ChangeMembersProgress) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChangeMembersProgress) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ChangeMembersProgress) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Seq != 0 {
+		i = encodeVarintTraft(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Config != nil {
+		{
+			size, err := m.Config.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.OtherLeader != nil {
+		{
+			size, err := m.OtherLeader.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Stage) > 0 {
+		i -= len(m.Stage)
+		copy(dAtA[i:], m.Stage)
+		i = encodeVarintTraft(dAtA, i, uint64(len(m.Stage)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeoutNowReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeoutNowReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeoutNowReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeoutNowReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeoutNowReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeoutNowReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.VotedFor != nil {
+		{
+			size, err := m.VotedFor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.OK {
+		i--
+		if m.OK {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FetchLogsReq) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FetchLogsReq) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FetchLogsReq) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FetchLogsReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FetchLogsReply) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FetchLogsReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Logs) > 0 {
+		for iNdEx := len(m.Logs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Logs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTraft(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Committed != nil {
+		{
+			size, err := m.Committed.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Accepted != nil {
+		{
+			size, err := m.Accepted.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Committer != nil {
+		{
+			size, err := m.Committer.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTraft(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintTraft(dAtA []byte, offset int, v uint64) int {
+	offset -= sovTraft(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Cmd) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Op)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.Value != nil {
+		n += m.Value.Size()
+	}
+	return n
+}
+
+func (m *Cmd_VStr) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.VStr)
+	n += 2 + l + sovTraft(uint64(l))
+	return n
+}
+func (m *Cmd_VI64) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2 + sovTraft(uint64(m.VI64))
+	return n
+}
+func (m *Cmd_VClusterConfig) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.VClusterConfig != nil {
+		l = m.VClusterConfig.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+func (m *TailBitmap) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovTraft(uint64(m.Offset))
+	}
+	if len(m.Words) > 0 {
+		l = 0
+		for _, e := range m.Words {
+			l += sovTraft(uint64(e))
+		}
+		n += 1 + sovTraft(uint64(l)) + l
+	}
+	if m.Reclamed != 0 {
+		n += 1 + sovTraft(uint64(m.Reclamed))
+	}
+	return n
+}
+
+func (m *Record) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Author != nil {
+		l = m.Author.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Seq != 0 {
+		n += 1 + sovTraft(uint64(m.Seq))
+	}
+	if m.Cmd != nil {
+		l = m.Cmd.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.Depends != nil {
+		l = m.Depends.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.Overrides != nil {
+		l = m.Overrides.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *LeaderId) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Term != 0 {
+		n += 1 + sovTraft(uint64(m.Term))
+	}
+	if m.Id != 0 {
+		n += 1 + sovTraft(uint64(m.Id))
+	}
+	return n
+}
+
+func (m *Node) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Config != nil {
+		l = m.Config.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Id != 0 {
+		n += 1 + sovTraft(uint64(m.Id))
+	}
+	if len(m.Status) > 0 {
+		for k, v := range m.Status {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovTraft(uint64(l))
+			}
+			mapEntrySize := 1 + sovTraft(uint64(k)) + l
+			n += mapEntrySize + 1 + sovTraft(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *LogStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *LeaderStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VoteExpireAt != 0 {
+		n += 1 + sovTraft(uint64(m.VoteExpireAt))
+	}
+	return n
+}
+
+func (m *ReplicaStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Applied != nil {
+		l = m.Applied.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VoteExpireAt != 0 {
+		n += 1 + sovTraft(uint64(m.VoteExpireAt))
+	}
+	return n
+}
+
+func (m *HardState) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VoteExpireAt != 0 {
+		n += 1 + sovTraft(uint64(m.VoteExpireAt))
+	}
+	return n
+}
+
+func (m *Snapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovTraft(uint64(m.Offset))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Applied != nil {
+		l = m.Applied.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Config != nil {
+		l = m.Config.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *KVSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.KeyIndex)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovTraft(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ReplicaInfo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovTraft(uint64(m.Id))
+	}
+	l = len(m.Addr)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Position != 0 {
+		n += 1 + sovTraft(uint64(m.Position))
+	}
+	return n
+}
+
+func (m *QuorumRule) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Votes) > 0 {
+		for k, v := range m.Votes {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + sovTraft(uint64(k)) + 1 + sovTraft(uint64(v))
+			n += mapEntrySize + 1 + sovTraft(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Groups) > 0 {
+		for _, e := range m.Groups {
+			l = e.Size()
+			n += 1 + l + sovTraft(uint64(l))
+		}
+	}
+	if m.Threshold != 0 {
+		n += 1 + sovTraft(uint64(m.Threshold))
+	}
+	return n
+}
+
+func (m *ClusterConfig) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Members) > 0 {
+		for k, v := range m.Members {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovTraft(uint64(l))
+			}
+			mapEntrySize := 1 + sovTraft(uint64(k)) + l
+			n += mapEntrySize + 1 + sovTraft(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Quorums) > 0 {
+		l = 0
+		for _, e := range m.Quorums {
+			l += sovTraft(uint64(e))
+		}
+		n += 2 + sovTraft(uint64(l)) + l
+	}
+	if len(m.VoteQuorums) > 0 {
+		l = 0
+		for _, e := range m.VoteQuorums {
+			l += sovTraft(uint64(e))
+		}
+		n += 2 + sovTraft(uint64(l)) + l
+	}
+	if m.QuorumRule != nil {
+		l = m.QuorumRule.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.VoteQuorumRule != nil {
+		l = m.VoteQuorumRule.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.Next != nil {
+		l = m.Next.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *VoteReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Candidate != nil {
+		l = m.Candidate.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *VoteReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovTraft(uint64(m.Id))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 2 + l + sovTraft(uint64(l))
+	}
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 2 + l + sovTraft(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LogForwardReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 1 + l + sovTraft(uint64(l))
+		}
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Heartbeat {
+		n += 2
+	}
+	return n
+}
+
+func (m *LogForwardReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.OK {
+		n += 2
+	}
+	return n
+}
+
+func (m *SnapshotChunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.SnapshotOffset != 0 {
+		n += 1 + sovTraft(uint64(m.SnapshotOffset))
+	}
+	if m.SnapshotSize != 0 {
+		n += 1 + sovTraft(uint64(m.SnapshotSize))
+	}
+	if m.ChunkOffset != 0 {
+		n += 1 + sovTraft(uint64(m.ChunkOffset))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Done {
+		n += 2
+	}
+	return n
+}
+
+func (m *InstallSnapshotReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OK {
+		n += 2
+	}
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.NextChunkOffset != 0 {
+		n += 1 + sovTraft(uint64(m.NextChunkOffset))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *ProposeReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Cmd != nil {
+		l = m.Cmd.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.WaitApplied {
+		n += 2
+	}
+	return n
+}
+
+func (m *ProposeReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OtherLeader != nil {
+		l = m.OtherLeader.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.OK {
+		n += 2
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	l = len(m.Result)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Seq != 0 {
+		n += 1 + sovTraft(uint64(m.Seq))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *TransferLeadershipReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TargetId != 0 {
+		n += 1 + sovTraft(uint64(m.TargetId))
+	}
+	return n
+}
+
+func (m *TransferLeadershipReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OK {
+		n += 2
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.OtherLeader != nil {
+		l = m.OtherLeader.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *ChangeMembersReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Add) > 0 {
+		for _, e := range m.Add {
+			l = e.Size()
+			n += 1 + l + sovTraft(uint64(l))
+		}
+	}
+	if len(m.Remove) > 0 {
+		l = 0
+		for _, e := range m.Remove {
+			l += sovTraft(uint64(e))
+		}
+		n += 1 + sovTraft(uint64(l)) + l
+	}
+	return n
+}
+
+func (m *ChangeMembersProgress) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Stage)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.OtherLeader != nil {
+		l = m.OtherLeader.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Config != nil {
+		l = m.Config.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Seq != 0 {
+		n += 1 + sovTraft(uint64(m.Seq))
+	}
+	return n
+}
+
+func (m *TimeoutNowReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *TimeoutNowReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OK {
+		n += 2
+	}
+	if m.VotedFor != nil {
+		l = m.VotedFor.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *FetchLogsReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	return n
+}
+
+func (m *FetchLogsReply) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Committer != nil {
+		l = m.Committer.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Accepted != nil {
+		l = m.Accepted.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if m.Committed != nil {
+		l = m.Committed.Size()
+		n += 1 + l + sovTraft(uint64(l))
+	}
+	if len(m.Logs) > 0 {
+		for _, e := range m.Logs {
+			l = e.Size()
+			n += 1 + l + sovTraft(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovTraft(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozTraft(x uint64) (n int) {
+	return sovTraft(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Cmd) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Cmd: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Cmd: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Op = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 31:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VStr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = &Cmd_VStr{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 32:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VI64", wireType)
+			}
+			var v int64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Value = &Cmd_VI64{v}
+		case 33:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VClusterConfig", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ClusterConfig{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Value = &Cmd_VClusterConfig{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TailBitmap) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TailBitmap: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TailBitmap: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Words = append(m.Words, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthTraft
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthTraft
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.Words) == 0 {
+					m.Words = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Words = append(m.Words, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Words", wireType)
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reclamed", wireType)
+			}
+			m.Reclamed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Reclamed |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Record) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Record: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Record: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Author", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Author == nil {
+				m.Author = &LeaderId{}
+			}
+			if err := m.Author.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 30:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cmd", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cmd == nil {
+				m.Cmd = &Cmd{}
+			}
+			if err := m.Cmd.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 32:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Depends", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Depends == nil {
+				m.Depends = &TailBitmap{}
+			}
+			if err := m.Depends.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 40:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Overrides", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Overrides == nil {
+				m.Overrides = &TailBitmap{}
+			}
+			if err := m.Overrides.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LeaderId) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LeaderId: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LeaderId: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
+			}
+			m.Term = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Term |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Node) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Node: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Node: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Config == nil {
+				m.Config = &ClusterConfig{}
+			}
+			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Status == nil {
+				m.Status = make(map[int64]*ReplicaStatus)
+			}
+			var mapkey int64
+			var mapvalue *ReplicaStatus
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthTraft
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthTraft
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &ReplicaStatus{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTraft(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTraft
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Status[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LogStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LogStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LogStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LeaderStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LeaderStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VoteExpireAt", wireType)
+			}
+			m.VoteExpireAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.VoteExpireAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicaStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicaStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
+			}
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Applied", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Applied == nil {
+				m.Applied = &TailBitmap{}
+			}
+			if err := m.Applied.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VoteExpireAt", wireType)
+			}
+			m.VoteExpireAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.VoteExpireAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HardState) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HardState: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HardState: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VoteExpireAt", wireType)
+			}
+			m.VoteExpireAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.VoteExpireAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Snapshot) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Snapshot: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Snapshot: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Applied", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Applied == nil {
+				m.Applied = &TailBitmap{}
+			}
+			if err := m.Applied.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Config == nil {
+				m.Config = &ClusterConfig{}
+			}
+			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *KVSnapshot) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KVSnapshot: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KVSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyIndex", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.KeyIndex = append(m.KeyIndex[:0], dAtA[iNdEx:postIndex]...)
+			if m.KeyIndex == nil {
+				m.KeyIndex = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &Cmd{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReplicaInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicaInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicaInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Addr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Position", wireType)
+			}
+			m.Position = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Position |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuorumRule) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuorumRule: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuorumRule: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Votes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Votes == nil {
+				m.Votes = make(map[int64]int64)
+			}
+			var mapkey int64
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTraft(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTraft
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Votes[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Groups", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Groups = append(m.Groups, &QuorumRule{})
+			if err := m.Groups[len(m.Groups)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Threshold", wireType)
+			}
+			m.Threshold = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Threshold |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
-
-func (m *LogForwardReply) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.VotedFor != nil {
-		l = m.VotedFor.Size()
-		n += 1 + l + sovTraft(uint64(l))
-	}
-	if m.Accepted != nil {
-		l = m.Accepted.Size()
-		n += 1 + l + sovTraft(uint64(l))
-	}
-	if m.Committed != nil {
-		l = m.Committed.Size()
-		n += 1 + l + sovTraft(uint64(l))
-	}
-	if m.OK {
-		n += 2
-	}
-	return n
-}
 
-func (m *ProposeReply) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.OtherLeader != nil {
-		l = m.OtherLeader.Size()
-		n += 1 + l + sovTraft(uint64(l))
-	}
-	if m.OK {
-		n += 2
-	}
-	l = len(m.Err)
-	if l > 0 {
-		n += 1 + l + sovTraft(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovTraft(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozTraft(x uint64) (n int) {
-	return sovTraft(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *Cmd) Unmarshal(dAtA []byte) error {
+func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3309,49 +8727,284 @@ func (m *Cmd) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Cmd: wiretype end group for non-group")
+			return fmt.Errorf("proto: ClusterConfig: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Cmd: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ClusterConfig: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 10:
+		case 11:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
 				}
-				if iNdEx >= l {
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Members == nil {
+				m.Members = make(map[int64]*ReplicaInfo)
+			}
+			var mapkey int64
+			var mapvalue *ReplicaInfo
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthTraft
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthTraft
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &ReplicaInfo{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTraft(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTraft
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Members[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 21:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Quorums = append(m.Quorums, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthTraft
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthTraft
+				}
+				if postIndex > l {
 					return io.ErrUnexpectedEOF
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
 				}
+				elementCount = count
+				if elementCount != 0 && len(m.Quorums) == 0 {
+					m.Quorums = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Quorums = append(m.Quorums, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Quorums", wireType)
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthTraft
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthTraft
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 22:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.VoteQuorums = append(m.VoteQuorums, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraft
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthTraft
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthTraft
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.VoteQuorums) == 0 {
+					m.VoteQuorums = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraft
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.VoteQuorums = append(m.VoteQuorums, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field VoteQuorums", wireType)
 			}
-			m.Op = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 20:
+		case 23:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field QuorumRule", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3361,29 +9014,33 @@ func (m *Cmd) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			if m.QuorumRule == nil {
+				m.QuorumRule = &QuorumRule{}
+			}
+			if err := m.QuorumRule.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 31:
+		case 24:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VStr", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field VoteQuorumRule", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3393,47 +9050,31 @@ func (m *Cmd) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Value = &Cmd_VStr{string(dAtA[iNdEx:postIndex])}
-			iNdEx = postIndex
-		case 32:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VI64", wireType)
+			if m.VoteQuorumRule == nil {
+				m.VoteQuorumRule = &QuorumRule{}
 			}
-			var v int64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if err := m.VoteQuorumRule.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			m.Value = &Cmd_VI64{v}
-		case 33:
+			iNdEx = postIndex
+		case 31:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VClusterConfig", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Next", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3460,11 +9101,12 @@ func (m *Cmd) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &ClusterConfig{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Next == nil {
+				m.Next = &ClusterConfig{}
+			}
+			if err := m.Next.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Value = &Cmd_VClusterConfig{v}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3487,7 +9129,7 @@ func (m *Cmd) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *TailBitmap) Unmarshal(dAtA []byte) error {
+func (m *VoteReq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3510,17 +9152,17 @@ func (m *TailBitmap) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: TailBitmap: wiretype end group for non-group")
+			return fmt.Errorf("proto: VoteReq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TailBitmap: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: VoteReq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Candidate", wireType)
 			}
-			m.Offset = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3530,92 +9172,69 @@ func (m *TailBitmap) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Candidate == nil {
+				m.Candidate = &LeaderId{}
+			}
+			if err := m.Candidate.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
-			if wireType == 0 {
-				var v uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowTraft
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					v |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				m.Words = append(m.Words, v)
-			} else if wireType == 2 {
-				var packedLen int
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowTraft
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					packedLen |= int(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				if packedLen < 0 {
-					return ErrInvalidLengthTraft
-				}
-				postIndex := iNdEx + packedLen
-				if postIndex < 0 {
-					return ErrInvalidLengthTraft
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
 				}
-				if postIndex > l {
+				if iNdEx >= l {
 					return io.ErrUnexpectedEOF
 				}
-				var elementCount int
-				var count int
-				for _, integer := range dAtA[iNdEx:postIndex] {
-					if integer < 128 {
-						count++
-					}
-				}
-				elementCount = count
-				if elementCount != 0 && len(m.Words) == 0 {
-					m.Words = make([]uint64, 0, elementCount)
-				}
-				for iNdEx < postIndex {
-					var v uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowTraft
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						v |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					m.Words = append(m.Words, v)
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-			} else {
-				return fmt.Errorf("proto: wrong wireType = %d for field Words", wireType)
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Reclamed", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
-			m.Reclamed = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3625,11 +9244,28 @@ func (m *TailBitmap) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Reclamed |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -3651,7 +9287,7 @@ func (m *TailBitmap) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Record) Unmarshal(dAtA []byte) error {
+func (m *VoteReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3674,15 +9310,34 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Record: wiretype end group for non-group")
+			return fmt.Errorf("proto: VoteReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Record: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: VoteReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Author", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3709,18 +9364,18 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Author == nil {
-				m.Author = &LeaderId{}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			if err := m.Author.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 10:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
 			}
-			m.Seq = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3730,14 +9385,31 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Seq |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 30:
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 21:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Cmd", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3764,16 +9436,16 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Cmd == nil {
-				m.Cmd = &Cmd{}
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
 			}
-			if err := m.Cmd.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 32:
+		case 22:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Depends", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3800,16 +9472,16 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Depends == nil {
-				m.Depends = &TailBitmap{}
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
 			}
-			if err := m.Depends.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 40:
+		case 30:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Overrides", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3836,10 +9508,8 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Overrides == nil {
-				m.Overrides = &TailBitmap{}
-			}
-			if err := m.Overrides.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Logs = append(m.Logs, &Record{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3864,7 +9534,7 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LeaderId) Unmarshal(dAtA []byte) error {
+func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3887,17 +9557,17 @@ func (m *LeaderId) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LeaderId: wiretype end group for non-group")
+			return fmt.Errorf("proto: LogForwardReq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LeaderId: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LogForwardReq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
-			m.Term = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3907,16 +9577,103 @@ func (m *LeaderId) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Term |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Logs = append(m.Logs, &Record{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
+			}
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Heartbeat", wireType)
 			}
-			m.Id = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -3926,11 +9683,12 @@ func (m *LeaderId) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Id |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Heartbeat = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -3952,7 +9710,7 @@ func (m *LeaderId) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Node) Unmarshal(dAtA []byte) error {
+func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3975,15 +9733,15 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Node: wiretype end group for non-group")
+			return fmt.Errorf("proto: LogForwardReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Node: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LogForwardReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4010,16 +9768,16 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Config == nil {
-				m.Config = &ClusterConfig{}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
 			}
-			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4046,16 +9804,18 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &Record{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
 			}
-			m.Id = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4065,16 +9825,69 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Id |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
+			}
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
+			}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogOffset", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
 			}
-			m.LogOffset = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4084,14 +9897,65 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.LogOffset |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
+			m.OK = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SnapshotChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SnapshotChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4118,92 +9982,124 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Status == nil {
-				m.Status = make(map[int64]*ReplicaStatus)
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			var mapkey int64
-			var mapvalue *ReplicaStatus
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowTraft
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotOffset", wireType)
+			}
+			m.SnapshotOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowTraft
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapkey |= int64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowTraft
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthTraft
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthTraft
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &ReplicaStatus{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipTraft(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if (skippy < 0) || (iNdEx+skippy) < 0 {
-						return ErrInvalidLengthTraft
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SnapshotOffset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Status[mapkey] = mapvalue
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotSize", wireType)
+			}
+			m.SnapshotSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SnapshotSize |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkOffset", wireType)
+			}
+			m.ChunkOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunkOffset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Done", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Done = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -4225,7 +10121,7 @@ func (m *Node) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LogStatus) Unmarshal(dAtA []byte) error {
+func (m *InstallSnapshotReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4248,13 +10144,88 @@ func (m *LogStatus) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogStatus: wiretype end group for non-group")
+			return fmt.Errorf("proto: InstallSnapshotReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: InstallSnapshotReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.OK = bool(v != 0)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NextChunkOffset", wireType)
+			}
+			m.NextChunkOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NextChunkOffset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
@@ -4290,9 +10261,9 @@ func (m *LogStatus) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 4:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4319,10 +10290,10 @@ func (m *LogStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Committer == nil {
-				m.Committer = &LeaderId{}
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
 			}
-			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -4347,7 +10318,7 @@ func (m *LogStatus) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
+func (m *ProposeReq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4370,15 +10341,15 @@ func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LeaderStatus: wiretype end group for non-group")
+			return fmt.Errorf("proto: ProposeReq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LeaderStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ProposeReq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 10:
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Cmd", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4405,18 +10376,18 @@ func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.VotedFor == nil {
-				m.VotedFor = &LeaderId{}
+			if m.Cmd == nil {
+				m.Cmd = &Cmd{}
 			}
-			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Cmd.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 11:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VoteExpireAt", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field WaitApplied", wireType)
 			}
-			m.VoteExpireAt = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4426,11 +10397,12 @@ func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.VoteExpireAt |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.WaitApplied = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -4452,7 +10424,7 @@ func (m *LeaderStatus) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
+func (m *ProposeReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4475,15 +10447,15 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaStatus: wiretype end group for non-group")
+			return fmt.Errorf("proto: ProposeReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ProposeReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field OtherLeader", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4510,18 +10482,38 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Accepted == nil {
-				m.Accepted = &TailBitmap{}
+			if m.OtherLeader == nil {
+				m.OtherLeader = &LeaderId{}
 			}
-			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.OtherLeader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.OK = bool(v != 0)
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4531,33 +10523,29 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Committed == nil {
-				m.Committed = &TailBitmap{}
-			}
-			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Err = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Applied", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4567,33 +10555,31 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Applied == nil {
-				m.Applied = &TailBitmap{}
-			}
-			if err := m.Applied.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Result = append(m.Result[:0], dAtA[iNdEx:postIndex]...)
+			if m.Result == nil {
+				m.Result = []byte{}
 			}
 			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
 			}
-			var msglen int
+			m.Seq = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4603,31 +10589,14 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Seq |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthTraft
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthTraft
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Committer == nil {
-				m.Committer = &LeaderId{}
-			}
-			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 10:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4654,32 +10623,13 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.VotedFor == nil {
-				m.VotedFor = &LeaderId{}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 11:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VoteExpireAt", wireType)
-			}
-			m.VoteExpireAt = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.VoteExpireAt |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -4701,7 +10651,7 @@ func (m *ReplicaStatus) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReplicaInfo) Unmarshal(dAtA []byte) error {
+func (m *TransferLeadershipReq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4724,68 +10674,17 @@ func (m *ReplicaInfo) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaInfo: wiretype end group for non-group")
+			return fmt.Errorf("proto: TransferLeadershipReq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TransferLeadershipReq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
-			}
-			m.Id = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Id |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthTraft
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthTraft
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Addr = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Position", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TargetId", wireType)
 			}
-			m.Position = 0
+			m.TargetId = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -4795,7 +10694,7 @@ func (m *ReplicaInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Position |= int64(b&0x7F) << shift
+				m.TargetId |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -4821,7 +10720,7 @@ func (m *ReplicaInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
+func (m *TransferLeadershipReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4844,15 +10743,67 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ClusterConfig: wiretype end group for non-group")
+			return fmt.Errorf("proto: TransferLeadershipReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ClusterConfig: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TransferLeadershipReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 11:
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.OK = bool(v != 0)
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Err = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OtherLeader", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4879,95 +10830,100 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Members == nil {
-				m.Members = make(map[int64]*ReplicaInfo)
+			if m.OtherLeader == nil {
+				m.OtherLeader = &LeaderId{}
 			}
-			var mapkey int64
-			var mapvalue *ReplicaInfo
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowTraft
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowTraft
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapkey |= int64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowTraft
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthTraft
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthTraft
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &ReplicaInfo{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipTraft(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if (skippy < 0) || (iNdEx+skippy) < 0 {
-						return ErrInvalidLengthTraft
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+			if err := m.OtherLeader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ChangeMembersReq) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ChangeMembersReq: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ChangeMembersReq: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Add", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Members[mapkey] = mapvalue
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Add = append(m.Add, &ReplicaInfo{})
+			if err := m.Add[len(m.Add)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 21:
+		case 2:
 			if wireType == 0 {
-				var v uint64
+				var v int64
 				for shift := uint(0); ; shift += 7 {
 					if shift >= 64 {
 						return ErrIntOverflowTraft
@@ -4977,12 +10933,12 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 					}
 					b := dAtA[iNdEx]
 					iNdEx++
-					v |= uint64(b&0x7F) << shift
+					v |= int64(b&0x7F) << shift
 					if b < 0x80 {
 						break
 					}
 				}
-				m.Quorums = append(m.Quorums, v)
+				m.Remove = append(m.Remove, v)
 			} else if wireType == 2 {
 				var packedLen int
 				for shift := uint(0); ; shift += 7 {
@@ -5017,11 +10973,11 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 					}
 				}
 				elementCount = count
-				if elementCount != 0 && len(m.Quorums) == 0 {
-					m.Quorums = make([]uint64, 0, elementCount)
+				if elementCount != 0 && len(m.Remove) == 0 {
+					m.Remove = make([]int64, 0, elementCount)
 				}
 				for iNdEx < postIndex {
-					var v uint64
+					var v int64
 					for shift := uint(0); ; shift += 7 {
 						if shift >= 64 {
 							return ErrIntOverflowTraft
@@ -5031,15 +10987,15 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 						}
 						b := dAtA[iNdEx]
 						iNdEx++
-						v |= uint64(b&0x7F) << shift
+						v |= int64(b&0x7F) << shift
 						if b < 0x80 {
 							break
 						}
 					}
-					m.Quorums = append(m.Quorums, v)
+					m.Remove = append(m.Remove, v)
 				}
 			} else {
-				return fmt.Errorf("proto: wrong wireType = %d for field Quorums", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Remove", wireType)
 			}
 		default:
 			iNdEx = preIndex
@@ -5062,7 +11018,7 @@ func (m *ClusterConfig) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *VoteReq) Unmarshal(dAtA []byte) error {
+func (m *ChangeMembersProgress) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5085,17 +11041,17 @@ func (m *VoteReq) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: VoteReq: wiretype end group for non-group")
+			return fmt.Errorf("proto: ChangeMembersProgress: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: VoteReq: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ChangeMembersProgress: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Candidate", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Stage", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -5105,33 +11061,29 @@ func (m *VoteReq) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Candidate == nil {
-				m.Candidate = &LeaderId{}
-			}
-			if err := m.Candidate.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Stage = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -5141,31 +11093,27 @@ func (m *VoteReq) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Committer == nil {
-				m.Committer = &LeaderId{}
-			}
-			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Err = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field OtherLeader", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5192,121 +11140,16 @@ func (m *VoteReq) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Accepted == nil {
-				m.Accepted = &TailBitmap{}
+			if m.OtherLeader == nil {
+				m.OtherLeader = &LeaderId{}
 			}
-			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.OtherLeader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipTraft(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthTraft
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *VoteReply) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowTraft
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: VoteReply: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: VoteReply: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
-			}
-			m.Id = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Id |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthTraft
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthTraft
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Committer == nil {
-				m.Committer = &LeaderId{}
-			}
-			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5333,18 +11176,18 @@ func (m *VoteReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.VotedFor == nil {
-				m.VotedFor = &LeaderId{}
+			if m.Config == nil {
+				m.Config = &ClusterConfig{}
 			}
-			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 21:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
 			}
-			var msglen int
+			m.Seq = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -5354,31 +11197,64 @@ func (m *VoteReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Seq |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthTraft
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraft(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthTraft
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Accepted == nil {
-				m.Accepted = &TailBitmap{}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TimeoutNowReq) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraft
 			}
-			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
-		case 22:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TimeoutNowReq: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TimeoutNowReq: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5405,16 +11281,16 @@ func (m *VoteReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Committed == nil {
-				m.Committed = &TailBitmap{}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 30:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5441,8 +11317,10 @@ func (m *VoteReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &Record{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5467,7 +11345,7 @@ func (m *VoteReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
+func (m *TimeoutNowReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5490,13 +11368,69 @@ func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogForwardReq: wiretype end group for non-group")
+			return fmt.Errorf("proto: TimeoutNowReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogForwardReq: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TimeoutNowReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.OK = bool(v != 0)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.VotedFor == nil {
+				m.VotedFor = &LeaderId{}
+			}
+			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
@@ -5532,9 +11466,9 @@ func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 2:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5561,8 +11495,10 @@ func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Logs = append(m.Logs, &Record{})
-			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5587,7 +11523,7 @@ func (m *LogForwardReq) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
+func (m *FetchLogsReq) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5610,15 +11546,15 @@ func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogForwardReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: FetchLogsReq: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogForwardReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FetchLogsReq: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VotedFor", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5645,10 +11581,10 @@ func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.VotedFor == nil {
-				m.VotedFor = &LeaderId{}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			if err := m.VotedFor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5724,26 +11660,6 @@ func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 10:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTraft
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			m.OK = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraft(dAtA[iNdEx:])
@@ -5765,7 +11681,7 @@ func (m *LogForwardReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ProposeReply) Unmarshal(dAtA []byte) error {
+func (m *FetchLogsReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5788,15 +11704,15 @@ func (m *ProposeReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ProposeReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: FetchLogsReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ProposeReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FetchLogsReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OtherLeader", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committer", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5823,18 +11739,18 @@ func (m *ProposeReply) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.OtherLeader == nil {
-				m.OtherLeader = &LeaderId{}
+			if m.Committer == nil {
+				m.Committer = &LeaderId{}
 			}
-			if err := m.OtherLeader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Committer.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OK", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
-			var v int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -5844,17 +11760,33 @@ func (m *ProposeReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.OK = bool(v != 0)
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Accepted == nil {
+				m.Accepted = &TailBitmap{}
+			}
+			if err := m.Accepted.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Committed", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTraft
@@ -5864,23 +11796,61 @@ func (m *ProposeReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthTraft
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthTraft
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Err = string(dAtA[iNdEx:postIndex])
+			if m.Committed == nil {
+				m.Committed = &TailBitmap{}
+			}
+			if err := m.Committed.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Logs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraft
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTraft
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTraft
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Logs = append(m.Logs, &Record{})
+			if err := m.Logs[len(m.Logs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -0,0 +1,204 @@
+package traft
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// transferTimeout is the max time a leadership transfer takes.
+// Proposals are refused in the meantime.
+var transferTimeout = time.Second * 3
+
+// transferCheckInterval is how often the leader checks if the target has got
+// all its logs, and then if the target is elected.
+var transferCheckInterval = time.Millisecond * 10
+
+// leadershipTransfer is a leadership transfer in progress on the leader.
+type leadershipTransfer struct {
+	committer *LeaderId
+	target    int64
+}
+
+// transferLeadership makes replica `target` the leader.
+// The leader stops accepting proposals, waits until target has all its logs,
+// then tells target to elect itself at once with a greater term.
+//
+// It returns the leader after the transfer.
+// If this replica is not the leader, it returns the one it follows and
+// ErrNotLeader.
+func (tr *TRaft) transferLeadership(target int64) (*LeaderId, error) {
+
+	var committer *LeaderId
+	rst := tr.queryOrStop("func", func() error {
+		var err error
+		committer, err = tr.hdlTransferStart(target)
+		return err
+	})
+	if rst == nil {
+		return nil, ErrStopped
+	}
+	if rst.err != nil || committer.Id == target {
+		return committer, rst.err
+	}
+
+	defer tr.queryOrStop("func", func() error {
+		tr.hdlTransferEnd(committer)
+		return nil
+	})
+
+	deadline := tr.clock.Now() + int64(transferTimeout)
+	sent := false
+
+	for {
+		var leader *LeaderId
+		var req *TimeoutNowReq
+		var to ReplicaInfo
+
+		rst := tr.queryOrStop("func", func() error {
+			me := tr.Status[tr.Id]
+			leader = me.VotedFor.Clone()
+			if !leader.Equal(committer) || sent {
+				return nil
+			}
+
+			m := tr.Config.Members[target]
+			if m == nil {
+				return errors.Wrapf(ErrNotMember, "target: %d", target)
+			}
+
+			if !tr.Status[target].Accepted.Includes(me.Accepted) {
+				// do not wait for a replicator that is backing off.
+				tr.notifyReplicators()
+				return nil
+			}
+
+			req = &TimeoutNowReq{
+				Committer: committer.Clone(),
+				Accepted:  me.Accepted.Clone(),
+			}
+			to = *m
+			return nil
+		})
+		if rst == nil {
+			return nil, ErrStopped
+		}
+		if rst.err != nil {
+			return leader, rst.err
+		}
+
+		if !leader.Equal(committer) {
+			if leader.Id == target {
+				return leader, nil
+			}
+			return leader, errors.Wrapf(ErrLeaderLost,
+				"transferring to %d, current leader: %s", target, leader.ShortStr())
+		}
+
+		if req != nil {
+			reply, err := tr.transport.SendTimeoutNow(&to, req)
+			sent = err == nil && reply.OK
+
+			lg.Infow("transfer-leadership:timeout-now",
+				"target", target,
+				"err", err,
+				"reply", reply)
+		}
+
+		if tr.clock.Now() > deadline {
+			return leader, errors.Wrapf(ErrTimeout, "transferring to %d", target)
+		}
+
+		tr.sleep(transferCheckInterval)
+	}
+}
+
+// hdlTransferStart starts transferring leadership to target if this replica
+// is the leader.
+// It returns the leader this replica follows.
+// It must be called from Loop().
+func (tr *TRaft) hdlTransferStart(target int64) (*LeaderId, error) {
+	me := tr.Status[tr.Id]
+	leader := me.VotedFor.Clone()
+
+	if leader.Id != tr.Id ||
+		!leader.Equal(me.Committer) ||
+		tr.clock.Now() > me.VoteExpireAt {
+		return leader, ErrNotLeader
+	}
+
+	if _, ok := tr.Config.Members[target]; !ok {
+		return leader, errors.Wrapf(ErrNotMember, "target: %d", target)
+	}
+
+	if tr.transferring != nil {
+		return leader, errors.Wrapf(ErrTransferring, "to: %d", tr.transferring.target)
+	}
+
+	if target == tr.Id {
+		return leader, nil
+	}
+
+	lg.Infow("transfer-leadership:start", "committer", leader.ShortStr(), "target", target)
+
+	tr.transferring = &leadershipTransfer{
+		committer: leader,
+		target:    target,
+	}
+	return leader, nil
+}
+
+// hdlTransferEnd lets the leader accept proposals again, after a leadership
+// transfer by committer finished or failed.
+// It must be called from Loop().
+func (tr *TRaft) hdlTransferEnd(committer *LeaderId) {
+	if tr.transferring != nil && tr.transferring.committer.Equal(committer) {
+		tr.transferring = nil
+	}
+}
+
+// hdlTimeoutNow makes VoteLoop() start an election at once, if the leader this
+// replica follows asks to and this replica has got all logs of the leader.
+// It must be called from Loop().
+func (tr *TRaft) hdlTimeoutNow(req *TimeoutNowReq) *TimeoutNowReply {
+	me := tr.Status[tr.Id]
+
+	if req.Committer.Equal(me.VotedFor) &&
+		req.Committer.Cmp(me.Committer) > 0 &&
+		me.Committed.Includes(req.Accepted) {
+
+		// No log is forwarded to this replica since the leader is elected,
+		// but all logs of the leader are committed here, thus they are the
+		// same as the leader's.
+		tr.discardUncommitted()
+		me.Committer = req.Committer.Clone()
+		tr.persistHardState()
+	}
+
+	repl := &TimeoutNowReply{
+		VotedFor:  me.VotedFor.Clone(),
+		Committer: me.Committer.Clone(),
+		Accepted:  me.Accepted.Clone(),
+	}
+
+	if !req.Committer.Equal(me.VotedFor) ||
+		!req.Committer.Equal(me.Committer) ||
+		!me.Accepted.Includes(req.Accepted) {
+
+		lg.Infow("hdl-timeout-now:refused",
+			"req.Committer", req.Committer.ShortStr(),
+			"me.VotedFor", me.VotedFor.ShortStr(),
+			"me.Committer", me.Committer.ShortStr())
+		return repl
+	}
+
+	tr.sendMsg("hdl-timeout-now:elect", "committer", req.Committer)
+
+	select {
+	case tr.electCh <- struct{}{}:
+	default:
+	}
+
+	repl.OK = true
+	return repl
+}
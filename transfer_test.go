@@ -0,0 +1,248 @@
+package traft
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTRaft_hdlTransferStart(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	cases := []struct {
+		votedFor  *LeaderId
+		committer *LeaderId
+		expireIn  int64
+		target    int64
+		wantErr   error
+	}{
+		{lid(2, 1), lid(2, 1), leaderLease, 2, nil},
+		{lid(2, 1), lid(2, 1), leaderLease, 1, nil},
+		{lid(2, 1), lid(2, 1), leaderLease, 4, ErrNotMember},
+		{lid(2, 1), lid(2, 1), -1, 2, ErrNotLeader},
+		{lid(2, 2), lid(2, 2), leaderLease, 2, ErrNotLeader},
+		// voted for myself but not yet won
+		{lid(2, 1), lid(1, 2), leaderLease, 2, ErrNotLeader},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+		tr.initTraft(c.committer, c.committer, []int64{}, nil, nil, c.votedFor)
+		tr.Status[1].VoteExpireAt = uSecondI64() + c.expireIn
+
+		leader, err := tr.hdlTransferStart(c.target)
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.votedFor, leader, "%d-th: case: %+v", i+1, c)
+
+		transferring := c.wantErr == nil && c.target != 1
+		ta.Equal(transferring, tr.transferring != nil, "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_hdlTransferStart_refuseProposal(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	defer tr.Stop()
+
+	tr.initTraft(lid(2, 1), lid(2, 1), []int64{}, nil, nil, lid(2, 1))
+	tr.Status[1].VoteExpireAt = uSecondI64() + leaderLease
+
+	_, err := tr.hdlTransferStart(2)
+	ta.Nil(err)
+
+	_, err = tr.hdlTransferStart(3)
+	ta.Equal(ErrTransferring, errors.Cause(err))
+
+	ch := make(chan *ProposeReply, 1)
+	tr.hdlPropose(&ProposeReq{Cmd: NewCmdI64("set", "x", 1)}, ch)
+	reply := <-ch
+	ta.False(reply.OK)
+	ta.Equal(ErrTransferring.Error(), reply.Err)
+	ta.Equal(int64(0), tr.logs.LastIndex()+1)
+
+	// a transfer by another leadership does not end this one.
+	tr.hdlTransferEnd(lid(1, 1))
+	ta.NotNil(tr.transferring)
+
+	tr.hdlTransferEnd(lid(2, 1))
+	ta.Nil(tr.transferring)
+
+	tr.hdlPropose(&ProposeReq{Cmd: NewCmdI64("set", "x", 1)}, ch)
+	ta.Equal(int64(1), tr.logs.LastIndex()+1)
+}
+
+func TestTRaft_hdlTimeoutNow(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	cases := []struct {
+		committer *LeaderId
+		lsns      []int64
+		committed []int64
+		votedFor  *LeaderId
+
+		req *TimeoutNowReq
+
+		wantOK        bool
+		wantCommitter *LeaderId
+	}{
+		{
+			lid(2, 2), []int64{0, 1, 2}, nil, lid(2, 2),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			true, lid(2, 2),
+		},
+		{
+			lid(2, 2), []int64{0, 1}, nil, lid(2, 2),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			false, lid(2, 2),
+		},
+		{
+			lid(2, 2), []int64{0, 1, 2}, nil, lid(3, 3),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			false, lid(2, 2),
+		},
+		{
+			// nothing is forwarded since lid(2, 2) is elected, but all are
+			// committed.
+			lid(1, 2), []int64{0, 1, 2}, []int64{0, 1, 2}, lid(2, 2),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			true, lid(2, 2),
+		},
+		{
+			lid(1, 2), []int64{0, 1, 2}, []int64{0, 1}, lid(2, 2),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			false, lid(1, 2),
+		},
+		{
+			// accepted from an older committer but none is committed: the
+			// leader has to forward logs to it first.
+			lid(1, 2), []int64{0, 1, 2}, nil, lid(2, 2),
+			&TimeoutNowReq{Committer: lid(2, 2), Accepted: bm(3)},
+			false, lid(1, 2),
+		},
+	}
+
+	for i, c := range cases {
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+		tr.initTraft(c.committer, c.committer, c.lsns, nil, c.committed, c.votedFor)
+
+		repl := tr.hdlTimeoutNow(c.req)
+		ta.Equal(c.wantOK, repl.OK, "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantCommitter, tr.Status[1].Committer, "%d-th: case: %+v", i+1, c)
+
+		elect := 0
+		if c.wantOK {
+			elect = 1
+		}
+		ta.Equal(elect, len(tr.electCh), "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
+func TestTRaft_transferLeadership_uncommitted(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	n := NewMemNetwork()
+	ts := serveMemCluster(n, []int64{1, 2, 3})
+	defer stopAll(ts)
+
+	leader := lid(2, 1)
+
+	// The target and replica 3 accepted all logs of the leader, but do not
+	// yet know they are committed.
+	for i, tr := range ts {
+		var committed []int64
+		if i == 0 {
+			committed = []int64{0, 1, 2}
+		}
+		inLoop(tr, func() {
+			tr.initTraft(leader, leader, []int64{0, 1, 2}, nil, committed, leader)
+			tr.Status[tr.Id].VoteExpireAt = uSecondI64() + leaderLease
+		})
+	}
+	inLoop(ts[0], func() {
+		st := ts[0].Status[2]
+		st.Committer = leader.Clone()
+		st.Accepted = bm(3)
+	})
+
+	ts[1].StartVoteLoop()
+	ts[2].StartVoteLoop()
+
+	got, err := ts[0].transferLeadership(2)
+	ta.Nil(err)
+	ta.Equal(int64(2), got.Id)
+
+	// The new leader commits the logs it accepted from the former one.
+	waitFor(ta, ts[1], func() bool {
+		me := ts[1].Status[2]
+		return me.VotedFor.Equal(me.Committer) && me.Committed.Includes(bm(3))
+	})
+}
+
+func TestFault_transferLeadership(t *testing.T) {
+
+	ta := require.New(t)
+
+	c := newFaultCluster(t, []int64{1, 2, 3})
+
+	c.write("x=1", c.ids...)
+	c.waitKV("x", 1, c.ids...)
+
+	leader := c.waitLeader(c.ids...)
+	target := c.others(leader)[0]
+
+	// a follower redirects to the leader.
+	reply, err := c.get(target).TransferLeadership(context.Background(),
+		&TransferLeadershipReq{TargetId: target})
+	ta.Nil(err)
+	ta.False(reply.OK)
+	ta.Equal(ErrNotLeader.Error(), reply.Err)
+
+	// The target may be behind in logs.
+	c.net.Cut(leader, target)
+	c.write("x=2", c.ids...)
+	c.net.Heal()
+
+	// The leader may give up, e.g., refused by a replica that voted for a
+	// candidate that did not win.
+	if l := c.waitLeader(c.ids...); l != leader {
+		leader = l
+		target = c.others(leader)[0]
+	}
+
+	start := time.Now()
+
+	reply, err = c.get(leader).TransferLeadership(context.Background(),
+		&TransferLeadershipReq{TargetId: target})
+	ta.Nil(err)
+	ta.True(reply.OK, "reply: %+v", reply)
+	ta.Equal(target, reply.OtherLeader.Id)
+
+	ta.Equal(target, c.waitLeader(c.ids...))
+	ta.True(time.Since(start) < time.Duration(leaderLease),
+		"transfer does not wait for the lease to expire")
+
+	c.write("x=3", c.ids...)
+	c.waitKV("x", 3, c.ids...)
+}
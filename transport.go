@@ -0,0 +1,140 @@
+package traft
+
+import (
+	context "context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Transport sends requests from one replica to the others.
+// By default TRaft uses gRPC.
+// MemNetwork provides in-process transports to run a cluster in one test.
+type Transport interface {
+	SendVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error)
+	SendPreVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error)
+	SendLogForward(to *ReplicaInfo, req *LogForwardReq) (*LogForwardReply, error)
+	SendTimeoutNow(to *ReplicaInfo, req *TimeoutNowReq) (*TimeoutNowReply, error)
+	SendFetchLogs(to *ReplicaInfo, req *FetchLogsReq) (*FetchLogsReply, error)
+
+	// SendSnapshotChunks sends chunks of a snapshot through one stream.
+	// The receiver replies when the snapshot is installed or a chunk is
+	// refused.
+	SendSnapshotChunks(to *ReplicaInfo, chunks []*SnapshotChunk) (*InstallSnapshotReply, error)
+
+	// UpdateConfig is called when the cluster config changes.
+	UpdateConfig(conf *ClusterConfig)
+
+	// Close releases resources such as connections.
+	Close()
+}
+
+// grpcTransport sends requests with gRPC through long-lived connections.
+type grpcTransport struct {
+	peers *peers
+}
+
+func newGRPCTransport() *grpcTransport {
+	return &grpcTransport{
+		peers: newPeers(),
+	}
+}
+
+// rpcTo sends an rpc to replica ri.
+// It returns the error of connecting to ri or the error returned by action.
+func (t *grpcTransport) rpcTo(ri *ReplicaInfo, action func(TRaftClient, context.Context) error) error {
+
+	cli, err := t.peers.client(ri)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+
+	return action(cli, ctx)
+}
+
+func (t *grpcTransport) SendVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error) {
+	var reply *VoteReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) (err error) {
+		reply, err = cli.Vote(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) SendPreVote(to *ReplicaInfo, req *VoteReq) (*VoteReply, error) {
+	var reply *VoteReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) (err error) {
+		reply, err = cli.PreVote(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) SendTimeoutNow(to *ReplicaInfo, req *TimeoutNowReq) (*TimeoutNowReply, error) {
+	var reply *TimeoutNowReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) (err error) {
+		reply, err = cli.TimeoutNow(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) SendFetchLogs(to *ReplicaInfo, req *FetchLogsReq) (*FetchLogsReply, error) {
+	var reply *FetchLogsReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) (err error) {
+		reply, err = cli.FetchLogs(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) SendLogForward(to *ReplicaInfo, req *LogForwardReq) (*LogForwardReply, error) {
+	var reply *LogForwardReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) (err error) {
+		reply, err = cli.LogForward(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) SendSnapshotChunks(to *ReplicaInfo, chunks []*SnapshotChunk) (*InstallSnapshotReply, error) {
+	var reply *InstallSnapshotReply
+	err := t.rpcTo(to, func(cli TRaftClient, ctx context.Context) error {
+
+		stream, err := cli.InstallSnapshot(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "open stream")
+		}
+
+		for _, c := range chunks {
+			err = stream.Send(c)
+			if err != nil {
+				// io.EOF means the receiver closed the stream, e.g., it
+				// expects a different ChunkOffset. The reason is in the
+				// reply.
+				if err == io.EOF {
+					break
+				}
+				return errors.Wrapf(err, "send chunk at %d", c.ChunkOffset)
+			}
+		}
+
+		reply, err = stream.CloseAndRecv()
+		if err != nil {
+			return errors.Wrapf(err, "recv reply")
+		}
+		return nil
+	})
+	return reply, err
+}
+
+func (t *grpcTransport) UpdateConfig(conf *ClusterConfig) {
+	t.peers.updateConfig(conf)
+}
+
+func (t *grpcTransport) Close() {
+	t.peers.close()
+}
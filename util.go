@@ -1,12 +1,16 @@
 package traft
 
 import (
-	context "context"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
-	grpc "google.golang.org/grpc"
+	"github.com/pkg/errors"
 )
 
 func cmpI64(a, b int64) int {
@@ -59,22 +63,85 @@ func serveCluster(ids []int64) []*TRaft {
 	return trafts
 }
 
-// send rpc to addr.
-// TODO use a single loop to send to one replica
-func rpcTo(addr string,
-	action func(TRaftClient, context.Context)) {
+// serveMemCluster starts replicas connected by a MemNetwork.
+// Unlike serveCluster, no port is used.
+func serveMemCluster(n *MemNetwork, ids []int64) []*TRaft {
 
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	cluster := make(map[int64]string)
+
+	trafts := make([]*TRaft, 0)
+
+	for _, id := range ids {
+		cluster[id] = fmt.Sprintf("mem-%d", id)
+	}
+
+	for _, id := range ids {
+		srv := NewTRaft(id, cluster, WithTransport(n.Transport(id)))
+		trafts = append(trafts, srv)
+
+		n.Register(cluster[id], srv)
+		srv.StartMainLoop()
+	}
+
+	return trafts
+}
+
+// writeFileAtomic writes data with a crc32 checksum to dir/fn atomically:
+// it writes a tmp file, fsync it then rename it.
+func writeFileAtomic(dir, fn string, data []byte) error {
+
+	buf := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.Checksum(data, crcTable))
+	copy(buf[4:], data)
+
+	path := filepath.Join(dir, fn)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
-		// TODO check error
-		panic("wooooooh")
+		return errors.Wrapf(err, "create %s", tmp)
 	}
-	defer conn.Close()
 
-	cli := NewTRaftClient(conn)
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "write %s", tmp)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return errors.Wrapf(err, "rename %s", tmp)
+	}
+
+	return syncDir(dir)
+}
+
+// readFileChecked reads data written by writeFileAtomic.
+// It returns nil if the file does not exist.
+func readFileChecked(dir, fn string) ([]byte, error) {
+	path := filepath.Join(dir, fn)
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "read %s", path)
+	}
+
+	if len(buf) < 4 {
+		return nil, errors.Errorf("file too short: %s", path)
+	}
+
+	data := buf[4:]
+	if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(buf[0:4]) {
+		return nil, errors.Errorf("checksum mismatch: %s", path)
+	}
 
-	action(cli, ctx)
+	return data, nil
 }
@@ -1,12 +1,34 @@
 package traft
 
 import (
+	context "context"
 	fmt "fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	grpc "google.golang.org/grpc"
 )
 
+// rpcTo sends an rpc to addr as a client outside the cluster does.
+// It dials for every call.
+func rpcTo(addr string,
+	action func(TRaftClient, context.Context)) {
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	cli := NewTRaftClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	action(cli, ctx)
+}
+
 func Test_serveCluster(t *testing.T) {
 
 	ta := require.New(t)
@@ -34,8 +56,8 @@ func Test_serveCluster(t *testing.T) {
 			&ReplicaInfo{Id: 3, Addr: ":5503", Position: 2},
 		}, tr.Config.SortedReplicaInfos())
 
-		ta.Equal(int64(0), tr.LogOffset)
-		ta.Equal([]*Record{}, tr.Logs)
+		ta.Equal(int64(0), tr.logs.FirstIndex())
+		ta.Equal([]*Record{}, tr.allLogs())
 		ta.Equal(ids[i], tr.Id)
 		for _, id := range ids {
 			st := tr.Status[id]
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	proto "github.com/gogo/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -45,7 +46,9 @@ func withCluster(t *testing.T,
 
 	ts := serveCluster(ids)
 	for i, id := range ids {
-		ts[i].initTraft(lid(0, 0), lid(0, 0), []int64{}, nil, nil, lid(0, id))
+		inLoop(ts[i], func() {
+			ts[i].initTraft(lid(0, 0), lid(0, 0), []int64{}, nil, nil, lid(0, id))
+		})
 	}
 
 	t.Run(name, func(t *testing.T) {
@@ -55,6 +58,33 @@ func withCluster(t *testing.T,
 	stopAll(ts)
 }
 
+// inLoop runs f in the Loop of tr, thus f does not race with the running
+// TRaft.
+func inLoop(tr *TRaft, f func()) {
+	tr.queryOrStop("func", func() error {
+		f()
+		return nil
+	})
+}
+
+// statusOf returns a copy of the status of replica `id` that tr has.
+func statusOf(tr *TRaft, id int64) *ReplicaStatus {
+	var st *ReplicaStatus
+	inLoop(tr, func() {
+		st = proto.Clone(tr.Status[id]).(*ReplicaStatus)
+	})
+	return st
+}
+
+// logsOf returns all logs tr has.
+func logsOf(tr *TRaft) []*Record {
+	var logs []*Record
+	inLoop(tr, func() {
+		logs = tr.allLogs()
+	})
+	return logs
+}
+
 func TestTRaft_Vote(t *testing.T) {
 
 	ta := require.New(t)
@@ -74,10 +104,12 @@ func TestTRaft_Vote(t *testing.T) {
 		voter voterStat,
 	) *VoteReply {
 
-		t1.initTraft(
-			voter.committer, voter.author, voter.logs, voter.nilLogs, nil,
-			voter.votedFor,
-		)
+		inLoop(t1, func() {
+			t1.initTraft(
+				voter.committer, voter.author, voter.logs, voter.nilLogs, nil,
+				voter.votedFor,
+			)
+		})
 
 		req := &VoteReq{
 			Candidate: cand.candidateId,
@@ -201,7 +233,7 @@ func TestTRaft_Vote(t *testing.T) {
 			},
 			"%d-th: case: %+v", i+1, c)
 
-		ta.InDelta(uSecondI64()+leaderLease, t1.Status[id].VoteExpireAt, 1000*1000*1000)
+		ta.InDelta(uSecondI64()+leaderLease, statusOf(t1, id).VoteExpireAt, 1000*1000*1000)
 	}
 }
 
@@ -299,27 +331,35 @@ func TestTRaft_VoteOnce(t *testing.T) {
 			[]int64{0, 1, 2},
 			func(t *testing.T, ts []*TRaft) {
 				ta := require.New(t)
-				for i, cmt := range c.committers {
-					if cmt != nil {
-						ts[i].Status[int64(i)].Committer = cmt
+				// VotedFor is set first: Loop does not allow a Committer
+				// greater than VotedFor.
+				for i, v := range c.votedFors {
+					if v != nil {
+						inLoop(ts[i], func() {
+							ts[i].Status[int64(i)].VotedFor = v
+						})
 					}
 				}
 
-				for i, v := range c.votedFors {
-					if v != nil {
-						ts[i].Status[int64(i)].VotedFor = v
+				for i, cmt := range c.committers {
+					if cmt != nil {
+						inLoop(ts[i], func() {
+							ts[i].Status[int64(i)].Committer = cmt
+						})
 					}
 				}
 
 				for i, ls := range c.logs {
 					for _, l := range ls {
-						ts[i].addlogs(l)
+						inLoop(ts[i], func() {
+							ts[i].addlogs(l)
+						})
 					}
 				}
 
-				voted, err, higher := VoteOnce(
+				voted, err, higher := ts[0].VoteOnce(
 					c.candidate,
-					ExportLogStatus(ts[0].Status[0]),
+					ExportLogStatus(statusOf(ts[0], 0)),
 					ts[0].Config.Clone(),
 				)
 
@@ -334,6 +374,259 @@ func TestTRaft_VoteOnce(t *testing.T) {
 	}
 }
 
+func TestTRaft_VoteOnce_twoWinners(t *testing.T) {
+
+	// A voter grants a greater candidate of the term it voted, thus two
+	// candidates of the same term both win. The smaller one then loses its
+	// voters.
+
+	lid := NewLeaderId
+
+	withCluster(t, "twoWinners",
+		[]int64{0, 1, 2},
+		func(t *testing.T, ts []*TRaft) {
+			ta := require.New(t)
+
+			inLoop(ts[0], func() { ts[0].Status[0].VotedFor = lid(1, 0) })
+			voted, err, _ := ts[0].VoteOnce(
+				lid(1, 0),
+				ExportLogStatus(statusOf(ts[0], 0)),
+				ts[0].Config.Clone(),
+			)
+			ta.Nil(err)
+			ta.NotNil(voted)
+
+			// VoteOnce returns once a quorum granted.
+			waitFor(ta, ts[2], func() bool {
+				return ts[2].Status[2].VotedFor.Equal(lid(1, 0))
+			})
+
+			inLoop(ts[1], func() { ts[1].Status[1].VotedFor = lid(1, 1) })
+			voted, err, _ = ts[1].VoteOnce(
+				lid(1, 1),
+				ExportLogStatus(statusOf(ts[1], 1)),
+				ts[1].Config.Clone(),
+			)
+			ta.Nil(err)
+			ta.NotNil(voted)
+
+			for i, tr := range ts {
+				waitFor(ta, tr, func() bool {
+					return tr.Status[int64(i)].VotedFor.Equal(lid(1, 1))
+				})
+			}
+		})
+}
+
+func TestTRaft_PreVoteOnce(t *testing.T) {
+
+	// cluster = {0, 1, 2}
+	// ts[0] pre-votes with lid(1, 0).
+
+	lid := NewLeaderId
+
+	alive := uSecondI64() + int64(time.Second*10)
+
+	cases := []struct {
+		name       string
+		committers []*LeaderId
+		votedFors  []*LeaderId
+		expireAts  []int64
+		want       error
+	}{
+		{name: "2emptyVoter",
+			want: nil,
+		},
+		{name: "leader-alive-on-one",
+			votedFors: []*LeaderId{nil, lid(0, 2)},
+			expireAts: []int64{0, alive},
+			want:      nil,
+		},
+		{name: "leader-alive-on-two",
+			votedFors: []*LeaderId{nil, lid(0, 2), lid(0, 2)},
+			expireAts: []int64{0, alive, alive},
+			want:      ErrLeaderAlive,
+		},
+		{name: "stalelog",
+			committers: []*LeaderId{nil, lid(2, 0), lid(3, 0)},
+			votedFors:  []*LeaderId{nil, lid(2, 0), lid(3, 0)},
+			want:       ErrStaleLog,
+		},
+		{name: "higherTerm-is-left-to-vote",
+			votedFors: []*LeaderId{nil, lid(3, 1), lid(5, 2)},
+			want:      nil,
+		},
+		{name: "higherTerm-leader-alive",
+			votedFors: []*LeaderId{nil, lid(3, 1), lid(5, 2)},
+			expireAts: []int64{0, alive, alive},
+			want:      ErrStaleTermId,
+		},
+	}
+
+	for _, c := range cases {
+		withCluster(t, c.name,
+			[]int64{0, 1, 2},
+			func(t *testing.T, ts []*TRaft) {
+				ta := require.New(t)
+				// VotedFor is set first: Loop does not allow a Committer
+				// greater than VotedFor.
+				for i, v := range c.votedFors {
+					if v != nil {
+						inLoop(ts[i], func() {
+							ts[i].Status[int64(i)].VotedFor = v
+						})
+					}
+				}
+
+				for i, cmt := range c.committers {
+					if cmt != nil {
+						inLoop(ts[i], func() {
+							ts[i].Status[int64(i)].Committer = cmt
+						})
+					}
+				}
+
+				for i, e := range c.expireAts {
+					inLoop(ts[i], func() {
+						ts[i].Status[int64(i)].VoteExpireAt = e
+					})
+				}
+
+				better, err := ts[0].PreVoteOnce(
+					lid(1, 0),
+					ExportLogStatus(statusOf(ts[0], 0)),
+					ts[0].Config.Clone(),
+				)
+				ta.Equal(c.want, errors.Cause(err))
+
+				// logs are fetched from the one with the greatest log status.
+				if c.want == ErrStaleLog {
+					ta.Equal(int64(2), better.Id)
+				} else {
+					ta.Nil(better)
+				}
+
+				// pre-vote changes nothing on voters
+				for i, v := range c.votedFors {
+					if v != nil {
+						ta.Equal(v, statusOf(ts[i], int64(i)).VotedFor)
+					}
+				}
+			})
+	}
+}
+
+func TestTRaft_hdlVoteWin(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+	bm := NewTailBitmap
+
+	tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"})
+	tr.StartMainLoop()
+	defer tr.Stop()
+
+	// only lsn 0, 1 of lid(3, 2) are received, while lid(3, 2) has all logs
+	// committed by lid(2, 3).
+	inLoop(tr, func() {
+		tr.initTraft(lid(3, 2), lid(3, 2), []int64{0, 1}, nil, nil, lid(4, 1))
+	})
+
+	_, logs := buildPseudoLogs(lid(2, 3), []int64{0, 1, 2, 3}, nil)
+	votes := []*VoteReply{
+		{
+			Id:        3,
+			VotedFor:  lid(4, 1),
+			Committer: lid(2, 3),
+			Accepted:  bm(4),
+			Committed: bm(4),
+			Logs:      logs[2:],
+		},
+	}
+
+	var ok bool
+	inLoop(tr, func() {
+		ok = tr.hdlVoteWin(&leaderAndVotes{
+			&LeaderStatus{VotedFor: lid(4, 1), VoteExpireAt: uSecondI64() + leaderLease},
+			votes,
+		})
+	})
+	ta.True(ok)
+
+	me := statusOf(tr, 1)
+	ta.Equal(lid(4, 1), me.Committer)
+	ta.Equal(bm(4), me.Accepted)
+	ta.Equal("[<003#002:000{set(x, 0)}-0→0><003#002:001{set(x, 1)}-0→0>"+
+		"<002#003:002{set(x, 2)}-0→0><002#003:003{set(x, 3)}-0→0>]",
+		RecordsShortStr(logsOf(tr), ""))
+}
+
+func TestTRaft_nextVoteStep(t *testing.T) {
+
+	ta := require.New(t)
+
+	lid := NewLeaderId
+
+	lease := leaderLease / 2
+
+	cases := []struct {
+		votedFor *LeaderId
+		expireIn int64
+		electNow bool
+
+		wantLead      *LeaderId
+		wantCandidate *LeaderId
+		wantPreVote   bool
+		wantWait      time.Duration
+		wantVotedFor  *LeaderId
+	}{
+		{
+			lid(2, 1), lease, false,
+			lid(2, 1), nil, false, 0, lid(2, 1),
+		},
+		{
+			lid(2, 2), lease, false,
+			nil, nil, false, followerSleep, lid(2, 2),
+		},
+		{
+			lid(2, 2), -lease, false,
+			nil, lid(3, 1), true, 0, lid(2, 2),
+		},
+		{
+			// the leader asks me to elect: vote myself at once.
+			lid(2, 2), lease, true,
+			nil, lid(3, 1), false, 0, lid(3, 1),
+		},
+	}
+
+	for i, c := range cases {
+		clock := NewFakeClock(uSecondI64())
+		tr := NewTRaft(1, map[int64]string{1: ":5501", 2: ":5502", 3: ":5503"},
+			WithClock(clock))
+		tr.StartMainLoop()
+
+		var step *voteStep
+		inLoop(tr, func() {
+			tr.initTraft(c.votedFor, c.votedFor, []int64{0}, nil, nil, c.votedFor)
+			tr.Status[1].VoteExpireAt = clock.Now() + c.expireIn
+			step = tr.nextVoteStep(c.electNow)
+		})
+
+		ta.Equal(c.wantLead, step.lead, "%d-th: case: %+v", i+1, c)
+		ta.Equal(c.wantWait, step.wait, "%d-th: case: %+v", i+1, c)
+		if c.wantCandidate == nil {
+			ta.Nil(step.election, "%d-th: case: %+v", i+1, c)
+		} else {
+			ta.Equal(c.wantCandidate, step.election.leadst.VotedFor, "%d-th: case: %+v", i+1, c)
+			ta.Equal(c.wantPreVote, step.election.preVote, "%d-th: case: %+v", i+1, c)
+		}
+		ta.Equal(c.wantVotedFor, statusOf(tr, 1).VotedFor, "%d-th: case: %+v", i+1, c)
+
+		tr.Stop()
+	}
+}
+
 func TestTRaft_query(t *testing.T) {
 
 	ta := require.New(t)
@@ -346,7 +639,9 @@ func TestTRaft_query(t *testing.T) {
 	defer stopAll(ts)
 
 	t1 := ts[0]
-	t1.initTraft(lid(1, 2), lid(3, 4), []int64{5}, nil, nil, lid(2, id1))
+	inLoop(t1, func() {
+		t1.initTraft(lid(1, 2), lid(3, 4), []int64{5}, nil, nil, lid(2, id1))
+	})
 
 	got := query(t1.actionCh, "logStat", nil).v.(*LogStatus)
 	ta.Equal("001#002", got.Committer.ShortStr())
@@ -389,7 +684,9 @@ func readMsg(ts []*TRaft) string {
 	return msg
 }
 
-// waiting for expected message substring to present n times.
+// waiting for expected message substring to present at least n times.
+// A message may present more times than expected, e.g., more than one
+// candidate wins the same term, see TestTRaft_VoteOnce_twoWinners.
 func waitForMsg(ts []*TRaft, msgs map[string]int) {
 	for {
 		msg := readMsg(ts)
@@ -402,7 +699,7 @@ func waitForMsg(ts []*TRaft, msgs map[string]int) {
 
 		all0 := true
 		for _, n := range msgs {
-			all0 = all0 && n == 0
+			all0 = all0 && n <= 0
 		}
 
 		lg.Infow("require-msg", "msgs", msgs)
@@ -423,19 +720,19 @@ func TestTRaft_VoteLoop(t *testing.T) {
 		func(t *testing.T, ts []*TRaft) {
 			ta := require.New(t)
 
-			go ts[0].VoteLoop()
+			ts[0].StartVoteLoop()
 
 			waitForMsg(ts, map[string]int{
 				"vote-win VotedFor:<Term:1 >": 1,
 			})
 
-			ta.Equal(lid(1, 0), ts[0].Status[0].VotedFor)
+			ta.Equal(lid(1, 0), statusOf(ts[0], 0).VotedFor)
 			ta.InDelta(uSecondI64()+leaderLease,
-				ts[0].Status[0].VoteExpireAt, 1000*1000*1000)
+				statusOf(ts[0], 0).VoteExpireAt, 1000*1000*1000)
 
-			ta.Equal(lid(1, 0), ts[1].Status[1].VotedFor)
+			ta.Equal(lid(1, 0), statusOf(ts[1], 1).VotedFor)
 			ta.InDelta(uSecondI64()+leaderLease,
-				ts[1].Status[1].VoteExpireAt, 1000*1000*1000)
+				statusOf(ts[1], 1).VoteExpireAt, 1000*1000*1000)
 		})
 
 	withCluster(t, "emptyVoters/candidate-2",
@@ -443,23 +740,23 @@ func TestTRaft_VoteLoop(t *testing.T) {
 		func(t *testing.T, ts []*TRaft) {
 			ta := require.New(t)
 
-			go ts[1].VoteLoop()
+			ts[1].StartVoteLoop()
 			waitForMsg(ts, map[string]int{
 				"vote-win VotedFor:<Term:1 Id:1 >": 1,
 			})
 
-			ta.Equal(lid(1, 1), ts[1].Status[1].VotedFor)
+			ta.Equal(lid(1, 1), statusOf(ts[1], 1).VotedFor)
 
 			ta.InDelta(uSecondI64()+leaderLease,
-				ts[1].Status[1].VoteExpireAt, 1000*1000*1000)
+				statusOf(ts[1], 1).VoteExpireAt, 1000*1000*1000)
 		})
 
 	withCluster(t, "emptyVoters/candidate-12",
 		[]int64{0, 1, 2},
 		func(t *testing.T, ts []*TRaft) {
 
-			go ts[0].VoteLoop()
-			go ts[1].VoteLoop()
+			ts[0].StartVoteLoop()
+			ts[1].StartVoteLoop()
 
 			// only one succ to elect.
 			// In 1 second, there wont be another winning election.
@@ -472,34 +769,45 @@ func TestTRaft_VoteLoop(t *testing.T) {
 		[]int64{0, 1, 2},
 		func(t *testing.T, ts []*TRaft) {
 
-			go ts[0].VoteLoop()
-			go ts[1].VoteLoop()
-			go ts[2].VoteLoop()
+			ts[0].StartVoteLoop()
+			ts[1].StartVoteLoop()
+			ts[2].StartVoteLoop()
 
-			// only one succ to elect.
-			// In 1 second, there wont be another winning election.
+			// At least one succ to elect.
+			// A voter is allowed to vote for a greater candidate of the
+			// same term, thus two candidates may both win term 1, e.g.,
+			// 001#001 and 001#002, and fewer than 2 fail. Then the smaller
+			// one can not renew its lease with heartbeats.
+			// See TestTRaft_VoteOnce_twoWinners.
 			waitForMsg(ts, map[string]int{
 				"vote-win":  1,
-				"vote-fail": 2,
+				"vote-fail": 1,
 			})
 		})
 
 	withCluster(t, "id2MaxCommitter",
 		[]int64{0, 1, 2},
 		func(t *testing.T, ts []*TRaft) {
-			ts[0].initTraft(lid(2, 1), lid(0, 1), []int64{2}, nil, nil, lid(4, 0))
-			ts[1].initTraft(lid(3, 2), lid(0, 1), []int64{2}, nil, nil, lid(4, 1))
-			ts[2].initTraft(lid(1, 3), lid(0, 1), []int64{2}, nil, nil, lid(4, 2))
+			inLoop(ts[0], func() {
+				ts[0].initTraft(lid(2, 1), lid(0, 1), []int64{2}, nil, nil, lid(4, 0))
+			})
+			inLoop(ts[1], func() {
+				ts[1].initTraft(lid(3, 2), lid(0, 1), []int64{2}, nil, nil, lid(4, 1))
+			})
+			inLoop(ts[2], func() {
+				ts[2].initTraft(lid(1, 3), lid(0, 1), []int64{2}, nil, nil, lid(4, 2))
+			})
 
-			go ts[0].VoteLoop()
-			go ts[1].VoteLoop()
-			go ts[2].VoteLoop()
+			ts[0].StartVoteLoop()
+			ts[1].StartVoteLoop()
+			ts[2].StartVoteLoop()
 
 			// only one succ to elect.
 			// In 1 second, there wont be another winning election.
+			// ts[0] may follow the winner before it fails.
 			waitForMsg(ts, map[string]int{
 				"vote-win VotedFor:<Term:5 Id:1 >": 1,
-				"vote-fail":                        2,
+				"vote-fail":                        1,
 			})
 		})
 
@@ -510,16 +818,24 @@ func TestTRaft_VoteLoop(t *testing.T) {
 			ta := require.New(t)
 			_ = ta
 
-			ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{0, 2}, nil, nil, lid(4, 0))
-			ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{0, 4}, nil, nil, lid(4, 1))
-			ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{0, 3}, nil, []int64{0}, lid(4, 2))
+			inLoop(ts[0], func() {
+				ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{0, 2}, nil, nil, lid(4, 0))
+			})
+			inLoop(ts[1], func() {
+				ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{0, 4}, nil, nil, lid(4, 1))
+			})
+			inLoop(ts[2], func() {
+				ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{0, 3}, nil, []int64{0}, lid(4, 2))
+			})
 			// ts[3].initTraft(lid(1, 2), lid(1, 1), []int64{0, 2, 3}, nil, nil, lid(0, 3))
 			// ts[4].initTraft(lid(1, 2), lid(1, 1), []int64{0, 2, 3}, nil, nil, lid(0, 4))
 
 			ts[3].Stop()
 			ts[4].Stop()
-			ts[1].Status[1].VotedFor = lid(3, 1)
-			go ts[1].VoteLoop()
+			inLoop(ts[1], func() {
+				ts[1].Status[1].VotedFor = lid(3, 1)
+			})
+			ts[1].StartVoteLoop()
 
 			// only one succ to elect.
 			// In 1 second, there wont be another winning election.
@@ -533,22 +849,22 @@ func TestTRaft_VoteLoop(t *testing.T) {
 					"<001#001:002{set(x, 2)}-0→0>",
 					"<002#001:003{set(x, 3)}-0→0>",
 					"<001#001:004{set(x, 4)}-0→0>]"),
-				RecordsShortStr(ts[1].Logs, ""),
+				RecordsShortStr(logsOf(ts[1]), ""),
 			)
 
-			ta.Equal(lid(5, 1), ts[1].Status[1].Committer)
-			ta.Equal(bm(0, 0, 2, 3, 4), ts[1].Status[1].Accepted)
-			ta.Equal(bm(0), ts[1].Status[1].Committed)
+			ta.Equal(lid(5, 1), statusOf(ts[1], 1).Committer)
+			ta.Equal(bm(0, 0, 2, 3, 4), statusOf(ts[1], 1).Accepted)
+			ta.Equal(bm(0), statusOf(ts[1], 1).Committed)
 
-			ta.Equal(lid(2, 0), ts[1].Status[0].Committer)
+			ta.Equal(lid(2, 0), statusOf(ts[1], 0).Committer)
 			// using Equal to avoid comparison between nil and []int64{}
-			ta.True(bm(0).Equal(ts[1].Status[0].Accepted))
-			ta.True(bm(0).Equal(ts[1].Status[0].Committed))
+			ta.True(bm(0).Equal(statusOf(ts[1], 0).Accepted))
+			ta.True(bm(0).Equal(statusOf(ts[1], 0).Committed))
 
-			ta.Equal(lid(1, 2), ts[1].Status[2].Committer)
+			ta.Equal(lid(1, 2), statusOf(ts[1], 2).Committer)
 			// reduced Accepted to Committed
-			ta.Equal(bm(0, 0), ts[1].Status[2].Accepted)
-			ta.Equal(bm(0, 0), ts[1].Status[2].Committed)
+			ta.Equal(bm(0, 0), statusOf(ts[1], 2).Accepted)
+			ta.Equal(bm(0, 0), statusOf(ts[1], 2).Committed)
 		})
 }
 
@@ -570,14 +886,33 @@ func TestTRaft_Propose(t *testing.T) {
 		return reply
 	}
 
+	sendProposeApplied := func(addr string, xcmd interface{}) *ProposeReply {
+		cmd := toCmd(xcmd)
+		var reply *ProposeReply
+		rpcTo(addr, func(cli TRaftClient, ctx context.Context) {
+			var err error
+			reply, err = cli.ProposeApplied(ctx, cmd)
+			if err != nil {
+				lg.Infow("err:", "err", err)
+			}
+		})
+		return reply
+	}
+
 	withCluster(t, "invalidLeader",
 		[]int64{0, 1, 2},
 		func(t *testing.T, ts []*TRaft) {
 			ta := require.New(t)
 
-			ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{}, nil, nil, lid(2, 0))
-			ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{}, nil, nil, lid(3, 1))
-			ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{}, nil, []int64{0}, lid(1, 2))
+			inLoop(ts[0], func() {
+				ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{}, nil, nil, lid(2, 0))
+			})
+			inLoop(ts[1], func() {
+				ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{}, nil, nil, lid(3, 1))
+			})
+			inLoop(ts[2], func() {
+				ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{}, nil, []int64{0}, lid(1, 2))
+			})
 
 			mems := ts[1].Config.Members
 
@@ -590,7 +925,7 @@ func TestTRaft_Propose(t *testing.T) {
 			}, reply)
 
 			// elect ts[1]
-			go ts[1].VoteLoop()
+			ts[1].StartVoteLoop()
 
 			waitForMsg(ts, map[string]int{
 				"vote-win VotedFor:<Term:4 Id:1 >": 1,
@@ -610,14 +945,20 @@ func TestTRaft_Propose(t *testing.T) {
 
 			ta := require.New(t)
 
-			ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{}, nil, nil, lid(3, 0))
-			ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{}, nil, nil, lid(3, 1))
-			ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{}, nil, []int64{0}, lid(3, 2))
+			inLoop(ts[0], func() {
+				ts[0].initTraft(lid(2, 0), lid(1, 1), []int64{}, nil, nil, lid(3, 0))
+			})
+			inLoop(ts[1], func() {
+				ts[1].initTraft(lid(3, 1), lid(1, 1), []int64{}, nil, nil, lid(3, 1))
+			})
+			inLoop(ts[2], func() {
+				ts[2].initTraft(lid(1, 2), lid(2, 1), []int64{}, nil, []int64{0}, lid(3, 2))
+			})
 
 			mems := ts[1].Config.Members
 
 			// elect ts[1]
-			go ts[1].VoteLoop()
+			ts[1].StartVoteLoop()
 
 			waitForMsg(ts, map[string]int{
 				"vote-win VotedFor:<Term:4 Id:1 >": 1,
@@ -627,37 +968,47 @@ func TestTRaft_Propose(t *testing.T) {
 
 			// succ to propsoe
 			reply := sendPropose(mems[1].Addr, "y=1")
-			ta.Equal(&ProposeReply{OK: true}, reply)
+			ta.Equal(&ProposeReply{OK: true, Seq: 0, Committer: lid(4, 1)}, reply)
 
-			ta.Equal(bm(1), ts[1].Status[1].Accepted)
-			ta.Equal(bm(1), ts[1].Status[1].Committed)
+			ta.Equal(bm(1), statusOf(ts[1], 1).Accepted)
+			ta.Equal(bm(1), statusOf(ts[1], 1).Committed)
 			ta.Equal(
 				join("[<004#001:000{set(y, 1)}-0:1→0>", "]"),
-				RecordsShortStr(ts[1].Logs, ""),
+				RecordsShortStr(logsOf(ts[1]), ""),
 			)
 
-			reply = sendPropose(mems[1].Addr, "y=2")
-			ta.Equal(&ProposeReply{OK: true, OtherLeader: nil}, reply)
+			// the result of `set` is the previous value
+			prev, err := NewCmdI64("set", "y", 1).Marshal()
+			ta.Nil(err)
+
+			reply = sendProposeApplied(mems[1].Addr, "y=2")
+			ta.Equal(&ProposeReply{
+				OK:        true,
+				Result:    prev,
+				Seq:       1,
+				Committer: lid(4, 1),
+			}, reply)
 
-			ta.Equal(bm(2), ts[1].Status[1].Accepted)
-			ta.Equal(bm(2), ts[1].Status[1].Committed)
+			ta.Equal(bm(2), statusOf(ts[1], 1).Accepted)
+			ta.Equal(bm(2), statusOf(ts[1], 1).Committed)
+			ta.Equal(bm(2), statusOf(ts[1], 1).Applied)
 			ta.Equal(
 				join("[<004#001:000{set(y, 1)}-0:1→0>",
 					"<004#001:001{set(y, 2)}-0:3→0>",
 					"]"),
-				RecordsShortStr(ts[1].Logs, ""),
+				RecordsShortStr(logsOf(ts[1]), ""),
 			)
 
 			reply = sendPropose(mems[1].Addr, "x=3")
-			ta.Equal(&ProposeReply{OK: true, OtherLeader: nil}, reply)
+			ta.Equal(&ProposeReply{OK: true, Seq: 2, Committer: lid(4, 1)}, reply)
 
-			ta.Equal(bm(3), ts[1].Status[1].Accepted)
+			ta.Equal(bm(3), statusOf(ts[1], 1).Accepted)
 			ta.Equal(
 				join("[<004#001:000{set(y, 1)}-0:1→0>",
 					"<004#001:001{set(y, 2)}-0:3→0>",
 					"<004#001:002{set(x, 3)}-0:4→0>",
 					"]"),
-				RecordsShortStr(ts[1].Logs, ""),
+				RecordsShortStr(logsOf(ts[1]), ""),
 			)
 		})
 }
@@ -677,13 +1028,18 @@ func TestTRaft_LogForward(t *testing.T) {
 
 	// init cluster
 	// give ts[1] a highest term thus to be a leader
-	ts[0].initTraft(lid(2, 0), lid(0, 1), []int64{}, nil, nil, lid(3, 0))
-	ts[1].initTraft(lid(3, 1), lid(0, 1), []int64{}, nil, nil, lid(5, 1))
-	ts[2].initTraft(lid(1, 2), lid(0, 1), []int64{}, nil, []int64{0}, lid(2, 2))
-
-	ts[0].addlogs()
-	ts[1].addlogs("x=0", "y=1", "x=2")
-	ts[2].addlogs("", "y=5")
+	inLoop(ts[0], func() {
+		ts[0].initTraft(lid(2, 0), lid(0, 1), []int64{}, nil, nil, lid(3, 0))
+		ts[0].addlogs()
+	})
+	inLoop(ts[1], func() {
+		ts[1].initTraft(lid(3, 1), lid(0, 1), []int64{}, nil, nil, lid(5, 1))
+		ts[1].addlogs("x=0", "y=1", "x=2")
+	})
+	inLoop(ts[2], func() {
+		ts[2].initTraft(lid(1, 2), lid(0, 1), []int64{}, nil, []int64{0}, lid(2, 2))
+		ts[2].addlogs("", "y=5")
+	})
 
 	sendLogForward := func(addr string, req *LogForwardReq) *LogForwardReply {
 		var reply *LogForwardReply
@@ -697,7 +1053,7 @@ func TestTRaft_LogForward(t *testing.T) {
 		return reply
 	}
 
-	logs := ts[1].Logs
+	logs := logsOf(ts[1])
 
 	sec1k := int64(time.Second * 1000)
 	cases := []struct {
@@ -756,12 +1112,14 @@ func TestTRaft_LogForward(t *testing.T) {
 		t.Run(
 			fmt.Sprintf("%d-to-%d/%s", 1, c.to, c.name),
 			func(t *testing.T) {
-				dst := ts[c.to].Status[c.to]
-				dst.VotedFor = c.votedFor
-				dst.VoteExpireAt = uSecondI64() + c.expire
+				inLoop(ts[c.to], func() {
+					dst := ts[c.to].Status[c.to]
+					dst.VotedFor = c.votedFor
+					dst.VoteExpireAt = uSecondI64() + c.expire
 
-				fmt.Println(ts[c.to].Node)
-				ts[c.to].checkStatus()
+					fmt.Println(ts[c.to].Node)
+					ts[c.to].checkStatus()
+				})
 
 				addr := ts[1].Config.Members[c.to].Addr
 				repl := sendLogForward(addr, &LogForwardReq{
@@ -773,15 +1131,15 @@ func TestTRaft_LogForward(t *testing.T) {
 				ta.Equal(c.wantVotedFor, repl.VotedFor)
 				if c.wantAccepted != nil {
 					ta.True(c.wantAccepted.Equal(repl.Accepted))
-					ta.True(c.wantAccepted.Equal(dst.Accepted))
+					ta.True(c.wantAccepted.Equal(statusOf(ts[c.to], c.to).Accepted))
 				}
 				if c.wantCommitted != nil {
 					ta.True(c.wantCommitted.Equal(repl.Committed))
-					ta.True(c.wantCommitted.Equal(dst.Committed))
+					ta.True(c.wantCommitted.Equal(statusOf(ts[c.to], c.to).Committed))
 				}
 				if c.wantLogs != nil {
 					ta.Equal("["+join(c.wantLogs...)+"]",
-						RecordsShortStr(ts[c.to].Logs, ""))
+						RecordsShortStr(logsOf(ts[c.to]), ""))
 				}
 			})
 	}
@@ -800,7 +1158,7 @@ func TestTRaft_AddLog_nil(t *testing.T) {
 		"[<000#001:000{set(x, 1)}-0:1→0>",
 		"<000#001:001{set(y, 1)}-0:2→0>",
 		"<>",
-		"<000#001:003{set(x, 1)}-0:9→0>]"), RecordsShortStr(tr.Logs, ""))
+		"<000#001:003{set(x, 1)}-0:9→0>]"), RecordsShortStr(tr.allLogs(), ""))
 }
 
 func TestTRaft_AddLog(t *testing.T) {
@@ -811,18 +1169,18 @@ func TestTRaft_AddLog(t *testing.T) {
 	tr := NewTRaft(id, map[int64]string{id: "123"})
 
 	tr.AddLog(NewCmdI64("set", "x", 1))
-	ta.Equal("[<000#001:000{set(x, 1)}-0:1→0>]", RecordsShortStr(tr.Logs))
+	ta.Equal("[<000#001:000{set(x, 1)}-0:1→0>]", RecordsShortStr(tr.allLogs()))
 
 	tr.AddLog(NewCmdI64("set", "y", 1))
 	ta.Equal(join(
 		"[<000#001:000{set(x, 1)}-0:1→0>",
-		"<000#001:001{set(y, 1)}-0:2→0>]"), RecordsShortStr(tr.Logs, ""))
+		"<000#001:001{set(y, 1)}-0:2→0>]"), RecordsShortStr(tr.allLogs(), ""))
 
 	tr.AddLog(NewCmdI64("set", "x", 1))
 	ta.Equal(join(
 		"[<000#001:000{set(x, 1)}-0:1→0>",
 		"<000#001:001{set(y, 1)}-0:2→0>",
-		"<000#001:002{set(x, 1)}-0:5→0>]"), RecordsShortStr(tr.Logs, ""))
+		"<000#001:002{set(x, 1)}-0:5→0>]"), RecordsShortStr(tr.allLogs(), ""))
 
 	varnames := "wxyz"
 
@@ -830,20 +1188,17 @@ func TestTRaft_AddLog(t *testing.T) {
 		vi := i % len(varnames)
 		tr.AddLog(NewCmdI64("set", varnames[vi:vi+1], int64(i)))
 	}
-	l := len(tr.Logs)
-	ta.Equal("<000#001:069{set(y, 66)}-0:2222222222222222:22→0>", tr.Logs[l-1].ShortStr())
+	ta.Equal("<000#001:069{set(y, 66)}-0:2222222222222222:22→0>", tr.logs.Get(tr.logs.LastIndex()).ShortStr())
 
 	// truncate some logs, then add another 67
 	// To check Overrides and Depends
 
-	tr.LogOffset = 65
-	tr.Logs = tr.Logs[65:]
+	tr.logs.Reclaim(65)
 
 	for i := 0; i < 67; i++ {
 		vi := i % len(varnames)
 		tr.AddLog(NewCmdI64("set", varnames[vi:vi+1], 100+int64(i)))
 	}
-	l = len(tr.Logs)
-	ta.Equal("<000#001:136{set(y, 166)}-64:1111111111111122:111→64:1>", tr.Logs[l-1].ShortStr())
+	ta.Equal("<000#001:136{set(y, 166)}-64:1111111111111122:111→64:1>", tr.logs.Get(tr.logs.LastIndex()).ShortStr())
 
 }
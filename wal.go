@@ -0,0 +1,360 @@
+package traft
+
+import (
+	"encoding/binary"
+	fmt "fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// walSegmentSize is the size in byte a WAL segment grows to before a new
+// segment is created.
+var walSegmentSize = int64(64 * 1024 * 1024)
+
+const (
+	walSuffix = ".wal"
+
+	// frame header: 4 bytes payload length, 4 bytes crc32 of type+payload and
+	// 1 byte entry type.
+	walHeaderSize = 9
+)
+
+// types of WAL entries.
+const (
+	// a Record is written(or overridden if it has the same Seq).
+	// A Record with nil Cmd erases a previously written one.
+	walRecord = byte(1)
+
+	// the Committed bitmap of this replica.
+	walCommitted = byte(2)
+
+	// records at and after a lsn are removed.
+	walTruncate = byte(3)
+
+	// records before a lsn are removed.
+	walReclaim = byte(4)
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WAL is a segmented write-ahead log on disk.
+//
+// Every entry is framed as:
+//
+//	length(4) crc32(4) type(1) payload(length)
+//
+// Segment files are named by an increasing segment id.
+// An entry is only appended to the last segment.
+// A partially written entry at the end of the last segment, e.g., because of a
+// crash, is truncated when the WAL is opened.
+type WAL struct {
+	dir string
+
+	// ids of all segments, in ascending order.
+	segs []int64
+
+	// the last segment for appending.
+	f    *os.File
+	size int64
+}
+
+// OpenWAL opens the WAL in dir, or creates an empty one if there is none.
+func OpenWAL(dir string) (*WAL, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "mkdir %s", dir)
+	}
+
+	segs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAL{
+		dir:  dir,
+		segs: segs,
+	}
+
+	if len(segs) == 0 {
+		err = w.newSegment(0)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	last := segs[len(segs)-1]
+	path := w.segPath(last)
+
+	// find the end of the last valid entry, drop the torn tail.
+	valid, err := readSegment(path, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+
+	err = f.Truncate(valid)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "truncate %s", path)
+	}
+
+	_, err = f.Seek(valid, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "seek %s", path)
+	}
+
+	w.f = f
+	w.size = valid
+	return w, nil
+}
+
+// ReadAll calls fn with every entry in the WAL, from the oldest to the newest,
+// along with the id of the segment an entry is in.
+func (w *WAL) ReadAll(fn func(seg int64, typ byte, payload []byte) error) error {
+	for i, id := range w.segs {
+		isLast := i == len(w.segs)-1
+		seg := id
+		_, err := readSegment(w.segPath(id), isLast,
+			func(typ byte, payload []byte) error {
+				return fn(seg, typ, payload)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendRecords writes records to the WAL.
+// The records are not durable until Sync() returns.
+func (w *WAL) AppendRecords(recs ...*Record) error {
+	for _, r := range recs {
+		b, err := r.Marshal()
+		if err != nil {
+			return errors.Wrapf(err, "marshal record %s", r.ShortStr())
+		}
+
+		err = w.append(walRecord, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendCommitted writes the Committed bitmap to the WAL.
+func (w *WAL) AppendCommitted(committed *TailBitmap) error {
+	b, err := committed.Marshal()
+	if err != nil {
+		return errors.Wrapf(err, "marshal committed")
+	}
+	return w.append(walCommitted, b)
+}
+
+// AppendLsn writes an entry with a lsn as payload, such as walTruncate.
+func (w *WAL) AppendLsn(typ byte, lsn int64) error {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(lsn))
+	return w.append(typ, b)
+}
+
+// LastSegment returns the id of the segment for appending.
+func (w *WAL) LastSegment() int64 {
+	return w.segs[len(w.segs)-1]
+}
+
+// Segments returns ids of all segments in ascending order.
+func (w *WAL) Segments() []int64 {
+	segs := make([]int64, len(w.segs))
+	copy(segs, w.segs)
+	return segs
+}
+
+// RemoveFirstSegment removes the oldest segment.
+// The last segment can not be removed.
+func (w *WAL) RemoveFirstSegment() error {
+	if len(w.segs) <= 1 {
+		return errors.Errorf("can not remove the last segment")
+	}
+
+	path := w.segPath(w.segs[0])
+	err := os.Remove(path)
+	if err != nil {
+		return errors.Wrapf(err, "remove %s", path)
+	}
+	w.segs = w.segs[1:]
+
+	return syncDir(w.dir)
+}
+
+// Sync flushes all appended entries to disk.
+func (w *WAL) Sync() error {
+	err := w.f.Sync()
+	return errors.Wrapf(err, "sync %s", w.f.Name())
+}
+
+func (w *WAL) Close() error {
+	err := w.f.Close()
+	return errors.Wrapf(err, "close %s", w.f.Name())
+}
+
+func (w *WAL) append(typ byte, payload []byte) error {
+
+	if w.size >= walSegmentSize {
+		err := w.rollover()
+		if err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	buf[8] = typ
+	copy(buf[walHeaderSize:], payload)
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(buf[8:], crcTable))
+
+	n, err := w.f.Write(buf)
+	w.size += int64(n)
+	return errors.Wrapf(err, "write %s", w.f.Name())
+}
+
+// rollover syncs the current segment and starts a new one.
+func (w *WAL) rollover() error {
+	err := w.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return w.newSegment(w.segs[len(w.segs)-1] + 1)
+}
+
+func (w *WAL) newSegment(id int64) error {
+	path := w.segPath(id)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "create %s", path)
+	}
+
+	err = syncDir(w.dir)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.segs = append(w.segs, id)
+	w.f = f
+	w.size = 0
+	return nil
+}
+
+func (w *WAL) segPath(id int64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%016d%s", id, walSuffix))
+}
+
+func listSegments(dir string) ([]int64, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "readdir %s", dir)
+	}
+
+	segs := []int64{}
+	for _, fi := range fis {
+		name := fi.Name()
+		if !strings.HasSuffix(name, walSuffix) {
+			continue
+		}
+
+		var id int64
+		_, err := fmt.Sscanf(strings.TrimSuffix(name, walSuffix), "%d", &id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid segment name %s", name)
+		}
+		segs = append(segs, id)
+	}
+
+	sort.Slice(segs, func(i, j int) bool {
+		return segs[i] < segs[j]
+	})
+
+	return segs, nil
+}
+
+// readSegment reads entries in a segment and returns the size of all valid
+// entries.
+// A broken entry is treated as the end of segment if allowTorn is true,
+// otherwise an error is returned.
+func readSegment(
+	path string,
+	allowTorn bool,
+	fn func(typ byte, payload []byte) error,
+) (int64, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "read %s", path)
+	}
+
+	pos := int64(0)
+	for pos < int64(len(data)) {
+		rest := data[pos:]
+
+		if len(rest) < walHeaderSize {
+			break
+		}
+
+		l := int64(binary.LittleEndian.Uint32(rest[0:4]))
+		if int64(len(rest)) < walHeaderSize+l {
+			break
+		}
+
+		crc := binary.LittleEndian.Uint32(rest[4:8])
+		body := rest[8 : walHeaderSize+l]
+		if crc32.Checksum(body, crcTable) != crc {
+			break
+		}
+
+		if fn != nil {
+			err := fn(body[0], body[1:])
+			if err != nil {
+				return pos, err
+			}
+		}
+
+		pos += walHeaderSize + l
+	}
+
+	if pos != int64(len(data)) && !allowTorn {
+		return pos, errors.Errorf("broken WAL entry at %s:%d", path, pos)
+	}
+
+	return pos, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return errors.Wrapf(err, "open dir %s", dir)
+	}
+	defer d.Close()
+
+	err = d.Sync()
+	return errors.Wrapf(err, "sync dir %s", dir)
+}
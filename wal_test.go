@@ -0,0 +1,146 @@
+package traft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tmpDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "traft-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func readWALRecords(w *WAL) ([]string, error) {
+	rst := []string{}
+	err := w.ReadAll(func(seg int64, typ byte, payload []byte) error {
+		if typ != walRecord {
+			return nil
+		}
+		r := &Record{}
+		err := r.Unmarshal(payload)
+		rst = append(rst, r.ShortStr())
+		return err
+	})
+	return rst, err
+}
+
+func TestWAL_AppendAndReopen(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+
+	w, err := OpenWAL(dir)
+	ta.Nil(err)
+
+	err = w.AppendRecords(
+		NewRecord(lid(1, 2), 0, NewCmdI64("set", "x", 1)),
+		NewRecord(lid(1, 2), 1, NewCmdI64("set", "y", 2)),
+	)
+	ta.Nil(err)
+	ta.Nil(w.AppendCommitted(NewTailBitmap(0, 0)))
+	ta.Nil(w.Sync())
+	ta.Nil(w.Close())
+
+	w, err = OpenWAL(dir)
+	ta.Nil(err)
+	defer w.Close()
+
+	got, err := readWALRecords(w)
+	ta.Nil(err)
+	ta.Equal([]string{
+		"<001#002:000{set(x, 1)}-0→0>",
+		"<001#002:001{set(y, 2)}-0→0>",
+	}, got)
+
+	// append after reopen
+	ta.Nil(w.AppendRecords(NewRecord(lid(1, 2), 2, NewCmdI64("set", "z", 3))))
+	got, err = readWALRecords(w)
+	ta.Nil(err)
+	ta.Equal(3, len(got))
+}
+
+func TestWAL_TornTail(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+
+	w, err := OpenWAL(dir)
+	ta.Nil(err)
+	ta.Nil(w.AppendRecords(
+		NewRecord(lid(1, 2), 0, NewCmdI64("set", "x", 1)),
+		NewRecord(lid(1, 2), 1, NewCmdI64("set", "y", 2)),
+	))
+	ta.Nil(w.Close())
+
+	// simulate a crash in the middle of writing the second entry
+	path := w.segPath(0)
+	fi, err := os.Stat(path)
+	ta.Nil(err)
+	ta.Nil(os.Truncate(path, fi.Size()-3))
+
+	w, err = OpenWAL(dir)
+	ta.Nil(err)
+
+	got, err := readWALRecords(w)
+	ta.Nil(err)
+	ta.Equal([]string{"<001#002:000{set(x, 1)}-0→0>"}, got)
+
+	// new entry is appended right after the last valid one.
+	ta.Nil(w.AppendRecords(NewRecord(lid(1, 2), 1, NewCmdI64("set", "y", 3))))
+	ta.Nil(w.Close())
+
+	w, err = OpenWAL(dir)
+	ta.Nil(err)
+	defer w.Close()
+
+	got, err = readWALRecords(w)
+	ta.Nil(err)
+	ta.Equal([]string{
+		"<001#002:000{set(x, 1)}-0→0>",
+		"<001#002:001{set(y, 3)}-0→0>",
+	}, got)
+}
+
+func TestWAL_Segments(t *testing.T) {
+
+	ta := require.New(t)
+
+	defer func(sz int64) { walSegmentSize = sz }(walSegmentSize)
+	walSegmentSize = 64
+
+	dir := tmpDir(t)
+	lid := NewLeaderId
+
+	w, err := OpenWAL(dir)
+	ta.Nil(err)
+
+	for i := int64(0); i < 10; i++ {
+		ta.Nil(w.AppendRecords(NewRecord(lid(1, 2), i, NewCmdI64("set", "x", i))))
+	}
+	ta.Nil(w.Close())
+
+	segs, err := filepath.Glob(filepath.Join(dir, "*"+walSuffix))
+	ta.Nil(err)
+	ta.True(len(segs) > 1, "segments: %v", segs)
+
+	w, err = OpenWAL(dir)
+	ta.Nil(err)
+	defer w.Close()
+
+	got, err := readWALRecords(w)
+	ta.Nil(err)
+	ta.Equal(10, len(got))
+	ta.Equal("<001#002:009{set(x, 9)}-0→0>", got[9])
+}